@@ -0,0 +1,148 @@
+package duplicates
+
+import (
+	"reflect"
+)
+
+type arraySliceKey struct {
+	elemType reflect.Type
+	address  uintptr
+}
+
+// Returns the element type of a slice or array pointer type.
+func arraySliceElem(t reflect.Type) (elemType reflect.Type, ok bool) {
+	switch t.Kind() {
+	case reflect.Slice:
+		return t.Elem(), true
+	case reflect.Ptr:
+		if t.Elem().Kind() == reflect.Array {
+			return t.Elem().Elem(), true
+		}
+	}
+	return nil, false
+}
+
+// ArraySliceAlias is a pointer to an array (*[N]T) and a slice ([]T) that
+// refer to the same memory, found with MatchArraySliceAliases. Since their
+// types differ, they are distinct TypedPointers despite aliasing each other.
+type ArraySliceAlias struct {
+	Array TypedPointer
+	Slice TypedPointer
+}
+
+// ArraySliceAliases returns every array pointer and slice that were found to
+// alias each other, in the order they were found. This only works if the
+// finder had MatchArraySliceAliases set while scanning.
+func (_this *DuplicateFinder) ArraySliceAliases() (aliases []ArraySliceAlias) {
+	for _, alias := range _this.arraySlicePairs {
+		// Skip aliases that were forgotten when a scope was popped.
+		if _, ok := _this.recordIndex[alias.Array]; !ok {
+			continue
+		}
+		if _, ok := _this.recordIndex[alias.Slice]; !ok {
+			continue
+		}
+		aliases = append(aliases, alias)
+	}
+	return
+}
+
+// Marks typedPtr and any previously registered slice or array pointer of the
+// other kind sharing its data address as duplicates.
+func (_this *DuplicateFinder) matchArraySliceAliases(typedPtr TypedPointer) {
+	elemType, ok := arraySliceElem(typedPtr.Type)
+	if !ok {
+		return
+	}
+	if _this.arraySliceAliases == nil {
+		_this.arraySliceAliases = make(map[arraySliceKey][]TypedPointer)
+	}
+
+	key := arraySliceKey{elemType: elemType, address: typedPtr.Address()}
+	for _, other := range _this.arraySliceAliases[key] {
+		if other.Type.Kind() == typedPtr.Type.Kind() {
+			continue
+		}
+		// Skip aliases that were forgotten when a scope was popped.
+		if _, ok := _this.recordIndex[other]; !ok {
+			continue
+		}
+		_this.DuplicatePointers[other] = true
+		_this.DuplicatePointers[typedPtr] = true
+		alias := ArraySliceAlias{Array: other, Slice: typedPtr}
+		if typedPtr.Type.Kind() == reflect.Ptr {
+			alias = ArraySliceAlias{Array: typedPtr, Slice: other}
+		}
+		_this.arraySlicePairs = append(_this.arraySlicePairs, alias)
+	}
+	_this.arraySliceAliases[key] = append(_this.arraySliceAliases[key], typedPtr)
+}
+
+// SharesMemory scans a and b, and reports whether any object is reachable
+// from both, which for example shows whether a deep copy is truly detached
+// from its original. Only the outermost shared objects are returned (in
+// discovery order), since everything reachable from them is shared as well.
+// Pointers to zero-sized objects are ignored, since the runtime may give
+// distinct zero-sized objects the same address.
+func SharesMemory(a, b interface{}) (sharesMemory bool, shared []TypedPointer) {
+	finder := NewDuplicateFinder()
+	finder.ZeroSizedPointers = ZeroSizeIgnore
+	finder.ScanForPointers(a)
+	finder.ScanForPointers(b)
+	for _, record := range finder.records {
+		if record.crossRoot {
+			shared = append(shared, record.pointer)
+		}
+	}
+	return len(shared) > 0, shared
+}
+
+// SharedHeader describes a field of two struct values that still references
+// the same underlying data.
+type SharedHeader struct {
+	// The name of the field, with embedded and nested struct fields
+	// separated by dots.
+	Field   string
+	Pointer TypedPointer
+}
+
+// SharedHeaders compares the map, slice, pointer, chan, and func fields of two
+// struct values (typically an original and its shallow copy) and reports
+// which of them still reference the same underlying data. Fields of nested
+// (non-pointer) structs are compared as well. a and b may be structs or
+// pointers to structs, and nothing is reported unless they are of the same
+// struct type.
+func SharedHeaders(a, b interface{}) (shared []SharedHeader) {
+	aValue := reflect.Indirect(reflect.ValueOf(a))
+	bValue := reflect.Indirect(reflect.ValueOf(b))
+	if aValue.Kind() != reflect.Struct || !bValue.IsValid() || aValue.Type() != bValue.Type() {
+		return nil
+	}
+	return appendSharedHeaders(shared, "", aValue, bValue)
+}
+
+func appendSharedHeaders(shared []SharedHeader, prefix string, a, b reflect.Value) []SharedHeader {
+	for i := 0; i < a.NumField(); i++ {
+		name := prefix + a.Type().Field(i).Name
+		aField := a.Field(i)
+		bField := b.Field(i)
+		switch aField.Kind() {
+		case reflect.Struct:
+			shared = appendSharedHeaders(shared, name+".", aField, bField)
+		case reflect.Map, reflect.Slice, reflect.Ptr, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+			if aField.IsNil() || bField.IsNil() {
+				continue
+			}
+			if aField.Kind() == reflect.Slice && (aField.Cap() == 0 || bField.Cap() == 0) {
+				continue
+			}
+			if aField.Pointer() == bField.Pointer() {
+				shared = append(shared, SharedHeader{
+					Field:   name,
+					Pointer: TypedPointerOfRV(aField),
+				})
+			}
+		}
+	}
+	return shared
+}
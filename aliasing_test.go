@@ -0,0 +1,152 @@
+package duplicates
+
+import (
+	"testing"
+	"unsafe"
+)
+
+type arraySliceAliasStruct struct {
+	Array *[4]int
+	Slice []int
+	Other []int
+}
+
+func TestMatchArraySliceAliases(t *testing.T) {
+	array := [4]int{1, 2, 3, 4}
+	v := &arraySliceAliasStruct{
+		Array: &array,
+		Slice: array[:],
+		Other: array[1:],
+	}
+
+	assertNoDuplicates(t, v)
+
+	finder := NewDuplicateFinder()
+	finder.MatchArraySliceAliases = true
+	finder.ScanForPointers(v)
+	if !finder.IsDuplicatePointer(v.Array) {
+		t.Errorf("Expected the array pointer to be a duplicate")
+	}
+	if !finder.IsDuplicatePointer(v.Slice) {
+		t.Errorf("Expected the slice to be a duplicate")
+	}
+	if finder.IsDuplicatePointer(v.Other) {
+		t.Errorf("Expected the offset slice to not be a duplicate")
+	}
+	expected := ArraySliceAlias{Array: TypedPointerOf(v.Array), Slice: TypedPointerOf(v.Slice)}
+	if aliases := finder.ArraySliceAliases(); len(aliases) != 1 || aliases[0] != expected {
+		t.Errorf("Expected %v but got %v", expected, aliases)
+	}
+}
+
+func TestArraySliceAliasesConverted(t *testing.T) {
+	slice := []int{1, 2, 3}
+	// Equivalent to (*[3]int)(slice), which requires Go 1.17.
+	array := (*[3]int)(unsafe.Pointer(&slice[0]))
+
+	finder := NewDuplicateFinder(WithArraySliceAliases())
+	finder.ScanForPointers(slice)
+	finder.PushScope()
+	finder.ScanForPointers(array)
+	expected := ArraySliceAlias{Array: TypedPointerOf(array), Slice: TypedPointerOf(slice)}
+	if aliases := finder.ArraySliceAliases(); len(aliases) != 1 || aliases[0] != expected {
+		t.Errorf("Expected %v but got %v", expected, aliases)
+	}
+	finder.PopScope()
+	if aliases := finder.ArraySliceAliases(); len(aliases) != 0 {
+		t.Errorf("Expected the alias to be forgotten with its scope but got %v", aliases)
+	}
+}
+
+type shallowCopyNested struct {
+	Tags []string
+}
+
+type shallowCopyStruct struct {
+	Name    string
+	Values  map[string]int
+	Items   []int
+	Parent  *shallowCopyStruct
+	private []byte
+	Nested  shallowCopyNested
+}
+
+func TestSharedHeaders(t *testing.T) {
+	original := &shallowCopyStruct{
+		Name:    "original",
+		Values:  map[string]int{"a": 1},
+		Items:   []int{1, 2, 3},
+		Parent:  &shallowCopyStruct{},
+		private: []byte{1},
+		Nested:  shallowCopyNested{Tags: []string{"x"}},
+	}
+	shallow := *original
+	shallow.Items = append([]int{}, original.Items...)
+
+	shared := SharedHeaders(original, &shallow)
+	expected := []string{"Values", "Parent", "private", "Nested.Tags"}
+	if len(shared) != len(expected) {
+		t.Fatalf("Expected %v but got %v", expected, shared)
+	}
+	for i, header := range shared {
+		if header.Field != expected[i] {
+			t.Errorf("Expected %v but got %v", expected[i], header.Field)
+		}
+	}
+	if shared[0].Pointer != TypedPointerOf(original.Values) {
+		t.Errorf("Expected pointer %v but got %v", TypedPointerOf(original.Values), shared[0].Pointer)
+	}
+}
+
+func TestSharedHeadersMismatched(t *testing.T) {
+	var nilStruct *shallowCopyStruct
+	pairs := [][2]interface{}{
+		{1, 1},
+		{&shallowCopyStruct{}, shallowCopyNested{}},
+		{&shallowCopyStruct{}, nilStruct},
+		{nil, nil},
+	}
+	for i, pair := range pairs {
+		if shared := SharedHeaders(pair[0], pair[1]); shared != nil {
+			t.Errorf("Pair %v: Expected nothing but got %v", i, shared)
+		}
+	}
+}
+
+type sharesMemoryConfig struct {
+	Name    string
+	Servers []*sharesMemoryServer
+	Empty   *struct{}
+}
+
+type sharesMemoryServer struct {
+	Host string
+	Tags map[string]string
+}
+
+func TestSharesMemory(t *testing.T) {
+	original := &sharesMemoryConfig{
+		Name:    "original",
+		Servers: []*sharesMemoryServer{{Host: "a", Tags: map[string]string{"x": "y"}}},
+		Empty:   &struct{}{},
+	}
+
+	deepCopy := &sharesMemoryConfig{
+		Name:    original.Name,
+		Servers: []*sharesMemoryServer{{Host: "a", Tags: map[string]string{"x": "y"}}},
+		Empty:   &struct{}{},
+	}
+	if sharesMemory, shared := SharesMemory(original, deepCopy); sharesMemory {
+		t.Errorf("Expected a deep copy to share nothing but got %v", shared)
+	}
+
+	server := *original.Servers[0]
+	partialCopy := &sharesMemoryConfig{
+		Name:    original.Name,
+		Servers: []*sharesMemoryServer{&server},
+	}
+	sharesMemory, shared := SharesMemory(original, partialCopy)
+	if !sharesMemory || len(shared) != 1 || shared[0] != TypedPointerOf(original.Servers[0].Tags) {
+		t.Errorf("Expected only the Tags map to be shared but got %v", shared)
+	}
+}
@@ -0,0 +1,93 @@
+package duplicates
+
+// PathsTo scans root and returns the paths from root to target, stopping once
+// limit paths have been found (or finding all of them if limit is 0). Unlike
+// PathTo, which only returns where target was first found, this includes every
+// route through shared objects, which helps explain why target is shared more
+// widely than expected. Paths that run through the same object twice (around
+// a cycle) are left out. The path at which target was first found comes first.
+func PathsTo(root interface{}, target TypedPointer, limit int) (paths []Path) {
+	finder := NewDuplicateFinder()
+	finder.RecordPaths = true
+
+	type sighting struct {
+		pathNode int32
+		// The record of the innermost referent being scanned, or -1.
+		parent int
+	}
+	sightings := make(map[int][]sighting)
+	finder.sightingHook = func(index int) {
+		parent := -1
+		if len(finder.referents) > 0 {
+			parent = finder.referents[len(finder.referents)-1].index
+		}
+		sightings[index] = append(sightings[index], sighting{pathNode: finder.pathNode, parent: parent})
+	}
+	finder.ScanForPointers(root)
+	targetIndex, ok := finder.recordIndex[target]
+	if !ok {
+		return
+	}
+
+	// Referents are only scanned from where they were first found, so
+	// everything found within one was found on a path through its first
+	// sighting. The search runs from target back towards root, on an explicit
+	// stack so that long chains don't exhaust the goroutine stack.
+	type frame struct {
+		index int
+		// The next of the record's sightings to follow.
+		next int
+		// The steps from this record's referent to that of the frame below.
+		steps []PathStep
+	}
+	var arena []pathNode
+	visiting := map[int]bool{targetIndex: true}
+	frames := []frame{{index: targetIndex}}
+	for len(frames) > 0 {
+		top := &frames[len(frames)-1]
+		if top.next >= len(sightings[top.index]) || (limit > 0 && len(paths) >= limit) {
+			visiting[top.index] = false
+			frames = frames[:len(frames)-1]
+			continue
+		}
+		s := sightings[top.index][top.next]
+		top.next++
+		if s.parent < 0 {
+			node := noPathNode
+			appendSteps := func(steps []PathStep) {
+				for _, step := range steps {
+					arena = append(arena, pathNode{parent: node, step: step})
+					node = int32(len(arena) - 1)
+				}
+			}
+			appendSteps(finder.pathAt(s.pathNode).Steps())
+			for i := len(frames) - 1; i >= 0; i-- {
+				appendSteps(frames[i].steps)
+			}
+			paths = append(paths, Path{nodes: arena, node: node})
+			continue
+		}
+		if visiting[s.parent] {
+			continue
+		}
+		visiting[s.parent] = true
+		frames = append(frames, frame{
+			index: s.parent,
+			steps: finder.stepsBetween(finder.pathsOfRecord(s.parent).first, s.pathNode),
+		})
+	}
+	return
+}
+
+// Returns the steps leading from the path node ancestor to the path node
+// node, which must be below it.
+func (_this *DuplicateFinder) stepsBetween(ancestor, node int32) []PathStep {
+	var steps []PathStep
+	for ; node != ancestor; node = _this.pathNodes[node].parent {
+		steps = append(steps, _this.pathNodes[node].step)
+	}
+	for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+	}
+	return steps
+}
@@ -0,0 +1,53 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+)
+
+type allPathsNode struct {
+	Name     string
+	Children []*allPathsNode
+	Next     *allPathsNode
+}
+
+func describePaths(paths []Path) []string {
+	described := []string{}
+	for _, path := range paths {
+		described = append(described, path.String())
+	}
+	return described
+}
+
+func TestPathsTo(t *testing.T) {
+	target := &allPathsNode{Name: "target"}
+	shared := &allPathsNode{Name: "shared", Children: []*allPathsNode{target}}
+	root := &allPathsNode{
+		Children: []*allPathsNode{shared, {Next: shared}, {Next: target}},
+	}
+	target.Next = root
+
+	actual := describePaths(PathsTo(root, TypedPointerOf(target), 0))
+	expected := []string{
+		".Children[0].Children[0]",
+		".Children[1].Next.Children[0]",
+		".Children[2].Next",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v but got %v", expected, actual)
+	}
+
+	actual = describePaths(PathsTo(root, TypedPointerOf(target), 2))
+	if !reflect.DeepEqual(actual, expected[:2]) {
+		t.Errorf("Expected %v but got %v", expected[:2], actual)
+	}
+
+	actual = describePaths(PathsTo(root, TypedPointerOf(&target.Name), 0))
+	if len(actual) != 3 || actual[1] != ".Children[1].Next.Children[0].Name" {
+		t.Errorf("Expected the paths to target's field but got %v", actual)
+	}
+
+	if paths := PathsTo(root, TypedPointerOf(&allPathsNode{}), 0); len(paths) != 0 {
+		t.Errorf("Expected no paths to an object not in root but got %v", describePaths(paths))
+	}
+}
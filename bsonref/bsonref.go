@@ -0,0 +1,203 @@
+// Package bsonref prepares values containing shared objects for encoding to
+// BSON or Extended JSON, where shared subdocuments would otherwise be silently
+// duplicated.
+//
+// Values are converted into a tree of ordered Documents (with the same shape
+// as the Mongo driver's bson.D), slices, and primitives. The first occurrence
+// of a shared object gets an extra leading "$id" element (or, if it isn't a
+// document, is wrapped as {"$id": N, "$value": ...}), and every later
+// occurrence is replaced with the DBRef-like {"$ref": N}.
+package bsonref
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	duplicates "github.com/kstenerud/go-duplicates"
+)
+
+// Element is a single key-value pair in a Document.
+type Element struct {
+	Key   string
+	Value interface{}
+}
+
+// Document is an ordered BSON document.
+type Document []Element
+
+// Converter converts values into reference-aware document trees.
+type Converter struct {
+	// The keys used to mark shared objects.
+	IDKey    string
+	RefKey   string
+	ValueKey string
+
+	table   *duplicates.DuplicateTable
+	session *duplicates.EncodingSession
+}
+
+// NewConverter scans value for duplicates and returns a Converter for it.
+func NewConverter(value interface{}) *Converter {
+	return NewConverterFromTable(duplicates.FindDuplicatesTable(value))
+}
+
+// NewConverterFromTable returns a Converter that assigns IDs from an existing
+// table.
+func NewConverterFromTable(table *duplicates.DuplicateTable) *Converter {
+	return &Converter{
+		IDKey:    "$id",
+		RefKey:   "$ref",
+		ValueKey: "$value",
+		table:    table,
+		session:  duplicates.NewEncodingSession(),
+	}
+}
+
+// Convert scans value for duplicates, and converts it into a reference-aware
+// document tree.
+func Convert(value interface{}) (interface{}, error) {
+	return NewConverter(value).Convert(value)
+}
+
+// Substitute decides what to do with a pointer, map, or slice. If it has
+// already been converted, replacement is a reference document and
+// isReference is true. Otherwise id is the ID that must be attached to it, or
+// -1 if it isn't shared.
+func (_this *Converter) Substitute(value reflect.Value) (replacement Document, isReference bool, id int) {
+	id, isDuplicate := _this.table.MarkerIDOfRV(value)
+	if !isDuplicate {
+		return nil, false, -1
+	}
+	if action, backReference := _this.session.Encounter(value, id); action == duplicates.EmitBackReference {
+		return Document{{Key: _this.RefKey, Value: backReference}}, true, backReference
+	}
+	return nil, false, id
+}
+
+// Convert converts value into a tree of Documents, []interface{}, and
+// primitives, substituting references for shared objects. Struct fields are
+// named according to their "bson" tag, or their lowercased name if untagged.
+func (_this *Converter) Convert(value interface{}) (interface{}, error) {
+	return _this.convert(reflect.ValueOf(value))
+}
+
+func (_this *Converter) convert(rv reflect.Value) (interface{}, error) {
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil, nil
+	case reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return _this.convert(rv.Elem())
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			return rv.Bytes(), nil
+		}
+		replacement, isReference, id := _this.Substitute(rv)
+		if isReference {
+			return replacement, nil
+		}
+		converted, err := _this.convertReferent(rv)
+		if err != nil || id < 0 {
+			return converted, err
+		}
+		if document, ok := converted.(Document); ok {
+			return append(Document{{Key: _this.IDKey, Value: id}}, document...), nil
+		}
+		return Document{{Key: _this.IDKey, Value: id}, {Key: _this.ValueKey, Value: converted}}, nil
+	case reflect.Array:
+		return _this.convertElements(rv)
+	case reflect.Struct:
+		return _this.convertStruct(rv)
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		return rv.String(), nil
+	default:
+		return nil, fmt.Errorf("bsonref: cannot convert %v", rv.Type())
+	}
+}
+
+func (_this *Converter) convertReferent(rv reflect.Value) (interface{}, error) {
+	switch rv.Kind() {
+	case reflect.Ptr:
+		return _this.convert(rv.Elem())
+	case reflect.Map:
+		return _this.convertMap(rv)
+	default:
+		return _this.convertElements(rv)
+	}
+}
+
+func (_this *Converter) convertElements(rv reflect.Value) (interface{}, error) {
+	elements := make([]interface{}, rv.Len())
+	for i := range elements {
+		element, err := _this.convert(rv.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		elements[i] = element
+	}
+	return elements, nil
+}
+
+func (_this *Converter) convertMap(rv reflect.Value) (interface{}, error) {
+	keys := rv.MapKeys()
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = fmt.Sprint(key.Interface())
+	}
+	indices := make([]int, len(keys))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.Slice(indices, func(i, j int) bool {
+		return names[indices[i]] < names[indices[j]]
+	})
+
+	document := make(Document, 0, len(keys))
+	for _, i := range indices {
+		value, err := _this.convert(rv.MapIndex(keys[i]))
+		if err != nil {
+			return nil, err
+		}
+		document = append(document, Element{Key: names[i], Value: value})
+	}
+	return document, nil
+}
+
+func (_this *Converter) convertStruct(rv reflect.Value) (interface{}, error) {
+	document := Document{}
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("bson"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		value, err := _this.convert(rv.Field(i))
+		if err != nil {
+			return nil, err
+		}
+		document = append(document, Element{Key: name, Value: value})
+	}
+	return document, nil
+}
@@ -0,0 +1,64 @@
+package bsonref
+
+import (
+	"reflect"
+	"testing"
+)
+
+type address struct {
+	City string `bson:"city"`
+}
+
+type person struct {
+	Name    string
+	Home    *address
+	Work    *address
+	Tags    *[]string
+	Aliases *[]string
+	Secret  string `bson:"-"`
+}
+
+func TestConvert(t *testing.T) {
+	home := &address{City: "Paris"}
+	tags := &[]string{"a"}
+	value := &person{Name: "x", Home: home, Work: home, Tags: tags, Aliases: tags, Secret: "s"}
+
+	actual, err := Convert(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Document{
+		{Key: "name", Value: "x"},
+		{Key: "home", Value: Document{{Key: "$id", Value: 0}, {Key: "city", Value: "Paris"}}},
+		{Key: "work", Value: Document{{Key: "$ref", Value: 0}}},
+		{Key: "tags", Value: Document{{Key: "$id", Value: 1}, {Key: "$value", Value: []interface{}{"a"}}}},
+		{Key: "aliases", Value: Document{{Key: "$ref", Value: 1}}},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v but got %v", expected, actual)
+	}
+}
+
+func TestConvertCycle(t *testing.T) {
+	m := map[string]interface{}{"n": 1}
+	m["self"] = m
+
+	actual, err := Convert(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Document{
+		{Key: "$id", Value: 0},
+		{Key: "n", Value: int64(1)},
+		{Key: "self", Value: Document{{Key: "$ref", Value: 0}}},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v but got %v", expected, actual)
+	}
+}
+
+func TestConvertUnsupported(t *testing.T) {
+	if _, err := Convert(make(chan int)); err == nil {
+		t.Errorf("Expected an error converting a channel")
+	}
+}
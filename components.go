@@ -0,0 +1,144 @@
+package duplicates
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// ComponentReferrer is a struct field that refers to a shared component.
+type ComponentReferrer struct {
+	// A pointer to the struct holding the field. If the struct wasn't
+	// addressable (for example because it was stored by value in a map),
+	// Parent.Pointer is nil.
+	Parent TypedPointer
+	// The name of the field.
+	Field string
+	// The path to the field.
+	Path Path
+}
+
+// SharedComponent is a single component instance that is referred to by more
+// than one distinct parent struct.
+type SharedComponent struct {
+	Component TypedPointer
+	// The first referring field of each distinct parent, in discovery order.
+	Referrers []ComponentReferrer
+}
+
+// ComponentGroup lists the shared instances of a single component type.
+type ComponentGroup struct {
+	// The pointer type of the component (for example *Logger).
+	Type       reflect.Type
+	Components []SharedComponent
+}
+
+// SharedComponents scans value for struct instances that are referred to by
+// the fields (including embedded pointer fields) of more than one distinct
+// parent struct, such as a *Logger or *Config handed to several services.
+// The results are grouped by component type, with the types having the most
+// shared instances first.
+func SharedComponents(value interface{}) []ComponentGroup {
+	return findSharedComponents(value, false, func(pointerType reflect.Type) bool {
+		return pointerType.Kind() == reflect.Ptr && pointerType.Elem().Kind() == reflect.Struct
+	})
+}
+
+var syncPrimitiveTypes = map[reflect.Type]bool{
+	reflect.TypeOf(&sync.Mutex{}):     true,
+	reflect.TypeOf(&sync.RWMutex{}):   true,
+	reflect.TypeOf(&sync.WaitGroup{}): true,
+	reflect.TypeOf(&sync.Cond{}):      true,
+	reflect.TypeOf(&sync.Once{}):      true,
+}
+
+// SharedSyncPrimitives scans value for sync primitives (Mutex, RWMutex,
+// WaitGroup, Cond, and Once) that are reachable from more than one distinct
+// owner. A struct holding a primitive by value owns it, as does every struct
+// holding a pointer to it. Sharing these lifecycle objects across components
+// is usually a design bug rather than benign aliasing.
+//
+// The results are grouped by primitive type, with the types having the most
+// shared instances first.
+func SharedSyncPrimitives(value interface{}) []ComponentGroup {
+	return findSharedComponents(value, true, func(pointerType reflect.Type) bool {
+		return syncPrimitiveTypes[pointerType]
+	})
+}
+
+// Finds the instances matching isComponent that are referred to by more than
+// one distinct parent struct. If includeHolders is set, a struct holding an
+// instance by value counts as one of its parents.
+func findSharedComponents(value interface{}, includeHolders bool, isComponent func(reflect.Type) bool) []ComponentGroup {
+	finder := NewDuplicateFinder()
+	finder.RecordPaths = true
+
+	type parentKey struct {
+		parent TypedPointer
+		// Distinguishes parents that aren't addressable.
+		sighting int
+	}
+	referrers := make(map[int][]ComponentReferrer)
+	seenParents := make(map[int]map[parentKey]bool)
+	sightingCount := 0
+
+	finder.sightingHook = func(index int) {
+		referrer := finder.referrer
+		if finder.registeringField && includeHolders {
+			// The field's address: the struct holds the instance by value.
+			step := finder.pathNodes[finder.pathNode].step
+			referrer = fieldRef{container: step.Container, index: step.Index}
+		}
+		if referrer.container == nil {
+			return
+		}
+		if !isComponent(finder.records[index].pointer.Type) {
+			return
+		}
+		sightingCount++
+		key := parentKey{parent: TypedPointer{
+			Type:    reflect.PtrTo(referrer.container),
+			Pointer: finder.referrerParent,
+		}}
+		if key.parent.Pointer == nil {
+			key.sighting = sightingCount
+		}
+		parents := seenParents[index]
+		if parents == nil {
+			parents = make(map[parentKey]bool)
+			seenParents[index] = parents
+		}
+		if parents[key] {
+			return
+		}
+		parents[key] = true
+		referrers[index] = append(referrers[index], ComponentReferrer{
+			Parent: key.parent,
+			Field:  finder.plans.planFor(referrer.container).fieldNames[referrer.index],
+			Path:   finder.pathAt(finder.pathNode),
+		})
+	}
+	finder.ScanForPointers(value)
+
+	var groups []ComponentGroup
+	groupIndices := make(map[reflect.Type]int)
+	for index, record := range finder.records {
+		if len(referrers[index]) < 2 {
+			continue
+		}
+		groupIndex, ok := groupIndices[record.pointer.Type]
+		if !ok {
+			groupIndex = len(groups)
+			groupIndices[record.pointer.Type] = groupIndex
+			groups = append(groups, ComponentGroup{Type: record.pointer.Type})
+		}
+		groups[groupIndex].Components = append(groups[groupIndex].Components, SharedComponent{
+			Component: record.pointer,
+			Referrers: referrers[index],
+		})
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return len(groups[i].Components) > len(groups[j].Components)
+	})
+	return groups
+}
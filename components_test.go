@@ -0,0 +1,120 @@
+package duplicates
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type componentLogger struct {
+	Prefix string
+}
+
+type componentConfig struct {
+	Name string
+}
+
+type componentService struct {
+	*componentLogger
+	Config *componentConfig
+	Backup *componentConfig
+}
+
+type componentApp struct {
+	Users    componentService
+	Orders   componentService
+	Payments *componentService
+}
+
+func TestSharedComponents(t *testing.T) {
+	logger := &componentLogger{Prefix: "app"}
+	config := &componentConfig{Name: "shared"}
+	own := &componentConfig{Name: "own"}
+	app := &componentApp{
+		Users:    componentService{componentLogger: logger, Config: config, Backup: config},
+		Orders:   componentService{componentLogger: logger, Config: own},
+		Payments: &componentService{Config: config},
+	}
+
+	groups := SharedComponents(app)
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 component groups but got %v", len(groups))
+	}
+	byType := make(map[reflect.Type]ComponentGroup)
+	for _, group := range groups {
+		byType[group.Type] = group
+	}
+
+	loggers := byType[reflect.TypeOf(logger)]
+	if len(loggers.Components) != 1 || loggers.Components[0].Component != TypedPointerOf(logger) {
+		t.Fatalf("Expected the logger to be shared but got %v", loggers.Components)
+	}
+	referrers := loggers.Components[0].Referrers
+	if len(referrers) != 2 || referrers[0].Path.String() != ".Users.componentLogger" ||
+		referrers[1].Path.String() != ".Orders.componentLogger" {
+		t.Errorf("Unexpected logger referrers %v", referrers)
+	}
+	if referrers[0].Parent != TypedPointerOf(&app.Users) {
+		t.Errorf("Expected parent %v but got %v", TypedPointerOf(&app.Users), referrers[0].Parent)
+	}
+
+	// Users refers to the config twice, but is still only one parent.
+	configs := byType[reflect.TypeOf(config)]
+	if len(configs.Components) != 1 || len(configs.Components[0].Referrers) != 2 {
+		t.Fatalf("Expected the config to have 2 parents but got %v", configs.Components)
+	}
+	if field := configs.Components[0].Referrers[1].Field; field != "Config" {
+		t.Errorf("Expected field Config but got %v", field)
+	}
+}
+
+type syncWorker struct {
+	wg   *sync.WaitGroup
+	once *sync.Once
+}
+
+type syncPool struct {
+	sync.Mutex
+	wg      sync.WaitGroup
+	lock    *sync.Mutex
+	workers []*syncWorker
+}
+
+func TestSharedSyncPrimitives(t *testing.T) {
+	once := &sync.Once{}
+	pool := &syncPool{}
+	pool.lock = &pool.Mutex
+	pool.workers = []*syncWorker{
+		{wg: &pool.wg, once: once},
+		{wg: &pool.wg, once: once},
+	}
+
+	groups := SharedSyncPrimitives(pool)
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 shared primitive types but got %v", groups)
+	}
+	byType := make(map[reflect.Type]ComponentGroup)
+	for _, group := range groups {
+		byType[group.Type] = group
+	}
+
+	waitGroups := byType[reflect.TypeOf(&pool.wg)].Components
+	if len(waitGroups) != 1 || waitGroups[0].Component != TypedPointerOf(&pool.wg) {
+		t.Fatalf("Expected the wait group to be shared but got %v", waitGroups)
+	}
+	paths := []string{}
+	for _, referrer := range waitGroups[0].Referrers {
+		paths = append(paths, referrer.Path.String())
+	}
+	expected := []string{".wg", ".workers[0].wg", ".workers[1].wg"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected referring paths %v but got %v", expected, paths)
+	}
+
+	if onces := byType[reflect.TypeOf(once)].Components; len(onces) != 1 || len(onces[0].Referrers) != 2 {
+		t.Errorf("Expected the once to have 2 owners but got %v", onces)
+	}
+	if _, ok := byType[reflect.TypeOf(pool.lock)]; ok {
+		t.Errorf("Expected a mutex referred to only by its holder to not be reported")
+	}
+}
@@ -0,0 +1,123 @@
+package duplicates
+
+import (
+	"reflect"
+	"sort"
+)
+
+// PointerContainment is a pair of registered pointers where the storage of
+// one's referent lies entirely within the storage of the other's, such as a
+// pointer to an element of an array or slice, or to a field of a struct.
+type PointerContainment struct {
+	// The pointer to the enclosing storage, and the pointer into it.
+	Outer TypedPointer
+	Inner TypedPointer
+	// The number of bytes from the start of Outer's referent to the start of
+	// Inner's.
+	Offset uintptr
+}
+
+// A registered pointer's referent as an address range.
+type storageRange struct {
+	pointer TypedPointer
+	// Index into records.
+	record int
+	start  uintptr
+	end    uintptr
+}
+
+// ContainedPointers returns every pair of registered pointers where one's
+// referent lies within the other's, ordered by when their outer pointer was
+// found. Unlike OverlappingPointers, this also catches pointers into the
+// middle of an object, which never share an address with the object itself.
+//
+// Only pointers and slices have a known extent. A slice covers its elements,
+// and is only included if the finder had RecordSliceExtents set while
+// scanning. Struct fields that were only reached as part of their struct
+// aren't counted as objects of their own, since every field would otherwise
+// be reported. Where two pointers cover the same range, they are only
+// reported if one's type lies at the start of the other's (see
+// OverlappingPointers).
+func (_this *DuplicateFinder) ContainedPointers() (containments []PointerContainment) {
+	var ranges []storageRange
+	for i, record := range _this.records {
+		if record.fieldAddress && record.sightings == 1 {
+			continue
+		}
+		size, ok := _this.referentSize(record.pointer)
+		if !ok || size == 0 {
+			continue
+		}
+		start := record.pointer.Address()
+		ranges = append(ranges, storageRange{
+			pointer: record.pointer,
+			record:  i,
+			start:   start,
+			end:     start + size,
+		})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].start != ranges[j].start {
+			return ranges[i].start < ranges[j].start
+		}
+		return ranges[i].end > ranges[j].end
+	})
+
+	// The records of each pair's pointers, for ordering.
+	type foundContainment struct {
+		containment  PointerContainment
+		outer, inner int
+	}
+	var found []foundContainment
+	for i, outer := range ranges {
+		for j := i + 1; j < len(ranges) && ranges[j].start < outer.end; j++ {
+			inner := ranges[j]
+			if inner.end > outer.end {
+				continue
+			}
+			pairOuter, pairInner := outer, inner
+			if inner.start == outer.start && inner.end == outer.end {
+				if _, ok := overlapKind(outer.pointer.Type, inner.pointer.Type); !ok {
+					if _, ok := overlapKind(inner.pointer.Type, outer.pointer.Type); !ok {
+						continue
+					}
+					pairOuter, pairInner = inner, outer
+				}
+			}
+			found = append(found, foundContainment{
+				containment: PointerContainment{
+					Outer:  pairOuter.pointer,
+					Inner:  pairInner.pointer,
+					Offset: pairInner.start - pairOuter.start,
+				},
+				outer: pairOuter.record,
+				inner: pairInner.record,
+			})
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].outer != found[j].outer {
+			return found[i].outer < found[j].outer
+		}
+		return found[i].inner < found[j].inner
+	})
+	for _, f := range found {
+		containments = append(containments, f.containment)
+	}
+	return
+}
+
+// Returns the size of the storage that typedPtr refers to, if it's known.
+func (_this *DuplicateFinder) referentSize(typedPtr TypedPointer) (size uintptr, ok bool) {
+	switch typedPtr.Type.Kind() {
+	case reflect.Ptr:
+		return typedPtr.Type.Elem().Size(), true
+	case reflect.Slice:
+		var extent sliceExtent
+		if extent, ok = _this.sliceExtents[typedPtr]; ok {
+			size = uintptr(extent.length) * typedPtr.Type.Elem().Size()
+		}
+	}
+	return
+}
@@ -0,0 +1,56 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+)
+
+type containedRecord struct {
+	ID     int
+	Values [4]int
+}
+
+func TestContainedPointers(t *testing.T) {
+	record := &containedRecord{}
+	values := make([]int, 4)
+	finder := NewDuplicateFinder(WithSliceExtents())
+	finder.ScanForPointers([]interface{}{record, &record.Values[2], values, &values[1]})
+
+	intSize := reflect.TypeOf(0).Size()
+	expected := []PointerContainment{
+		{Outer: TypedPointerOf(record), Inner: TypedPointerOf(&record.Values[2]), Offset: 3 * intSize},
+		{Outer: TypedPointerOf(values), Inner: TypedPointerOf(&values[1]), Offset: intSize},
+	}
+	containments := finder.ContainedPointers()
+	if len(containments) != len(expected) {
+		t.Fatalf("Expected %v but got %v", expected, containments)
+	}
+	for i, containment := range containments {
+		if containment != expected[i] {
+			t.Errorf("Containment %v: Expected %v but got %v", i, expected[i], containment)
+		}
+	}
+}
+
+func TestContainedPointersSharedField(t *testing.T) {
+	record := &containedRecord{}
+	finder := NewDuplicateFinder()
+	finder.ScanForPointers([]interface{}{record, &record.Values})
+
+	// The field was also reached through a pointer of its own, so it counts
+	// as an object.
+	containments := finder.ContainedPointers()
+	if len(containments) == 0 || containments[0].Outer != TypedPointerOf(record) ||
+		containments[0].Inner != TypedPointerOf(&record.Values) {
+		t.Errorf("Expected %v to contain %v but got %v", record, &record.Values, containments)
+	}
+}
+
+func TestContainedPointersSlicesWithoutExtents(t *testing.T) {
+	values := make([]int, 4)
+	finder := NewDuplicateFinder()
+	finder.ScanForPointers([]interface{}{values, &values[1]})
+	if containments := finder.ContainedPointers(); len(containments) != 0 {
+		t.Errorf("Expected no containments but got %v", containments)
+	}
+}
@@ -0,0 +1,320 @@
+package duplicates
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// ContentDigest is a canonical hash of an object's content.
+type ContentDigest [sha256.Size]byte
+
+func (_this ContentDigest) String() string {
+	return hex.EncodeToString(_this[:])
+}
+
+// ContentHasher canonically hashes objects by content, so that objects with
+// identical content (including identical pointer structure) produce the same
+// digest regardless of where they live in memory. Cycles are hashed by their
+// shape as seen from where they were entered, so two identical cyclic
+// structures also produce the same digest. Objects within cycles can't be
+// cached, so hashing large cyclic structures is slow.
+//
+// Pointers, maps, and slices are hashed by what they reference. Channels,
+// funcs, and unsafe pointers have no inspectable content, and are hashed by
+// address.
+//
+// Digests of referenced objects are cached, so objects must not be modified
+// between calls unless the hasher is re-initialized.
+type ContentHasher struct {
+	plans  *PlanCache
+	cached map[contentKey]ContentDigest
+	active map[contentKey]int
+	buffer [8]byte
+}
+
+// Slices sharing a data pointer can have different lengths, so the length is
+// part of a referenced object's identity.
+type contentKey struct {
+	pointer TypedPointer
+	length  int
+}
+
+// Marks that a hash doesn't depend on any object that was still being hashed.
+const noActiveReference = math.MaxInt32
+
+func NewContentHasher() *ContentHasher {
+	_this := &ContentHasher{}
+	_this.Init()
+	return _this
+}
+
+func (_this *ContentHasher) Init() {
+	if _this.plans == nil {
+		_this.plans = defaultPlanCache
+	}
+	_this.cached = make(map[contentKey]ContentDigest)
+	_this.active = make(map[contentKey]int)
+}
+
+// Hash returns the content digest of value.
+func (_this *ContentHasher) Hash(value interface{}) ContentDigest {
+	return _this.HashRV(reflect.ValueOf(value))
+}
+
+// HashRV returns the content digest of value.
+func (_this *ContentHasher) HashRV(value reflect.Value) (digest ContentDigest) {
+	root := contentFrame{
+		h:      sha256.New(),
+		values: []reflect.Value{value},
+		depth:  notReferent,
+		lowest: noActiveReference,
+	}
+	if value.IsValid() {
+		_this.writeString(root.h, value.Type().String())
+	}
+
+	// Referenced objects are hashed on an explicit stack of frames rather
+	// than recursively, so that deep structures can't exhaust the goroutine
+	// stack.
+	frames := []contentFrame{root}
+	for {
+		top := &frames[len(frames)-1]
+		if count := len(top.values); count > 0 {
+			value := top.values[count-1]
+			top.values = top.values[:count-1]
+			if frame, ok := _this.writeContent(top, value); ok {
+				frames = append(frames, frame)
+			}
+			continue
+		}
+		if top.entries != nil && top.entries.Next() {
+			// Each map entry is hashed separately so that the entries can be
+			// written in an order that depends only on their content.
+			frames = append(frames, contentFrame{
+				h:      sha256.New(),
+				values: []reflect.Value{top.entries.Value(), top.entries.Key()},
+				depth:  notReferent,
+				lowest: noActiveReference,
+			})
+			continue
+		}
+
+		done := frames[len(frames)-1]
+		frames = frames[:len(frames)-1]
+		if len(frames) == 0 {
+			done.h.Sum(digest[:0])
+			return
+		}
+		parent := &frames[len(frames)-1]
+		if done.depth == notReferent {
+			var entry ContentDigest
+			done.h.Sum(entry[:0])
+			parent.entryDigests = append(parent.entryDigests, entry)
+			if done.lowest < parent.lowest {
+				parent.lowest = done.lowest
+			}
+			continue
+		}
+		referent := _this.finishReferent(&done)
+		if done.lowest <= done.depth && done.lowest < parent.lowest {
+			parent.lowest = done.lowest
+		}
+		parent.h.Write(referent[:])
+	}
+}
+
+// Marks a frame that hashes the root value or a map entry rather than a
+// referenced object.
+const notReferent = -1
+
+// The state of hashing a referenced object, a map entry, or the root value.
+type contentFrame struct {
+	h hash.Hash
+	// Values still to be written, last first.
+	values []reflect.Value
+	// The entries of a map referent that are still to be hashed.
+	entries      *mapIterator
+	entryDigests []ContentDigest
+	key          contentKey
+	// The frame's depth in active, or notReferent.
+	depth int
+	// The lowest depth of any object still being hashed that this frame's
+	// content refers back to.
+	lowest int
+}
+
+// Writes the parts of value that don't need their own frame to frame's hash,
+// and queues the rest. Returns a new frame if value references an object
+// that must be hashed first.
+func (_this *ContentHasher) writeContent(frame *contentFrame, value reflect.Value) (referent contentFrame, ok bool) {
+	h := frame.h
+	switch value.Kind() {
+	case reflect.Invalid:
+		_this.writeUint(h, 0)
+	case reflect.Bool:
+		if value.Bool() {
+			_this.writeUint(h, 1)
+		} else {
+			_this.writeUint(h, 0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		_this.writeUint(h, uint64(value.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		_this.writeUint(h, value.Uint())
+	case reflect.Float32, reflect.Float64:
+		_this.writeUint(h, math.Float64bits(value.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		_this.writeUint(h, math.Float64bits(real(value.Complex())))
+		_this.writeUint(h, math.Float64bits(imag(value.Complex())))
+	case reflect.String:
+		_this.writeString(h, value.String())
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		_this.writeUint(h, uint64(value.Pointer()))
+	case reflect.Interface:
+		if value.IsNil() {
+			_this.writeUint(h, 0)
+			return
+		}
+		elem := value.Elem()
+		_this.writeString(h, elem.Type().String())
+		frame.values = append(frame.values, elem)
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if value.IsNil() {
+			_this.writeUint(h, 0)
+			return
+		}
+		_this.writeUint(h, 1)
+		return _this.enterReferent(frame, value)
+	case reflect.Array:
+		for i := value.Len() - 1; i >= 0; i-- {
+			frame.values = append(frame.values, value.Index(i))
+		}
+	case reflect.Struct:
+		for i := value.NumField() - 1; i >= 0; i-- {
+			frame.values = append(frame.values, value.Field(i))
+		}
+	}
+	return
+}
+
+// Begins hashing the object that a non-nil pointer, map, or slice references.
+// If its digest is already known, it's written to frame's hash right away.
+func (_this *ContentHasher) enterReferent(frame *contentFrame, value reflect.Value) (referent contentFrame, ok bool) {
+	key := contentKey{pointer: TypedPointerOfRV(value)}
+	if value.Kind() == reflect.Slice {
+		key.length = value.Len()
+	}
+	if digest, ok := _this.cached[key]; ok {
+		frame.h.Write(digest[:])
+		return referent, false
+	}
+
+	h := sha256.New()
+	_this.writeString(h, value.Type().String())
+	if depth, ok := _this.active[key]; ok {
+		// A cycle: hash the distance back to the object it returns to, which
+		// is the same wherever the cycle is entered from.
+		_this.writeString(h, "cycle")
+		_this.writeUint(h, uint64(len(_this.active)-depth))
+		if depth < frame.lowest {
+			frame.lowest = depth
+		}
+		var digest ContentDigest
+		h.Sum(digest[:0])
+		frame.h.Write(digest[:])
+		return referent, false
+	}
+
+	referent = contentFrame{
+		h:      h,
+		key:    key,
+		depth:  len(_this.active),
+		lowest: noActiveReference,
+	}
+	_this.active[key] = referent.depth
+	switch value.Kind() {
+	case reflect.Ptr:
+		referent.values = []reflect.Value{value.Elem()}
+	case reflect.Slice:
+		_this.writeUint(h, uint64(value.Len()))
+		referent.values = make([]reflect.Value, 0, value.Len())
+		for i := value.Len() - 1; i >= 0; i-- {
+			referent.values = append(referent.values, value.Index(i))
+		}
+	case reflect.Map:
+		referent.entries = &mapIterator{}
+		resetMapIterator(referent.entries, value)
+		referent.entryDigests = make([]ContentDigest, 0, value.Len())
+	}
+	return referent, true
+}
+
+// Completes the digest of a referenced object once all of its content has
+// been written.
+func (_this *ContentHasher) finishReferent(frame *contentFrame) (digest ContentDigest) {
+	if frame.entries != nil {
+		// Writes a map's entries in an order that depends only on their
+		// content.
+		entries := frame.entryDigests
+		sort.Slice(entries, func(i, j int) bool {
+			return bytes.Compare(entries[i][:], entries[j][:]) < 0
+		})
+		_this.writeUint(frame.h, uint64(len(entries)))
+		for _, entry := range entries {
+			frame.h.Write(entry[:])
+		}
+	}
+	delete(_this.active, frame.key)
+	frame.h.Sum(digest[:0])
+
+	// Objects within a cycle hash differently depending on where the cycle
+	// was entered from, so only objects outside of cycles are cached.
+	if frame.lowest > frame.depth {
+		_this.cached[frame.key] = digest
+	}
+	return
+}
+
+func (_this *ContentHasher) writeUint(h hash.Hash, value uint64) {
+	binary.LittleEndian.PutUint64(_this.buffer[:], value)
+	h.Write(_this.buffer[:])
+}
+
+func (_this *ContentHasher) writeString(h hash.Hash, value string) {
+	_this.writeUint(h, uint64(len(value)))
+	h.Write([]byte(value))
+}
+
+type contentAlias struct {
+	pointer TypedPointer
+	record  int
+}
+
+// Looks for an already registered pointer whose referent has the same content
+// as pointer's (if IdentifyByContent). If one exists, pointer becomes an
+// alias of it.
+func (_this *DuplicateFinder) matchContent(pointer reflect.Value, typedPtr TypedPointer) (index int, isDuplicate bool) {
+	if _this.contentHasher == nil {
+		_this.contentHasher = NewContentHasher()
+		_this.contentHasher.plans = _this.plans
+		_this.contentIndex = make(map[ContentDigest]int)
+	}
+	digest := _this.contentHasher.HashRV(pointer)
+	if index, isDuplicate = _this.contentIndex[digest]; isDuplicate {
+		_this.recordIndex[typedPtr] = index
+		_this.DuplicatePointers[typedPtr] = true
+		_this.contentAliases = append(_this.contentAliases, contentAlias{
+			pointer: typedPtr,
+			record:  index,
+		})
+		return
+	}
+	_this.contentIndex[digest] = len(_this.records)
+	return
+}
@@ -0,0 +1,111 @@
+package duplicates
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+type contentTestConfig struct {
+	Name    string
+	Ports   []int
+	Labels  map[string]string
+	Backend *contentTestConfig
+}
+
+func newContentTestConfig(name string) *contentTestConfig {
+	return &contentTestConfig{
+		Name:   name,
+		Ports:  []int{80, 443},
+		Labels: map[string]string{"a": "1", "b": "2"},
+	}
+}
+
+func TestContentHasher(t *testing.T) {
+	hasher := NewContentHasher()
+	a := newContentTestConfig("x")
+	b := newContentTestConfig("x")
+	c := newContentTestConfig("y")
+	if hasher.Hash(a) != hasher.Hash(b) {
+		t.Errorf("Expected identical content to have identical digests")
+	}
+	if hasher.Hash(a) == hasher.Hash(c) {
+		t.Errorf("Expected different content to have different digests")
+	}
+	if hasher.Hash(a.Ports) == hasher.Hash(a.Ports[:1]) {
+		t.Errorf("Expected slices of different lengths to have different digests")
+	}
+}
+
+func TestContentHasherCycles(t *testing.T) {
+	a := newContentTestConfig("x")
+	a.Backend = a
+	b := newContentTestConfig("x")
+	b.Backend = b
+	c := newContentTestConfig("x")
+	c.Backend = newContentTestConfig("x")
+	c.Backend.Backend = c
+
+	hasher := NewContentHasher()
+	backendDigest := hasher.Hash(c.Backend)
+	if hasher.Hash(a) != hasher.Hash(b) {
+		t.Errorf("Expected identical cycles to have identical digests")
+	}
+	if hasher.Hash(a) == hasher.Hash(c) {
+		t.Errorf("Expected cycles of different lengths to have different digests")
+	}
+	hasher.Hash(c)
+	if hasher.Hash(c.Backend) != backendDigest {
+		t.Errorf("Expected digests within a cycle to not depend on hashing order")
+	}
+}
+
+func TestIdentifyByContent(t *testing.T) {
+	a := newContentTestConfig("x")
+	b := newContentTestConfig("x")
+	c := newContentTestConfig("y")
+	roots := []*contentTestConfig{a, b, c}
+
+	finder := NewDuplicateFinder()
+	finder.IdentifyByContent = true
+	finder.ScanForPointers(roots)
+	if !finder.IsDuplicatePointer(a) || !finder.IsDuplicatePointer(b) {
+		t.Errorf("Expected a and b to be duplicates by content")
+	}
+	if finder.IsDuplicatePointer(c) {
+		t.Errorf("Expected c to not be a duplicate")
+	}
+	if finder.IsDuplicatePointer(&a.Name) {
+		t.Errorf("Expected field addresses to be identified by address")
+	}
+
+	table := finder.Freeze()
+	markerA, _ := table.MarkerIDOf(a)
+	if markerB, isDuplicate := table.MarkerIDOf(b); !isDuplicate || markerB != markerA {
+		t.Errorf("Expected b to share a's marker ID %v but got %v (%v)", markerA, markerB, isDuplicate)
+	}
+}
+
+func TestContentHasherDeepList(t *testing.T) {
+	// Far less stack than hashing this list recursively would need.
+	defer debug.SetMaxStack(debug.SetMaxStack(1 << 20))
+
+	newList := func() *contentTestConfig {
+		head := newContentTestConfig("tail")
+		for i := 0; i < 30000; i++ {
+			head = &contentTestConfig{Name: "node", Backend: head}
+		}
+		return head
+	}
+	a := newList()
+	b := newList()
+	if NewContentHasher().Hash(a) != NewContentHasher().Hash(b) {
+		t.Errorf("Expected identical lists to have identical digests")
+	}
+
+	finder := NewDuplicateFinder()
+	finder.IdentifyByContent = true
+	finder.ScanForPointers([]*contentTestConfig{a, b})
+	if !finder.IsDuplicatePointer(a) || !finder.IsDuplicatePointer(b) {
+		t.Errorf("Expected a and b to be duplicates by content")
+	}
+}
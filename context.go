@@ -0,0 +1,26 @@
+package duplicates
+
+import (
+	"context"
+	"reflect"
+)
+
+// ScanForPointersContext works like ScanForPointers, except that it stops
+// scanning once ctx is done, returning ctx's error. Everything found up to
+// that point is kept, and the results are marked as truncated (see
+// Truncated).
+func (_this *DuplicateFinder) ScanForPointersContext(ctx context.Context, object interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	rootIndex := _this.rootCount
+	_this.rootCount++
+	_this.ctx = ctx
+	_this.ctxErr = nil
+	defer func() {
+		_this.ctx = nil
+		_this.ctxErr = nil
+	}()
+	_this.scanRoot(reflect.ValueOf(object), rootIndex)
+	return _this.ctxErr
+}
@@ -0,0 +1,52 @@
+package duplicates
+
+import (
+	"context"
+	"testing"
+)
+
+func TestScanForPointersContext(t *testing.T) {
+	v := 1
+	finder := NewDuplicateFinder()
+	if err := finder.ScanForPointersContext(context.Background(), []*int{&v, &v}); err != nil {
+		t.Fatal(err)
+	}
+	if !finder.IsDuplicatePointer(&v) || finder.Truncated() {
+		t.Errorf("Expected a complete scan")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	finder = NewDuplicateFinder()
+	if err := finder.ScanForPointersContext(ctx, []*int{&v, &v}); err != context.Canceled {
+		t.Errorf("Expected a cancelled context to stop the scan but got %v", err)
+	}
+}
+
+func TestScanForPointersContextCancelledDuringScan(t *testing.T) {
+	nodes := make([]*int, interruptCheckInterval*3)
+	for i := range nodes {
+		nodes[i] = new(int)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	finder := NewDuplicateFinder()
+	finder.sightingHook = func(index int) {
+		if index == interruptCheckInterval {
+			cancel()
+		}
+	}
+	err := finder.ScanForPointersContext(ctx, nodes)
+	if err != context.Canceled {
+		t.Errorf("Expected the scan to be cancelled but got %v", err)
+	}
+	if !finder.Truncated() || finder.NodesVisited() >= len(nodes) {
+		t.Errorf("Expected the scan to stop early but visited %v nodes", finder.NodesVisited())
+	}
+
+	finder.sightingHook = nil
+	v := 1
+	if err := finder.ScanForPointersContext(context.Background(), []*int{&v, &v}); err != nil || !finder.IsDuplicatePointer(&v) {
+		t.Errorf("Expected the finder to remain usable but got %v", err)
+	}
+}
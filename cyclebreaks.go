@@ -0,0 +1,74 @@
+package duplicates
+
+// SuggestCycleBreaks scans value and proposes a small set of struct fields
+// that, if set to nil (or replaced with IDs) in every instance, would leave
+// value without any reference cycles. The fields are chosen greedily, each
+// time picking the field with the most references still on cycles, so the
+// set is near-minimal rather than guaranteed minimal. Each field's Count is
+// the number of its references that were on cycles when it was chosen.
+//
+// Cycles that don't run through any struct field (for example those formed
+// entirely by map values or slice elements) can't be broken this way, and
+// their strongly connected components (see StronglyConnectedComponents) are
+// returned as unbreakable.
+func SuggestCycleBreaks(value interface{}) (fields []FieldStat, unbreakable [][]TypedPointer) {
+	finder, edges := scanReferenceEdges(value, false, false)
+	nodeCount := len(finder.records)
+	broken := make(map[fieldRef]bool)
+	isBroken := func(edge referenceEdge) bool {
+		return edge.field.container != nil && broken[edge.field]
+	}
+
+	componentOf := make([]int, nodeCount)
+	for {
+		components := tarjan(adjacency(nodeCount, edges, isBroken))
+		isCyclic := make([]bool, len(components))
+		for i, component := range components {
+			for _, node := range component {
+				componentOf[node] = i
+			}
+			isCyclic[i] = len(component) > 1
+		}
+		for _, edge := range edges {
+			if edge.from == edge.to && !isBroken(edge) {
+				isCyclic[componentOf[edge.from]] = true
+			}
+		}
+
+		var candidates []fieldRef
+		counts := make(map[fieldRef]int)
+		for _, edge := range edges {
+			if edge.field.container == nil || isBroken(edge) {
+				continue
+			}
+			if component := componentOf[edge.from]; component == componentOf[edge.to] && isCyclic[component] {
+				if counts[edge.field] == 0 {
+					candidates = append(candidates, edge.field)
+				}
+				counts[edge.field]++
+			}
+		}
+
+		if len(candidates) == 0 {
+			for i, component := range components {
+				if isCyclic[i] {
+					unbreakable = append(unbreakable, finder.pointersOf(component))
+				}
+			}
+			return
+		}
+
+		best := candidates[0]
+		for _, candidate := range candidates[1:] {
+			if counts[candidate] > counts[best] {
+				best = candidate
+			}
+		}
+		broken[best] = true
+		fields = append(fields, FieldStat{
+			Struct: best.container,
+			Field:  finder.plans.planFor(best.container).fieldNames[best.index],
+			Count:  counts[best],
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+)
+
+type cycleBreakNode struct {
+	Name   string
+	Next   *cycleBreakNode
+	Prev   *cycleBreakNode
+	Parent *cycleBreakNode
+	Links  map[string]*cycleBreakNode
+}
+
+func TestSuggestCycleBreaks(t *testing.T) {
+	// A doubly linked ring, whose members all point to a parent that points
+	// back into the ring.
+	parent := &cycleBreakNode{Name: "parent"}
+	ring := []*cycleBreakNode{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	for i, node := range ring {
+		node.Next = ring[(i+1)%len(ring)]
+		node.Prev = ring[(i+len(ring)-1)%len(ring)]
+		node.Parent = parent
+	}
+	parent.Next = ring[0]
+
+	fields, unbreakable := SuggestCycleBreaks(parent)
+	if len(unbreakable) != 0 {
+		t.Errorf("Expected no unbreakable cycles but got %v", unbreakable)
+	}
+	names := []string{}
+	for _, field := range fields {
+		if field.Struct != reflect.TypeOf(cycleBreakNode{}) {
+			t.Errorf("Unexpected struct %v", field.Struct)
+		}
+		names = append(names, field.Field)
+	}
+	// Removing Next also removes the parent's way back into the ring.
+	if !reflect.DeepEqual(names, []string{"Next", "Prev"}) {
+		t.Errorf("Expected Next and Prev to be suggested but got %v", names)
+	}
+	if fields[0].Count != 4 {
+		t.Errorf("Expected Next to be on 4 cycle references but got %v", fields[0].Count)
+	}
+}
+
+func TestSuggestCycleBreaksUnbreakable(t *testing.T) {
+	node := &cycleBreakNode{Name: "a"}
+	node.Links = map[string]*cycleBreakNode{}
+	links := node.Links
+	links["self"] = node
+
+	fields, unbreakable := SuggestCycleBreaks(links)
+	if len(fields) != 1 || fields[0].Field != "Links" {
+		t.Errorf("Expected Links to be suggested but got %v", fields)
+	}
+
+	m := map[string]interface{}{}
+	m["self"] = m
+	fields, unbreakable = SuggestCycleBreaks(m)
+	if len(fields) != 0 || len(unbreakable) != 1 || unbreakable[0][0] != TypedPointerOf(m) {
+		t.Errorf("Expected the map's cycle to be unbreakable but got %v, %v", fields, unbreakable)
+	}
+}
@@ -0,0 +1,103 @@
+package duplicates
+
+import (
+	"fmt"
+)
+
+// IsCycle returns true if ptr was found again from within what it refers to,
+// meaning that it is part of a reference cycle (a back reference) rather than
+// merely shared between separate branches.
+func (_this *DuplicateFinder) IsCycle(ptr TypedPointer) bool {
+	index, ok := _this.recordIndex[_this.identify(ptr)]
+	return ok && _this.records[index].inCycle
+}
+
+// Cycles returns every pointer that is part of a reference cycle (see IsCycle),
+// in the order in which their cycles were found.
+func (_this *DuplicateFinder) Cycles() (cycles []TypedPointer) {
+	found := make(map[int]bool)
+	for _, backRef := range _this.backReferences {
+		if !found[backRef.record] {
+			found[backRef.record] = true
+			cycles = append(cycles, _this.records[backRef.record].pointer)
+		}
+	}
+	return
+}
+
+// Cycle is a single reference cycle: a chain of pointers, each leading to the
+// next, the last of which leads back to the first.
+type Cycle struct {
+	// The pointers forming the cycle, starting with the one that was found
+	// again. Besides pointers, maps, and slices, this includes the addresses
+	// of the struct fields that the chain runs through.
+	Members []TypedPointer
+	// The path at which the first member was first found, and the path at
+	// which it was found again (if the finder had RecordPaths set while
+	// scanning).
+	Entry         Path
+	BackReference Path
+}
+
+// LoopSteps returns the steps leading from the first member back to itself.
+// This only works if the finder had RecordPaths set while scanning.
+func (_this Cycle) LoopSteps() []PathStep {
+	return _this.BackReference.Steps()[_this.Entry.Len():]
+}
+
+// ReferenceCycles returns every reference cycle found, in the order in which
+// they were found. Every back reference closes a cycle, so an object graph
+// with several paths back to the same pointer has several cycles through it.
+func (_this *DuplicateFinder) ReferenceCycles() (cycles []Cycle) {
+	for _, backRef := range _this.backReferences {
+		cycle := Cycle{
+			Entry:         _this.pathAt(_this.pathsOfRecord(backRef.record).first),
+			BackReference: _this.pathAt(backRef.pathNode),
+		}
+		for _, member := range _this.cycleMembers[backRef.membersStart:backRef.membersEnd] {
+			cycle.Members = append(cycle.Members, _this.records[member].pointer)
+		}
+		cycles = append(cycles, cycle)
+	}
+	return
+}
+
+// Records the members of the cycle that was just closed by finding the
+// pointer at index again: the referents from index onwards that are being
+// scanned.
+func (_this *DuplicateFinder) recordCycleMembers(index int) {
+	start := len(_this.referents) - 1
+	for start > 0 && _this.referents[start].index != index {
+		start--
+	}
+	for _, referent := range _this.referents[start:] {
+		_this.cycleMembers = append(_this.cycleMembers, referent.index)
+	}
+	_this.backReferences[len(_this.backReferences)-1].membersEnd = len(_this.cycleMembers)
+}
+
+// CycleError reports a reference cycle found by MustBeAcyclic.
+type CycleError struct {
+	Cycle Cycle
+}
+
+func (_this *CycleError) Error() string {
+	ptr := _this.Cycle.Members[0]
+	return fmt.Sprintf("duplicates: reference cycle: %v refers back to %v (%v)",
+		_this.Cycle.BackReference.StringFrom("root"), _this.Cycle.Entry.StringFrom("root"), ptr.Type)
+}
+
+// MustBeAcyclic scans value and returns a *CycleError describing the first
+// reference cycle found, or nil if value contains no cycles. This is useful
+// before handing a value to an encoder that can't handle cycles (such as
+// encoding/json).
+func MustBeAcyclic(value interface{}) error {
+	finder := NewDuplicateFinder()
+	finder.RecordPaths = true
+	finder.ScanForPointers(value)
+	cycles := finder.ReferenceCycles()
+	if len(cycles) == 0 {
+		return nil
+	}
+	return &CycleError{Cycle: cycles[0]}
+}
@@ -0,0 +1,104 @@
+package duplicates
+
+import (
+	"testing"
+)
+
+type cycleNode struct {
+	Name     string
+	Next     *cycleNode
+	Children []*cycleNode
+}
+
+func TestCycles(t *testing.T) {
+	shared := &cycleNode{Name: "shared"}
+	a := &cycleNode{Name: "a", Children: []*cycleNode{shared}}
+	b := &cycleNode{Name: "b", Next: a, Children: []*cycleNode{shared}}
+	a.Next = b
+
+	finder := NewDuplicateFinder()
+	finder.ScanForPointers(a)
+
+	cycles := finder.Cycles()
+	if len(cycles) != 1 || cycles[0] != TypedPointerOf(a) {
+		t.Errorf("Expected only a to close a cycle but got %v", cycles)
+	}
+	if !finder.IsCycle(TypedPointerOf(a)) {
+		t.Errorf("Expected a to be a cycle")
+	}
+	if finder.IsCycle(TypedPointerOf(shared)) || !finder.IsDuplicatePointer(shared) {
+		t.Errorf("Expected shared to be a duplicate but not a cycle")
+	}
+
+	report := finder.Report()
+	if info, _ := report.Info(TypedPointerOf(a)); !info.InCycle {
+		t.Errorf("Expected the report to mark a as in a cycle")
+	}
+	if info, _ := report.Info(TypedPointerOf(shared)); info.InCycle {
+		t.Errorf("Expected the report not to mark shared as in a cycle")
+	}
+}
+
+func TestReferenceCycles(t *testing.T) {
+	a := &cycleNode{Name: "a"}
+	b := &cycleNode{Name: "b", Next: a}
+	a.Children = []*cycleNode{b}
+
+	finder := NewDuplicateFinder()
+	finder.RecordPaths = true
+	finder.ScanForPointers(&cycleNode{Next: a})
+
+	cycles := finder.ReferenceCycles()
+	if len(cycles) != 1 {
+		t.Fatalf("Expected 1 cycle but got %v", cycles)
+	}
+	cycle := cycles[0]
+	expectedMembers := []TypedPointer{
+		TypedPointerOf(a),
+		TypedPointerOf(&a.Children),
+		TypedPointerOf(a.Children),
+		TypedPointerOf(b),
+		TypedPointerOf(&b.Next),
+	}
+	if len(cycle.Members) != len(expectedMembers) {
+		t.Fatalf("Expected members %v but got %v", expectedMembers, cycle.Members)
+	}
+	for i, member := range cycle.Members {
+		if member != expectedMembers[i] {
+			t.Errorf("Expected member %v to be %v but got %v", i, expectedMembers[i], member)
+		}
+	}
+	if cycle.Entry.String() != ".Next" || cycle.BackReference.String() != ".Next.Children[0].Next" {
+		t.Errorf("Unexpected paths %v, %v", cycle.Entry, cycle.BackReference)
+	}
+	loop := ""
+	for _, step := range cycle.LoopSteps() {
+		loop += step.String()
+	}
+	if loop != ".Children[0].Next" {
+		t.Errorf("Expected the loop to be .Children[0].Next but got %v", loop)
+	}
+}
+
+func TestMustBeAcyclic(t *testing.T) {
+	shared := &cycleNode{Name: "shared"}
+	acyclic := &cycleNode{Children: []*cycleNode{shared, shared}}
+	if err := MustBeAcyclic(acyclic); err != nil {
+		t.Errorf("Expected shared pointers not to be a cycle but got %v", err)
+	}
+
+	a := &cycleNode{Name: "a"}
+	a.Children = []*cycleNode{{Name: "b", Next: a}}
+	err := MustBeAcyclic(a)
+	cycleErr, ok := err.(*CycleError)
+	if !ok {
+		t.Fatalf("Expected a CycleError but got %v", err)
+	}
+	if cycleErr.Cycle.Members[0] != TypedPointerOf(a) {
+		t.Errorf("Expected the cycle to start at a but got %v", cycleErr.Cycle.Members[0])
+	}
+	expected := "duplicates: reference cycle: root.Children[0].Next refers back to root (*duplicates.cycleNode)"
+	if err.Error() != expected {
+		t.Errorf("Expected %q but got %q", expected, err.Error())
+	}
+}
@@ -0,0 +1,117 @@
+package duplicates
+
+// ImmediateDominators computes the dominator tree of the graph. A node
+// dominates another if every path from the scanned value to the other node
+// runs through it, meaning that if the dominating object were released, the
+// other object would become unreachable as well. For each node, the result
+// holds the index of its immediate (closest) dominator, or -1 if the node is
+// only dominated by the scanned value itself.
+func (_this *ReferenceGraph) ImmediateDominators() []int {
+	// Uses the iterative algorithm by Cooper, Harvey, and Kennedy, with the
+	// scanned value as an extra node at index len(Nodes).
+	nodeCount := len(_this.Nodes)
+	root := nodeCount
+	successors := func(node int) []int {
+		if node == root {
+			node = -1
+		}
+		var next []int
+		for _, edge := range _this.outgoing[node] {
+			next = append(next, _this.Edges[edge].To)
+		}
+		return next
+	}
+
+	// Reverse postorder numbering, from an iterative depth first search.
+	order := make([]int, 0, nodeCount+1)
+	postorder := make([]int, nodeCount+1)
+	visited := make([]bool, nodeCount+1)
+	type frame struct {
+		node int
+		next []int
+	}
+	stack := []frame{{node: root, next: successors(root)}}
+	visited[root] = true
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		if len(top.next) == 0 {
+			postorder[top.node] = len(order)
+			order = append(order, top.node)
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		next := top.next[0]
+		top.next = top.next[1:]
+		if !visited[next] {
+			visited[next] = true
+			stack = append(stack, frame{node: next, next: successors(next)})
+		}
+	}
+
+	const undefined = -2
+	dominators := make([]int, nodeCount+1)
+	for i := range dominators {
+		dominators[i] = undefined
+	}
+	dominators[root] = root
+	intersect := func(a, b int) int {
+		for a != b {
+			for postorder[a] < postorder[b] {
+				a = dominators[a]
+			}
+			for postorder[b] < postorder[a] {
+				b = dominators[b]
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		for i := len(order) - 2; i >= 0; i-- {
+			node := order[i]
+			newDominator := undefined
+			for _, edge := range _this.incoming[node] {
+				predecessor := _this.Edges[edge].From
+				if predecessor < 0 {
+					predecessor = root
+				}
+				if dominators[predecessor] == undefined {
+					continue
+				}
+				if newDominator == undefined {
+					newDominator = predecessor
+				} else {
+					newDominator = intersect(predecessor, newDominator)
+				}
+			}
+			if dominators[node] != newDominator {
+				dominators[node] = newDominator
+				changed = true
+			}
+		}
+	}
+
+	dominators = dominators[:nodeCount]
+	for i, dominator := range dominators {
+		if dominator == root {
+			dominators[i] = -1
+		}
+	}
+	return dominators
+}
+
+// Dominated returns the nodes dominated by node (see ImmediateDominators), in
+// discovery order. These are the objects that would become unreachable if
+// node's object were released.
+func (_this *ReferenceGraph) Dominated(node int) (dominated []int) {
+	dominators := _this.ImmediateDominators()
+	for i := range dominators {
+		for dominator := dominators[i]; dominator >= 0; dominator = dominators[dominator] {
+			if dominator == node {
+				dominated = append(dominated, i)
+				break
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,59 @@
+package duplicates
+
+import (
+	"testing"
+)
+
+type dominatorNode struct {
+	Name  string
+	Left  *dominatorNode
+	Right *dominatorNode
+}
+
+func TestImmediateDominators(t *testing.T) {
+	// root -> a -> shared, root -> b -> shared, shared -> leaf
+	leaf := &dominatorNode{Name: "leaf"}
+	shared := &dominatorNode{Name: "shared", Left: leaf}
+	a := &dominatorNode{Name: "a", Left: shared}
+	b := &dominatorNode{Name: "b", Left: shared}
+	root := &dominatorNode{Name: "root", Left: a, Right: b}
+	leaf.Right = leaf
+
+	graph := BuildReferenceGraph(root)
+	node := func(ptr interface{}) int {
+		index, ok := graph.NodeOf(TypedPointerOf(ptr))
+		if !ok {
+			t.Fatalf("Expected %v to be in the graph", ptr)
+		}
+		return index
+	}
+	dominators := graph.ImmediateDominators()
+
+	expected := map[int]int{
+		node(root):         -1,
+		node(&root.Left):   node(root),
+		node(a):            node(&root.Left),
+		node(b):            node(&root.Right),
+		node(shared):       node(root),
+		node(&shared.Left): node(shared),
+		node(leaf):         node(&shared.Left),
+		node(&leaf.Right):  node(leaf),
+	}
+	for index, dominator := range expected {
+		if dominators[index] != dominator {
+			t.Errorf("Expected %v to be dominated by %v but got %v", graph.Nodes[index], dominator, dominators[index])
+		}
+	}
+
+	dominated := graph.Dominated(node(shared))
+	expectedDominated := []int{node(&shared.Name), node(&shared.Left), node(leaf), node(&leaf.Name), node(&leaf.Left), node(&leaf.Right), node(&shared.Right)}
+	if len(dominated) != len(expectedDominated) {
+		t.Fatalf("Expected %v to be dominated by shared but got %v", expectedDominated, dominated)
+	}
+	for i := range dominated {
+		if dominated[i] != expectedDominated[i] {
+			t.Errorf("Expected %v to be dominated by shared but got %v", expectedDominated, dominated)
+			break
+		}
+	}
+}
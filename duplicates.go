@@ -3,7 +3,10 @@
 package duplicates
 
 import (
+	"context"
 	"reflect"
+	"time"
+	"unsafe"
 )
 
 // FindDuplicatePointers walks an object and its contents looking for pointer
@@ -17,6 +20,8 @@ import (
 // myTypedPtr represents a duplicate pointer.
 func FindDuplicatePointers(value interface{}) (duplicatePtrs map[TypedPointer]bool) {
 	finder := NewDuplicateFinder()
+	// Nothing but the map is returned, so nothing else needs to be kept.
+	finder.withoutRecords = true
 	finder.ScanForPointers(value)
 	return finder.DuplicatePointers
 }
@@ -24,9 +29,18 @@ func FindDuplicatePointers(value interface{}) (duplicatePtrs map[TypedPointer]bo
 // TypedPointer is a pointer value with an associated type. Typing is necessary
 // because the first field of a struct will have the same address as the struct
 // itself
+//
+// A TypedPointer identifies its object by a real pointer, which keeps the
+// object alive for as long as the TypedPointer is held (so that its address
+// can't be reused by another object), and which follows the object should the
+// garbage collector ever move it. Raw TypedPointers (see Raw) identify their
+// objects by address alone, as opaque values (see TypeHandler) always do.
 type TypedPointer struct {
-	Type    reflect.Type
-	Pointer uintptr
+	Type reflect.Type
+	// The object pointed to, or nil for a raw TypedPointer.
+	Pointer unsafe.Pointer
+	// The address of the object, for a raw TypedPointer only.
+	address uintptr
 }
 
 // TypedPointerOf gets the typed pointer of an arbitrary object.
@@ -40,7 +54,7 @@ func TypedPointerOf(value interface{}) TypedPointer {
 func TypedPointerOfRV(rv reflect.Value) TypedPointer {
 	return TypedPointer{
 		Type:    rv.Type(),
-		Pointer: rv.Pointer(),
+		Pointer: unsafe.Pointer(rv.Pointer()),
 	}
 }
 
@@ -51,130 +65,1074 @@ type DuplicateFinder struct {
 	// Non-duplicates will either not be present in the map, or will map to false.
 	// Either way, DuplicatePointers[myTypedPtr] will return true if and only if
 	// myTypedPtr represents a duplicate pointer.
+	//
+	// The finder itself only ever adds duplicates to the map, so that its
+	// length is the number of duplicates found (registered pointers that
+	// aren't duplicates can be queried through ReferenceCounts).
 	DuplicatePointers map[TypedPointer]bool
+
+	// If true, the finder retains the reflect.Value of every registered
+	// pointer, so that results can be resolved back to the actual objects.
+	RetainValues bool
+
+	// If true, the finder identifies pointers by raw address (see
+	// TypedPointer.Raw), as it did originally, and doesn't keep the objects
+	// it registered alive. Once an object is collected, its address may be
+	// reused by another one, which the results can't tell apart. Look up
+	// DuplicatePointers with raw TypedPointers; the finder's own methods
+	// accept either kind.
+	RawAddresses bool
+
+	// If true, registering the address of a struct's embedded first field
+	// while scanning the struct doesn't count as a sighting of it, since that
+	// address is the struct referring to its own embedded component rather
+	// than sharing between distinct owners. Pointers to the embedded field
+	// from elsewhere still count, so that it is a duplicate if it is
+	// referred to twice.
+	IgnoreEmbeddedAliases bool
+
+	// If true, a slice and a pointer to an array (*[N]T) with the same
+	// element type and the same data address are considered to be sharing,
+	// and both are marked as duplicates. Such aliases arise from slicing an
+	// array, or from converting a slice to an array pointer (Go 1.17+), and
+	// are listed by ArraySliceAliases.
+	MatchArraySliceAliases bool
+
+	// Registered slices and array pointers by element type and data address,
+	// and the aliases found among them (if MatchArraySliceAliases).
+	arraySliceAliases map[arraySliceKey][]TypedPointer
+	arraySlicePairs   []ArraySliceAlias
+
+	// If true, the finder records the length and capacity of every slice it
+	// registers, so that slices of the same backing array that start at
+	// different elements can be found (see SliceOverlaps), and so that
+	// pointers into slices can be found (see ContainedPointers).
+	RecordSliceExtents bool
+
+	// The largest length and capacity seen for each registered slice (if
+	// RecordSliceExtents).
+	sliceExtents map[TypedPointer]sliceExtent
+
+	// If true, the finder records the path from the root at which each
+	// pointer was first found (see PathTo).
+	RecordPaths bool
+
+	// If true, pointers, maps, and slices are identified by the content of
+	// what they reference rather than by address, so that distinct objects
+	// with identical content are reported as duplicates of each other.
+	// Content that is identical is not scanned again. The addresses of struct
+	// fields are still identified by address. Scanned objects must not be modified while the
+	// finder is in use (see ContentHasher).
+	IdentifyByContent bool
+
+	// What to do with pointers to zero-sized objects (such as *struct{} or
+	// *[0]byte), which the runtime may place at the same address regardless
+	// of whether they are shared. The default (ZeroSizeGroup) is to leave
+	// their duplicates out of DuplicatePointers and reports; set
+	// ZeroSizeReport to report them like any other pointer.
+	ZeroSizedPointers ZeroSizePolicy
+
+	// If true, map entries are scanned in sorted key order, so that the
+	// discovery order of duplicates doesn't depend on Go's randomized map
+	// iteration. This costs a sort of every scanned map's keys. Pointer keys
+	// (including those within struct and array keys) are ordered by when
+	// they were first found. Pointers that haven't been found before the map
+	// is scanned come last, in address order, which isn't deterministic.
+	SortMapKeys bool
+
+	// If greater than 0, the scan doesn't descend into values deeper than
+	// MaxDepth levels below a root (a root is at depth 0), and marks the
+	// results as truncated (see Truncated).
+	MaxDepth int
+
+	// If greater than 0, scanning stops once MaxNodes values have been
+	// visited (counting all scans since the finder was initialized), keeping
+	// the results found so far and marking them as truncated (see
+	// Truncated). This guards against unexpectedly huge object graphs.
+	MaxNodes int
+
+	// The name of the struct tag holding field options. Fields tagged "-"
+	// (for example `duplicates:"-"`) are neither scanned nor reported, which
+	// is useful for parent back-pointers, caches, and the like. If empty, the
+	// tag name is "duplicates".
+	TagName string
+
+	// If true, channels are registered like pointers, so that channels
+	// referenced from more than one place are reported as duplicates.
+	RegisterChannels bool
+
+	// If true, funcs are registered like pointers, so that funcs referenced
+	// from more than one place are reported as duplicates. A func is
+	// identified by its code pointer (see reflect.Value.Pointer), which means
+	// that all closures created from the same function literal, and all
+	// method values of the same method, count as the same func even if they
+	// capture different variables or receivers.
+	RegisterFuncs bool
+
+	// If true, unsafe pointers are registered like pointers (but never
+	// dereferenced, since their type is unknown), so that aliasing in graphs
+	// built on low-level code is still reported. An unsafe pointer and a
+	// typed pointer to the same address count as different pointers.
+	RegisterUnsafePointers bool
+
+	// If true, empty slices and maps are registered like any other. By
+	// default they are not, since distinct empty slices can share a data
+	// pointer (and nothing can be observed through an empty map's sharing
+	// until something is added to it).
+	RegisterEmptyContainers bool
+
+	// The order in which objects are scanned. The default is DepthFirst.
+	Order TraversalOrder
+
+	// Whether to scan below pointers that have already been scanned below
+	// when they are found again. The default is RevisitNever.
+	Revisit RevisitPolicy
+
+	// If true, the keys of maps are scanned as well as their values, so that
+	// pointers held in keys (or in structs used as keys) are found.
+	ScanMapKeys bool
+
+	// If true, unexported struct fields are not scanned, as in encoding/json:
+	// only sharing visible through exported fields (including the exported
+	// fields of embedded structs) is found.
+	SkipUnexportedFields bool
+
+	// If true, values reached through unexported fields are read using
+	// unsafe, so that reflection treats them like any other value: type
+	// handlers, PointerEnumerable, and retained values (see RetainValues)
+	// can then use them freely. A struct or array root passed by value is
+	// first copied to addressable memory, so that its fields can be reached
+	// in the same way as those of a root passed by pointer.
+	UnsafeReads bool
+
+	// If not nil, FieldFilter is called before scanning each struct field,
+	// with the path of the struct holding the field (which is empty unless
+	// RecordPaths is set). The field is only scanned if it returns true. This
+	// allows excluding fields of types that can't be tagged.
+	FieldFilter func(field reflect.StructField, path Path) bool
+
+	// If not nil, OnPointer is called for every sighting of a pointer during
+	// a scan, with the path it was found at (which is empty unless
+	// RecordPaths is set), and whether it has been found to be a duplicate so
+	// far. The returned VisitAction can skip what the pointer references or
+	// stop the scan.
+	OnPointer func(ptr TypedPointer, path Path, duplicate bool) VisitAction
+
+	// If greater than 0, each scan stops once it has run for MaxDuration,
+	// keeping the results found so far and marking them as truncated (see
+	// Truncated). This stops pathological inputs from holding up a goroutine
+	// for long. Each run of a pausable scan (see NewScan) gets a budget of its
+	// own.
+	MaxDuration time.Duration
+
+	// If not nil, OnProgress is called every ProgressInterval visited values
+	// (100000 if ProgressInterval isn't set) with the running totals of the
+	// scan, so that long scans can report progress or log heartbeats.
+	OnProgress       func(progress Progress)
+	ProgressInterval int
+
+	// If greater than 0, only the first MaxElements elements of any slice,
+	// array, or map are scanned (in key order if SortMapKeys is set, and in
+	// arbitrary order otherwise). The results are marked as truncated (see
+	// Truncated), and the containers that were cut short are listed by
+	// TruncatedContainers.
+	MaxElements int
+
+	// If true, TryScanForPointers skips over any value whose scanning panics
+	// and carries on with the rest of the scan, rather than aborting.
+	SkipPanickingValues bool
+
+	// If true, the finder records which struct fields referred to each
+	// pointer, so that reports can show which fields introduce sharing (see
+	// DuplicateReport.FieldStats).
+	RecordFieldStats bool
+
+	// Content identity state (if IdentifyByContent): the record of each
+	// distinct content, and the pointers that were found to alias a record
+	// by content.
+	contentHasher  *ContentHasher
+	contentIndex   map[ContentDigest]int
+	contentAliases []contentAlias
+
+	// The roots other than the first that each pointer was found from.
+	otherRoots map[TypedPointer][]int
+
+	// The first-seen value of each registered pointer (if RetainValues).
+	values map[TypedPointer]reflect.Value
+
+	// All registered pointers, in the order they were first seen. recordIndex
+	// is the one index of them, while DuplicatePointers holds only the
+	// duplicates among them.
+	records     []pointerRecord
+	recordIndex map[TypedPointer]int
+
+	// The path nodes at which each record was first and second sighted (if
+	// RecordPaths), by record index.
+	recordPaths []recordPath
+
+	// If true, the finder keeps no records at all, and DuplicatePointers is
+	// the index of registered pointers instead, mapping each of them to
+	// whether it's a duplicate. This is only for finders that can't be
+	// queried or configured afterwards (see FindDuplicatePointers).
+	withoutRecords bool
+
+	// The path nodes of all visited values (if RecordPaths). Paths handed out
+	// refer to this arena, so it is never truncated and reused.
+	pathNodes []pathNode
+
+	// All sightings of already registered pointers (if RecordPaths).
+	repeatSightings []repeatSighting
+
+	// All sightings made directly through a struct field (if
+	// RecordFieldStats).
+	fieldSightings []fieldSighting
+
+	// All sightings that closed a cycle, in the order they were found, and the
+	// records of the members of each of their cycles.
+	backReferences []backReference
+	cycleMembers   []int
+
+	// Start indices into records of each open scope.
+	scopes []int
+
+	// Statistics of all scans (see Stats).
+	nodesVisited int
+	maxDepth     int
+	references   int
+	kindCounts   [reflect.UnsafePointer + 1]int
+	scanDuration time.Duration
+
+	// Whether a limit stopped the scan from reaching everything, and whether
+	// the current scan is being abandoned.
+	truncated bool
+	stopping  bool
+	// The containers that MaxElements cut short, possibly more than once
+	// each.
+	truncatedContainers []TypedPointer
+
+	// The pointers whose referents are yet to be scanned (if BreadthFirst).
+	queue []queuedReferent
+
+	// The current scan position.
+	depth     int
+	rootIndex int
+	rootCount int
+	// The number of roots that were passed by value (see CopiedRoots).
+	copiedRoots int
+	// The path node of the current position (if RecordPaths).
+	pathNode int32
+	// The struct field directly holding the value currently being scanned,
+	// and the address of the struct it belongs to (0 if not addressable).
+	referrer       fieldRef
+	referrerParent unsafe.Pointer
+	// Whether the pointer being registered is the address of a struct field,
+	// which is always identified by address.
+	registeringField bool
+	// Whether the field being registered is an embedded first field whose
+	// registration doesn't count as a sighting (see IgnoreEmbeddedAliases).
+	registeringEmbedded bool
+	// Whether the current position was reached through exported fields only.
+	exported bool
+	// Whether the scan is re-walking an already scanned subtree because it
+	// was found to be reachable through exported fields after all.
+	upgrading bool
+	// Whether the scan is re-walking an already scanned subtree because of
+	// RevisitAlways.
+	revisiting bool
+	// The referents currently being scanned, innermost last.
+	referents []activeReferent
+	// The pending work of the current scan, innermost last, and the map
+	// cursors that its frames use (the first cursorsInUse of them). Between
+	// scans, the frames are returned to frameStacks through pooledFrames.
+	frames       []scanFrame
+	pooledFrames *[]scanFrame
+	mapCursors   []*mapCursor
+	cursorsInUse int
+
+	plans *PlanCache
+
+	// The fields excluded by tag for each struct type checked so far, if
+	// TagName is not the default, and the tag name they were checked for.
+	excludedFields    map[reflect.Type][]bool
+	excludedFieldsTag string
+
+	// Interfaces whose implementers, and package path prefixes whose types,
+	// are registered but not descended into, and the cached decision for each
+	// type checked so far.
+	leafInterfaces []reflect.Type
+	leafPackages   []string
+	leafTypes      map[reflect.Type]bool
+
+	// The pointers and types that are known to be shared on purpose.
+	allowedShared      map[TypedPointer]bool
+	allowedSharedTypes map[reflect.Type]bool
+
+	// The handlers that replace the scanning of specific types, and how many
+	// of them (or of EnumerateChildPointers calls) are currently running.
+	typeHandlers map[reflect.Type]TypeHandler
+	handling     int
+
+	// The types to skip, the struct types to restrict registration to (if
+	// not nil), and the cached skip decision for each type checked so far.
+	skippedTypes map[reflect.Type]bool
+	allowedTypes map[reflect.Type]bool
+	typeSkips    map[reflect.Type]bool
+
+	// True while running a scan that converts panics into errors, and the
+	// steps to the current position (which must be known even when paths
+	// aren't being recorded).
+	containing bool
+	steps      []PathStep
+	// The panics recovered while skipping panicking values, and the values
+	// currently being scanned that a panic would skip to the end of.
+	scanErrors   []*ScanError
+	containments []containment
+
+	// When the current scan started, and when its time budget runs out (if
+	// MaxDuration is set).
+	scanStart time.Time
+	deadline  time.Time
+
+	// The context of the current scan, if any (see ScanForPointersContext),
+	// and the error that stopped the scan.
+	ctx    context.Context
+	ctxErr error
+
+	// The pausable scan currently running, if any.
+	scan *Scan
+	// Positions to resume the current scan from (outermost first), and how
+	// many of them have been used.
+	resumePositions []int
+	resumeLevel     int
+	// True while re-entering values that were partially scanned before the
+	// scan paused, which must not be registered again.
+	reentering bool
+	// True while unwinding a paused scan, and the positions it paused at
+	// (innermost first).
+	paused         bool
+	pausePositions []int
+
+	// Called for every sighting of a pointer during a scan, with the index of
+	// its record.
+	sightingHook func(index int)
 }
 
-func NewDuplicateFinder() *DuplicateFinder {
+// NewDuplicateFinder creates a new finder, configured by any options given
+// (see Option).
+func NewDuplicateFinder(options ...Option) *DuplicateFinder {
 	_this := &DuplicateFinder{}
 	_this.Init()
+	for _, option := range options {
+		option(_this)
+	}
 	return _this
 }
 
 func (_this *DuplicateFinder) Init() {
-	_this.DuplicatePointers = make(map[TypedPointer]bool)
+	_this.initWith(make(map[TypedPointer]bool), 0)
+}
+
+// InitWithCapacity initializes the finder with storage pre-sized for
+// expectedPointers registered pointers, avoiding incremental growth when the
+// size of the scanned graph is known in advance.
+func (_this *DuplicateFinder) InitWithCapacity(expectedPointers int) {
+	_this.initWith(make(map[TypedPointer]bool), expectedPointers)
+}
+
+// InitWithMap initializes the finder to store its results in the
+// caller-owned duplicatePointers, which becomes the finder's
+// DuplicatePointers. Any existing entries in the map are removed.
+func (_this *DuplicateFinder) InitWithMap(duplicatePointers map[TypedPointer]bool) {
+	for ptr := range duplicatePointers {
+		delete(duplicatePointers, ptr)
+	}
+	_this.initWith(duplicatePointers, 0)
+}
+
+func (_this *DuplicateFinder) initWith(duplicatePointers map[TypedPointer]bool, capacity int) {
+	if _this.plans == nil {
+		_this.plans = defaultPlanCache
+	}
+	_this.DuplicatePointers = duplicatePointers
+	if cap(_this.records) < capacity {
+		_this.records = make([]pointerRecord, 0, capacity)
+	}
+	_this.records = _this.records[:0]
+	_this.recordPaths = _this.recordPaths[:0]
+	_this.recordIndex = make(map[TypedPointer]int, capacity)
+	_this.rootCount = 0
+	_this.copiedRoots = 0
+	_this.resetStats()
+	_this.truncated = false
+	_this.truncatedContainers = _this.truncatedContainers[:0]
+	_this.pathNodes = nil
+	_this.backReferences = _this.backReferences[:0]
+	_this.cycleMembers = _this.cycleMembers[:0]
+	_this.fieldSightings = _this.fieldSightings[:0]
+	_this.repeatSightings = _this.repeatSightings[:0]
+	_this.scopes = _this.scopes[:0]
+	_this.values = nil
+	_this.otherRoots = nil
+	_this.arraySliceAliases = nil
+	_this.arraySlicePairs = nil
+	_this.sliceExtents = nil
+	_this.contentHasher = nil
+	_this.contentIndex = nil
+	_this.contentAliases = _this.contentAliases[:0]
+}
+
+// Reset clears everything the finder has found, like Init, but keeps the
+// finder's storage for reuse (including the DuplicatePointers map, which is
+// cleared). Once warmed up, a finder that is Reset between scans of
+// similarly-shaped objects doesn't allocate at all, provided that:
+//
+//   - RecordPaths, RecordFieldStats, RetainValues, IdentifyByContent,
+//     MatchArraySliceAliases, RecordSliceExtents, and SortMapKeys are not
+//     set,
+//   - map values are pointers, maps, channels, or funcs (reflect must copy
+//     out other map values), and
+//   - the scan is neither pausable nor converting panics to errors.
+func (_this *DuplicateFinder) Reset() {
+	for ptr := range _this.DuplicatePointers {
+		delete(_this.DuplicatePointers, ptr)
+	}
+	for ptr := range _this.recordIndex {
+		delete(_this.recordIndex, ptr)
+	}
+	_this.records = _this.records[:0]
+	_this.recordPaths = _this.recordPaths[:0]
+	_this.rootCount = 0
+	_this.copiedRoots = 0
+	_this.resetStats()
+	_this.truncated = false
+	_this.truncatedContainers = _this.truncatedContainers[:0]
+	if len(_this.pathNodes) > 0 {
+		// Paths already handed out still refer to the old arena.
+		_this.pathNodes = nil
+	}
+	_this.backReferences = _this.backReferences[:0]
+	_this.cycleMembers = _this.cycleMembers[:0]
+	_this.fieldSightings = _this.fieldSightings[:0]
+	_this.repeatSightings = _this.repeatSightings[:0]
+	_this.scopes = _this.scopes[:0]
+	_this.values = nil
+	_this.otherRoots = nil
+	_this.arraySliceAliases = nil
+	_this.arraySlicePairs = nil
+	_this.sliceExtents = nil
+	_this.contentHasher = nil
+	_this.contentIndex = nil
+	_this.contentAliases = _this.contentAliases[:0]
 }
 
 // Returns true if pointer has been recorded before.
 // This method panics if pointer's Kind is not Chan, Func, Map, Ptr, Slice,
 // or UnsafePointer.
 func (_this *DuplicateFinder) IsDuplicatePointer(pointer interface{}) bool {
-	return _this.DuplicatePointers[TypedPointerOf(pointer)]
+	return _this.DuplicatePointers[_this.typedPointerOf(reflect.ValueOf(pointer))]
 }
 
 // Returns true if pointer has been recorded before.
 // This method panics if pointer's Kind is not Chan, Func, Map, Ptr, Slice,
 // or UnsafePointer.
 func (_this *DuplicateFinder) IsDuplicateRVPointer(pointer reflect.Value) bool {
-	return _this.DuplicatePointers[TypedPointerOfRV(pointer)]
+	return _this.DuplicatePointers[_this.typedPointerOf(pointer)]
+}
+
+// ResolvePointer returns the value that ptr was first registered from,
+// allowing the object it refers to to be inspected. This only works if the
+// finder had RetainValues set while scanning.
+func (_this *DuplicateFinder) ResolvePointer(ptr TypedPointer) (value reflect.Value, ok bool) {
+	value, ok = _this.values[_this.identify(ptr)]
+	return
 }
 
 // Register a pointer, returning true if it has been recorded before.
 // This method panics if pointer's Kind is not Chan, Func, Map, Ptr, Slice,
 // or UnsafePointer.
 func (_this *DuplicateFinder) RegisterPointer(pointer reflect.Value) (alreadyExists bool) {
-	typedPtr := TypedPointerOfRV(pointer)
-	if _, ok := _this.DuplicatePointers[typedPtr]; ok {
-		_this.DuplicatePointers[typedPtr] = true
+	if _this.ZeroSizedPointers == ZeroSizeIgnore && hasZeroSizedReferent(pointer.Type()) {
+		return false
+	}
+	return _this.registerPointer(_this.typedPointerOf(pointer), pointer)
+}
+
+// Registers typedPtr, which identifies value. Usually value is the pointer
+// itself, but opaque values have identities of their own (see
+// opaqueIdentity).
+func (_this *DuplicateFinder) registerPointer(typedPtr TypedPointer, pointer reflect.Value) (alreadyExists bool) {
+	if _this.RecordSliceExtents && pointer.Kind() == reflect.Slice {
+		_this.recordSliceExtent(typedPtr, pointer)
+	}
+	if index, ok := _this.recordIndex[typedPtr]; ok {
+		_this.recordSighting(index)
 		return true
 	}
+	if _this.IdentifyByContent && !_this.registeringField {
+		if index, isDuplicate := _this.matchContent(pointer, typedPtr); isDuplicate {
+			_this.recordSighting(index)
+			return true
+		}
+	}
+
+	if len(_this.records) == cap(_this.records) {
+		// Double the capacity rather than letting append grow it gradually,
+		// which copies the records many more times over a large scan.
+		grown := make([]pointerRecord, len(_this.records), 2*cap(_this.records)+64)
+		copy(grown, _this.records)
+		_this.records = grown
+	}
+	_this.recordIndex[typedPtr] = len(_this.records)
+	_this.records = append(_this.records, pointerRecord{
+		pointer:       typedPtr,
+		sightings:     _this.firstSightings(),
+		depth:         int32(_this.depth),
+		firstRoot:     int32(_this.rootIndex),
+		exportedPath:  _this.exported,
+		fieldAddress:  _this.registeringField,
+		zeroSized:     _this.ZeroSizedPointers == ZeroSizeGroup && hasZeroSizedReferent(typedPtr.Type),
+		allowedShared: (_this.allowedShared != nil || _this.allowedSharedTypes != nil) && _this.isAllowedShared(typedPtr),
+		restricted:    _this.isRestrictedType(typedPtr.Type),
+	})
+	if _this.RecordPaths {
+		_this.recordFirstPath(len(_this.records) - 1)
+	}
+	if _this.RetainValues {
+		if _this.values == nil {
+			_this.values = make(map[TypedPointer]reflect.Value)
+		}
+		_this.values[typedPtr] = pointer
+	}
+	if _this.MatchArraySliceAliases {
+		_this.matchArraySliceAliases(typedPtr)
+	}
+	return false
+}
 
-	_this.DuplicatePointers[typedPtr] = false
+// Registers typedPtr in a finder without records, returning true if it's new.
+func (_this *DuplicateFinder) registerWithoutRecord(typedPtr TypedPointer) (isNew bool) {
+	isDuplicate, exists := _this.DuplicatePointers[typedPtr]
+	if !exists {
+		_this.DuplicatePointers[typedPtr] = false
+		return true
+	}
+	if !isDuplicate && !hasZeroSizedReferent(typedPtr.Type) {
+		_this.DuplicatePointers[typedPtr] = true
+	}
 	return false
 }
 
-// Scan an object and all subobjects for duplicate pointers.
+// Returns the number of sightings that a new record starts with.
+func (_this *DuplicateFinder) firstSightings() int32 {
+	if _this.registeringEmbedded {
+		return 0
+	}
+	return 1
+}
+
+func (_this *DuplicateFinder) recordSighting(index int) {
+	if _this.registeringEmbedded {
+		return
+	}
+	record := &_this.records[index]
+	record.sightings++
+	if record.sightings == 1 {
+		// Only the struct's own embedded field had been seen so far.
+		if _this.RecordPaths && index < len(_this.recordPaths) {
+			_this.recordPaths[index].first = _this.pathNode
+		}
+		return
+	}
+	if _this.RecordPaths {
+		if record.sightings == 2 && index < len(_this.recordPaths) {
+			_this.recordPaths[index].repeat = _this.pathNode
+		}
+		_this.repeatSightings = append(_this.repeatSightings, repeatSighting{
+			record:   index,
+			pathNode: _this.pathNode,
+		})
+	}
+	if int(record.firstRoot) != _this.rootIndex {
+		record.crossRoot = true
+		_this.addOtherRoot(record.pointer)
+	}
+	if !record.zeroSized && !record.allowedShared && !record.restricted {
+		_this.DuplicatePointers[record.pointer] = true
+	}
+}
+
+// PushScope opens a new scope. Pointers registered while the scope is open
+// belong to it, but are still checked against the pointers of all enclosing
+// scopes.
+func (_this *DuplicateFinder) PushScope() {
+	_this.scopes = append(_this.scopes, len(_this.records))
+}
+
+// PopScope closes the current scope, forgetting all pointers that were first
+// registered within it, and returns those of them that were duplicates.
+// Pointers of enclosing scopes that were found again within the scope remain
+// marked as duplicates in the finder.
+//
+// This method panics if there is no open scope.
+func (_this *DuplicateFinder) PopScope() (scopeDuplicates map[TypedPointer]bool) {
+	if len(_this.scopes) == 0 {
+		panic("duplicates: PopScope called without a matching PushScope")
+	}
+	start := _this.scopes[len(_this.scopes)-1]
+	_this.scopes = _this.scopes[:len(_this.scopes)-1]
+
+	scopeDuplicates = make(map[TypedPointer]bool)
+	for _, record := range _this.records[start:] {
+		typedPtr := record.pointer
+		if _this.DuplicatePointers[typedPtr] {
+			scopeDuplicates[typedPtr] = true
+		}
+		delete(_this.DuplicatePointers, typedPtr)
+		delete(_this.recordIndex, typedPtr)
+		delete(_this.values, typedPtr)
+		delete(_this.otherRoots, typedPtr)
+		delete(_this.sliceExtents, typedPtr)
+	}
+	_this.records = _this.records[:start]
+	if len(_this.recordPaths) > start {
+		_this.recordPaths = _this.recordPaths[:start]
+	}
+
+	count := 0
+	for _, backRef := range _this.backReferences {
+		if backRef.record < start {
+			_this.backReferences[count] = backRef
+			count++
+		}
+	}
+	_this.backReferences = _this.backReferences[:count]
+
+	count = 0
+	for _, sighting := range _this.repeatSightings {
+		if sighting.record < start {
+			_this.repeatSightings[count] = sighting
+			count++
+		}
+	}
+	_this.repeatSightings = _this.repeatSightings[:count]
+
+	count = 0
+	for _, sighting := range _this.fieldSightings {
+		if sighting.record < start {
+			_this.fieldSightings[count] = sighting
+			count++
+		}
+	}
+	_this.fieldSightings = _this.fieldSightings[:count]
+
+	count = 0
+	for _, alias := range _this.contentAliases {
+		if alias.record < start {
+			_this.contentAliases[count] = alias
+			count++
+		} else {
+			delete(_this.DuplicatePointers, alias.pointer)
+			delete(_this.recordIndex, alias.pointer)
+		}
+	}
+	_this.contentAliases = _this.contentAliases[:count]
+	for digest, index := range _this.contentIndex {
+		if index >= start {
+			delete(_this.contentIndex, digest)
+		}
+	}
+	return
+}
+
+// ScopeDepth returns the number of currently open scopes.
+func (_this *DuplicateFinder) ScopeDepth() int {
+	return len(_this.scopes)
+}
+
+// NodesVisited returns the number of values visited by all scans since the
+// finder was initialized.
+func (_this *DuplicateFinder) NodesVisited() int {
+	return _this.nodesVisited
+}
+
+// ReferenceCounts returns how many times each registered pointer was
+// encountered. Pointers that were found only once have a count of 1.
+func (_this *DuplicateFinder) ReferenceCounts() map[TypedPointer]int {
+	counts := make(map[TypedPointer]int, len(_this.records))
+	for _, record := range _this.records {
+		if !record.restricted {
+			counts[record.pointer] = int(record.sightings)
+		}
+	}
+	return counts
+}
+
+// Scan an object and all subobjects for duplicate pointers. Each call scans a
+// new root; pointers found from more than one root are shared across roots.
+//
+// object may be of any type. Structs and arrays passed by value are scanned
+// from the copy made by the call (see CopiedRoots), so pass a pointer to them
+// if pointers into their own memory should be matched.
 func (_this *DuplicateFinder) ScanForPointers(object interface{}) {
-	_this.scanValue(reflect.ValueOf(object))
+	rootIndex := _this.rootCount
+	_this.rootCount++
+	_this.scanRoot(reflect.ValueOf(object), rootIndex)
+}
+
+func (_this *DuplicateFinder) scanRoot(root reflect.Value, rootIndex int) {
+	_this.acquireFrames()
+	defer _this.releaseFrames()
+	defer _this.recoverStoppedScan()
+	start := time.Now()
+	_this.scanStart = start
+	if _this.MaxDuration > 0 {
+		_this.deadline = start.Add(_this.MaxDuration)
+	}
+	_this.rootIndex = rootIndex
+	_this.depth = 0
+	_this.pathNode = noPathNode
+	_this.exported = true
+	_this.referrer = fieldRef{}
+	_this.clearQueue()
+	if isCopiedRoot(root) {
+		_this.copiedRoots++
+		if _this.UnsafeReads {
+			root = addressableCopy(root)
+		}
+	}
+	_this.scanValue(root)
+	_this.scanQueue()
+	_this.scanDuration += time.Since(start)
+}
+
+// CopiedRoots returns the number of roots since the finder was initialized
+// that were structs or arrays passed by value. Such a root is a copy, so
+// pointers, maps, and slices inside it are still followed, but the addresses
+// of its own fields and elements are those of the copy, and won't match any
+// pointer to the original.
+func (_this *DuplicateFinder) CopiedRoots() int {
+	return _this.copiedRoots
+}
+
+func isCopiedRoot(root reflect.Value) bool {
+	switch root.Kind() {
+	case reflect.Struct, reflect.Array:
+		return !root.CanAddr() && root.Type().Size() > 0
+	default:
+		return false
+	}
+}
+
+func (_this *DuplicateFinder) scanChild(value reflect.Value, step PathStep) {
+	parent := _this.enterChild(step)
+	referrer := _this.referrer
+	_this.referrer = fieldRef{}
+	_this.scanValue(value)
+	_this.referrer = referrer
+	_this.leaveChild(parent)
+}
+
+func (_this *DuplicateFinder) enterChild(step PathStep) (parent int32) {
+	_this.depth++
+	if _this.depth > _this.maxDepth {
+		_this.maxDepth = _this.depth
+	}
+	return _this.pushStep(step)
+}
+
+func (_this *DuplicateFinder) leaveChild(parent int32) {
+	_this.popStep(parent)
+	_this.depth--
+}
+
+func (_this *DuplicateFinder) pushStep(step PathStep) (parent int32) {
+	parent = _this.pathNode
+	if _this.RecordPaths {
+		_this.pathNode = _this.appendPathNode(parent, step)
+	}
+	if _this.containing {
+		_this.steps = append(_this.steps, step)
+	}
+	return
+}
+
+func (_this *DuplicateFinder) popStep(parent int32) {
+	if _this.containing {
+		_this.steps = _this.steps[:len(_this.steps)-1]
+	}
+	_this.pathNode = parent
+}
+
+// Counts value as visited, stopping the scan if MaxNodes is exceeded.
+func (_this *DuplicateFinder) countNode(value reflect.Value) {
+	_this.nodesVisited++
+	if _this.MaxNodes > 0 && _this.nodesVisited > _this.MaxNodes {
+		_this.nodesVisited--
+		_this.truncated = true
+		_this.stopScan()
+	}
+	if _this.scan != nil {
+		_this.scan.visited++
+	}
+	if _this.isInterruptible() && _this.nodesVisited%interruptCheckInterval == 0 {
+		_this.checkInterrupts()
+	}
+	if _this.OnProgress != nil && _this.isProgressDue() {
+		_this.reportProgress()
+	}
+	_this.kindCounts[value.Kind()]++
 }
 
-func (_this *DuplicateFinder) scanValue(value reflect.Value) {
+func (_this *DuplicateFinder) scanKind(value reflect.Value) {
+	if value.IsValid() && _this.isSkippedType(value.Type()) {
+		return
+	}
+	if !_this.reentering {
+		// Values that a resumed scan re-enters were counted before it paused.
+		_this.countNode(value)
+	}
+	if _this.UnsafeReads && value.IsValid() && !value.CanInterface() {
+		value = readableView(value)
+	}
+	if len(_this.typeHandlers) > 0 && value.IsValid() && _this.handleType(value) {
+		return
+	}
 	switch value.Kind() {
 	case reflect.Interface:
 		if value.IsNil() {
 			return
 		}
 		elem := value.Elem()
-		if !isScannableKind(elem.Kind()) {
+		if !_this.isScannable(elem.Kind()) {
 			return
 		}
-		_this.scanValue(elem)
-	case reflect.Ptr:
+		// The interface and its dynamic value are at the same depth.
+		parent := _this.pushStep(PathStep{Kind: StepTypeAssertion, Container: elem.Type()})
+		_this.pushFrame(scanFrame{op: opPopStep, parent: parent})
+		_this.pushValue(elem)
+	case reflect.Map, reflect.Slice:
 		if value.IsNil() {
 			return
 		}
-		if _this.RegisterPointer(value) {
+		if value.Len() == 0 && !_this.RegisterEmptyContainers {
 			return
 		}
-		elem := value.Elem()
-		if !isScannableKind(elem.Kind()) {
-			return
-		}
-		_this.scanValue(elem)
-	case reflect.Map:
+		_this.scanPointer(value)
+	case reflect.Ptr:
 		if value.IsNil() {
 			return
 		}
-		if value.Len() == 0 {
-			return
-		}
-		if _this.RegisterPointer(value) {
-			return
-		}
-		if !isScannableKind(value.Type().Elem().Kind()) {
-			return
-		}
-		iter := mapRange(value)
-		for iter.Next() {
-			_this.scanValue(iter.Value())
-		}
-	case reflect.Slice:
-		if value.IsNil() {
+		_this.scanPointer(value)
+	case reflect.Array:
+		if !_this.isElemScannable(_this.plans.planFor(value.Type())) {
 			return
 		}
 		if value.Len() == 0 {
 			return
 		}
-		if _this.RegisterPointer(value) {
+		_this.pushElements(value, false)
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		if !_this.isRegisteredKind(value.Kind()) || value.IsNil() {
 			return
 		}
-		if !isScannableKind(value.Type().Elem().Kind()) {
+		_this.enterPointer(value)
+	case reflect.Struct:
+		if isOpaqueType(value.Type()) {
+			_this.visitOpaque(value)
 			return
 		}
-		count := value.Len()
-		for i := 0; i < count; i++ {
-			_this.scanValue(value.Index(i))
-		}
-	case reflect.Array:
-		if !isScannableKind(value.Type().Elem().Kind()) {
+		if _this.isLeafType(value.Type()) {
 			return
 		}
-		if value.Len() == 0 {
-			return
+		_this.pushFields(value)
+	}
+}
+
+// Registers the address of a field, and begins scanning the field's contents
+// (returning true) if they haven't been scanned before. The caller must then
+// scan the field, and end the referent.
+func (_this *DuplicateFinder) beginAddressableField(field reflect.Value, referrer fieldRef) bool {
+	if _this.isSkippedType(field.Type()) {
+		return false
+	}
+	_this.referrer = fieldRef{}
+	_this.registeringField = true
+	_this.registeringEmbedded = _this.IgnoreEmbeddedAliases && isEmbeddedAtStart(referrer)
+	index, isNew, isUpgrade := _this.enterPointer(field.Addr())
+	_this.registeringField = false
+	_this.registeringEmbedded = false
+	revisit := !isNew && !isUpgrade && _this.shouldRevisit(index)
+	if !isNew && !isUpgrade && !revisit {
+		return false
+	}
+	if !_this.isScannable(field.Kind()) {
+		return false
+	}
+
+	if revisit {
+		_this.beginRevisit(index)
+	} else {
+		_this.beginReferent(index, isUpgrade)
+	}
+	_this.referrer = referrer
+	return true
+}
+
+// Returns true if referrer is an embedded field at the start of its struct.
+func isEmbeddedAtStart(referrer fieldRef) bool {
+	field := referrer.container.Field(referrer.index)
+	return field.Anonymous && field.Offset == 0
+}
+
+// Registers a non-nil pointer, map, or slice, and begins scanning what it
+// references if it hasn't been scanned before.
+func (_this *DuplicateFinder) scanPointer(value reflect.Value) {
+	index, isNew, isUpgrade := _this.enterPointer(value)
+	revisit := !isNew && !isUpgrade && _this.shouldRevisit(index)
+	if !isNew && !isUpgrade && !revisit {
+		return
+	}
+	plan := _this.plans.planFor(value.Type())
+	if !(_this.isElemScannable(plan) || (_this.ScanMapKeys && plan.keyScannable)) || _this.isLeafType(value.Type()) {
+		return
+	}
+
+	if isNew && _this.isQueueing() {
+		_this.queueReferent(index, value)
+		return
+	}
+	if isUpgrade && _this.records[index].queued {
+		// It will be scanned with its new exported state when dequeued.
+		return
+	}
+
+	if revisit {
+		_this.beginRevisit(index)
+	} else {
+		_this.beginReferent(index, isUpgrade)
+	}
+	_this.pushElements(value, true)
+}
+
+// Visits a pointer, unless the scan is resuming through it (in which case it
+// was already visited before the scan paused).
+func (_this *DuplicateFinder) enterPointer(pointer reflect.Value) (index int, isNew, isUpgrade bool) {
+	if _this.reentering {
+		return _this.recordIndex[_this.typedPointerOf(pointer)], true, false
+	}
+	index, isNew, isUpgrade = _this.visitPointer(pointer)
+	if index < 0 {
+		return
+	}
+	if _this.OnPointer != nil && (isNew || !_this.upgrading) {
+		if _this.visitOnPointer(_this.typedPointerOf(pointer), index, isNew) {
+			isNew = false
 		}
-		count := value.Len()
-		for i := 0; i < count; i++ {
-			_this.scanValue(value.Index(i))
+	}
+	if isUpgrade && _this.records[index].skipped {
+		isUpgrade = false
+	}
+	return
+}
+
+// Marks the start of scanning what the pointer at index references.
+func (_this *DuplicateFinder) beginReferent(index int, isUpgrade bool) {
+	if _this.withoutRecords {
+		return
+	}
+	referent := activeReferent{
+		index:       index,
+		wasScanning: _this.records[index].scanning,
+	}
+	_this.records[index].scanning = true
+	if isUpgrade && !_this.upgrading {
+		_this.upgrading = true
+		referent.startedUpgrade = true
+	}
+	_this.referents = append(_this.referents, referent)
+}
+
+// Marks the end of scanning the most recently begun referent.
+func (_this *DuplicateFinder) endReferent() {
+	if _this.withoutRecords {
+		return
+	}
+	last := len(_this.referents) - 1
+	referent := _this.referents[last]
+	_this.referents = _this.referents[:last]
+	_this.records[referent.index].scanning = referent.wasScanning
+	if referent.startedUpgrade {
+		_this.upgrading = false
+	}
+	if referent.startedRevisit {
+		_this.revisiting = false
+	}
+}
+
+// Records a sighting of pointer during a scan. isNew is true if pointer has
+// never been seen before. isUpgrade is true if pointer has been seen before,
+// but is now reachable through exported fields for the first time (meaning
+// that its subtree must be walked again to propagate this).
+func (_this *DuplicateFinder) visitPointer(pointer reflect.Value) (index int, isNew, isUpgrade bool) {
+	typedPtr := _this.typedPointerOf(pointer)
+	if _this.withoutRecords {
+		return -1, _this.registerWithoutRecord(typedPtr), false
+	}
+	index, ok := _this.recordIndex[typedPtr]
+	if !ok {
+		if _this.ZeroSizedPointers == ZeroSizeIgnore && hasZeroSizedReferent(typedPtr.Type) {
+			return -1, false, false
 		}
-	case reflect.Struct:
-		for i := 0; i < value.NumField(); i++ {
-			field := value.Field(i)
-			if field.CanAddr() {
-				field = field.Addr()
-			}
-			if isScannableKind(field.Kind()) {
-				_this.scanValue(field)
-			}
+		if _this.RegisterPointer(pointer) {
+			// Identical content to an already registered pointer.
+			index = _this.recordIndex[typedPtr]
+			_this.onSighting(index)
+			return index, false, false
 		}
+		index = len(_this.records) - 1
+		_this.onSighting(index)
+		return index, true, false
+	}
+
+	if _this.RecordSliceExtents && pointer.Kind() == reflect.Slice {
+		_this.recordSliceExtent(typedPtr, pointer)
+	}
+	record := &_this.records[index]
+	if _this.exported && !record.exportedPath {
+		record.exportedPath = true
+		isUpgrade = true
+	}
+	if _this.revisiting && !_this.upgrading {
+		record.revisits++
+	} else if !_this.upgrading {
+		_this.recordSighting(index)
+		if record.scanning {
+			// The pointer is one of our own ancestors.
+			record.inCycle = true
+			_this.backReferences = append(_this.backReferences, backReference{
+				record:       index,
+				pathNode:     _this.pathNode,
+				membersStart: len(_this.cycleMembers),
+			})
+			_this.recordCycleMembers(index)
+		}
+		_this.onSighting(index)
+	}
+	return
+}
+
+func (_this *DuplicateFinder) onSighting(index int) {
+	if !_this.registeringField {
+		_this.references++
+	}
+	if _this.RecordFieldStats && _this.referrer.container != nil {
+		_this.fieldSightings = append(_this.fieldSightings, fieldSighting{
+			record: index,
+			field:  _this.referrer,
+		})
+	}
+	if _this.sightingHook != nil {
+		_this.sightingHook(index)
 	}
 }
 
@@ -188,3 +1146,132 @@ const scannableKinds uint = (uint(1) << reflect.Interface) |
 func isScannableKind(kind reflect.Kind) bool {
 	return scannableKinds&(uint(1)<<kind) != 0
 }
+
+// Returns true if values of kind must be visited, which includes channels,
+// funcs, and unsafe pointers if they are registered.
+func (_this *DuplicateFinder) isScannable(kind reflect.Kind) bool {
+	return isScannableKind(kind) || _this.isRegisteredKind(kind)
+}
+
+// Returns true if the elements described by plan must be visited.
+func (_this *DuplicateFinder) isElemScannable(plan *typePlan) bool {
+	return plan.elemScannable || _this.isRegisteredKind(plan.elemKind)
+}
+
+// Returns true if channels, funcs, or unsafe pointers of kind are registered
+// as pointers.
+func (_this *DuplicateFinder) isRegisteredKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Chan:
+		return _this.RegisterChannels
+	case reflect.Func:
+		return _this.RegisterFuncs
+	case reflect.UnsafePointer:
+		return _this.RegisterUnsafePointers
+	default:
+		return false
+	}
+}
+
+type pointerRecord struct {
+	pointer TypedPointer
+	// How many times the pointer was encountered.
+	sightings int32
+	// The scan depth at which the pointer was first encountered.
+	depth int32
+	// The root that the pointer was first encountered from.
+	firstRoot int32
+	// How many times the pointer was reached while revisiting (see
+	// RevisitAlways).
+	revisits int32
+	// Whether the pointer was also encountered from another root.
+	crossRoot bool
+	// Whether the pointer was reached through exported fields only.
+	exportedPath bool
+	// Whether the pointer is the address of a struct field (and thus refers
+	// to part of another object rather than to an object of its own).
+	fieldAddress bool
+	// Whether the pointer's referent is currently being scanned (meaning that
+	// the pointer is an ancestor of the current scan position).
+	scanning bool
+	// Whether the pointer was encountered again from within its own referent.
+	inCycle bool
+	// Whether OnPointer asked for the pointer's referent not to be scanned.
+	skipped bool
+	// Whether the pointer's referent is waiting to be scanned in
+	// breadth-first order.
+	queued bool
+	// Whether the pointer's referent has zero size, and its duplicates are
+	// reported separately (see ZeroSizeGroup).
+	zeroSized bool
+	// Whether the pointer is known to be shared on purpose, and is never
+	// reported as a duplicate.
+	allowedShared bool
+	// Whether the pointer is only followed, and never reported, because of
+	// RestrictToTypes.
+	restricted bool
+}
+
+// The path nodes at which a pointer was first encountered, and at which it
+// was encountered for the second time.
+type recordPath struct {
+	first  int32
+	repeat int32
+}
+
+// Notes the current path as where the record at index was first sighted.
+// Records registered while RecordPaths was off have no paths.
+func (_this *DuplicateFinder) recordFirstPath(index int) {
+	for len(_this.recordPaths) < index {
+		_this.recordPaths = append(_this.recordPaths, recordPath{first: noPathNode, repeat: noPathNode})
+	}
+	_this.recordPaths = append(_this.recordPaths, recordPath{first: _this.pathNode, repeat: noPathNode})
+}
+
+// Returns the path nodes at which the record at index was sighted.
+func (_this *DuplicateFinder) pathsOfRecord(index int) recordPath {
+	if index < len(_this.recordPaths) {
+		return _this.recordPaths[index]
+	}
+	return recordPath{first: noPathNode, repeat: noPathNode}
+}
+
+// A pointer whose referent is currently being scanned.
+type activeReferent struct {
+	index          int
+	wasScanning    bool
+	startedUpgrade bool
+	startedRevisit bool
+}
+
+// Identifies a struct field.
+type fieldRef struct {
+	// The struct type, or nil if this doesn't refer to a field.
+	container reflect.Type
+	index     int
+}
+
+// A sighting of a pointer made directly through a struct field.
+type fieldSighting struct {
+	// Index into records.
+	record int
+	field  fieldRef
+}
+
+// A sighting of an already registered pointer.
+type repeatSighting struct {
+	// Index into records.
+	record   int
+	pathNode int32
+}
+
+// A sighting of a pointer from within its own referent, closing a cycle.
+type backReference struct {
+	// Index into records.
+	record int
+	// The path node of the sighting.
+	pathNode int32
+	// The range of cycleMembers holding the members of the cycle.
+	membersStart int
+	membersEnd   int
+}
@@ -3,9 +3,11 @@ package duplicates
 import (
 	"fmt"
 	"net/url"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
+	"unsafe"
 )
 
 func mapDifference(a, b map[TypedPointer]bool) (difference []TypedPointer) {
@@ -31,6 +33,24 @@ func assertNoDuplicates(t *testing.T, value interface{}) {
 	}
 }
 
+// Returns only the duplicates of a DuplicatePointers map, whose
+// non-duplicates may or may not be present.
+func onlyDuplicates(duplicatePtrs map[TypedPointer]bool) map[TypedPointer]bool {
+	duplicates := make(map[TypedPointer]bool)
+	for ptr, isDuplicate := range duplicatePtrs {
+		if isDuplicate {
+			duplicates[ptr] = true
+		}
+	}
+	return duplicates
+}
+
+// Returns true if ptr was registered, whether or not it's a duplicate.
+func isRegistered(finder *DuplicateFinder, ptr TypedPointer) bool {
+	_, ok := finder.recordIndex[ptr]
+	return ok
+}
+
 func assertDuplicates(t *testing.T, value interface{}, expectedDuplicates ...interface{}) {
 	expected := make(map[TypedPointer]bool)
 	for _, dup := range expectedDuplicates {
@@ -326,3 +346,343 @@ func Demonstrate() {
 func TestDemonstrate(t *testing.T) {
 	Demonstrate()
 }
+
+func TestDuplicatesScopes(t *testing.T) {
+	outer := 1
+	inner := 2
+	finder := NewDuplicateFinder()
+	finder.ScanForPointers(&outer)
+
+	finder.PushScope()
+	finder.ScanForPointers([]*int{&inner, &inner, &outer})
+	if finder.ScopeDepth() != 1 {
+		t.Errorf("Expected scope depth 1 but got %v", finder.ScopeDepth())
+	}
+	scopeDups := finder.PopScope()
+
+	if !scopeDups[TypedPointerOf(&inner)] || len(scopeDups) != 1 {
+		t.Errorf("Expected only &inner as a scope duplicate but got %v", describeDuplicates(scopeDups))
+	}
+	if !finder.IsDuplicatePointer(&outer) {
+		t.Errorf("Expected &outer to be a duplicate")
+	}
+	if finder.IsDuplicatePointer(&inner) {
+		t.Errorf("Expected &inner to have been forgotten")
+	}
+
+	finder.ScanForPointers(&inner)
+	if finder.IsDuplicatePointer(&inner) {
+		t.Errorf("Expected &inner to not be a duplicate after its scope was popped")
+	}
+}
+
+func TestInitWithMap(t *testing.T) {
+	v := 1
+	stale := 2
+	results := map[TypedPointer]bool{TypedPointerOf(&stale): true}
+
+	finder := &DuplicateFinder{}
+	finder.InitWithMap(results)
+	finder.ScanForPointers([]*int{&v, &v})
+	if !results[TypedPointerOf(&v)] {
+		t.Errorf("Expected results to be stored in the supplied map")
+	}
+	if _, ok := results[TypedPointerOf(&stale)]; ok {
+		t.Errorf("Expected existing entries to be removed")
+	}
+}
+
+func TestInitWithCapacity(t *testing.T) {
+	v := 1
+	finder := &DuplicateFinder{}
+	finder.InitWithCapacity(100)
+	finder.ScanForPointers([]*int{&v, &v})
+	if !finder.IsDuplicatePointer(&v) {
+		t.Errorf("Expected &v to be a duplicate")
+	}
+	if cap(finder.records) < 100 {
+		t.Errorf("Expected storage for 100 pointers but got %v", cap(finder.records))
+	}
+}
+
+func TestReferenceCounts(t *testing.T) {
+	v1 := 1
+	v2 := 2
+	slice := []*int{&v1, &v2, &v1, &v1}
+	finder := NewDuplicateFinder()
+	finder.ScanForPointers(slice)
+
+	counts := finder.ReferenceCounts()
+	expected := map[TypedPointer]int{
+		TypedPointerOf(slice): 1,
+		TypedPointerOf(&v1):   3,
+		TypedPointerOf(&v2):   1,
+	}
+	if len(counts) != len(expected) {
+		t.Errorf("Expected %v counts but got %v", len(expected), counts)
+	}
+	for ptr, count := range expected {
+		if counts[ptr] != count {
+			t.Errorf("Expected %v to be counted %v times but got %v", ptr, count, counts[ptr])
+		}
+	}
+	if info, _ := finder.Report().Info(TypedPointerOf(&v1)); info.Count != 3 {
+		t.Errorf("Expected the report to count &v1 3 times but got %v", info.Count)
+	}
+}
+
+func TestResolvePointer(t *testing.T) {
+	v := 1
+	finder := NewDuplicateFinder()
+	finder.RetainValues = true
+	finder.ScanForPointers([]*int{&v})
+
+	value, ok := finder.ResolvePointer(TypedPointerOf(&v))
+	if !ok || value.Interface().(*int) != &v {
+		t.Errorf("Expected &v to resolve to itself but got %v (%v)", value, ok)
+	}
+	other := 2
+	if _, ok := finder.ResolvePointer(TypedPointerOf(&other)); ok {
+		t.Errorf("Expected an unregistered pointer not to resolve")
+	}
+}
+
+type mapKeyStruct struct {
+	Name *string
+}
+
+func TestScanMapKeys(t *testing.T) {
+	a, b := 1, 2
+	name := "name"
+	root := struct {
+		Set    map[*int]bool
+		ByName map[mapKeyStruct]*int
+		Other  *int
+		Name   *string
+	}{
+		Set:    map[*int]bool{&a: true},
+		ByName: map[mapKeyStruct]*int{{Name: &name}: &b},
+		Other:  &a,
+		Name:   &name,
+	}
+
+	if FindDuplicatePointers(root)[TypedPointerOf(&a)] {
+		t.Errorf("Expected map keys not to be scanned by default")
+	}
+
+	finder := NewDuplicateFinder(WithMapKeys(), WithPaths())
+	finder.ScanForPointers(root)
+	if !finder.IsDuplicatePointer(&a) || !finder.IsDuplicatePointer(&name) {
+		t.Errorf("Expected pointers in map keys to be found")
+	}
+	if !isRegistered(finder, TypedPointerOf(&b)) {
+		t.Errorf("Expected map values to still be scanned")
+	}
+	path, _ := finder.PathTo(TypedPointerOf(&name))
+	if !strings.HasPrefix(path.String(), ".ByName{") || !strings.HasSuffix(path.String(), "}.Name") {
+		t.Errorf("Expected a path through the map key but got %v", path)
+	}
+
+	scanned := NewDuplicateFinder(WithMapKeys())
+	scan := scanned.NewScan(root)
+	for scan.RunNodes(2) != nil {
+	}
+	if !reflect.DeepEqual(scanned.DuplicatePointers, finder.DuplicatePointers) {
+		t.Errorf("Expected a paused scan to find %v but got %v", finder.DuplicatePointers, scanned.DuplicatePointers)
+	}
+}
+
+func TestRegisterChannels(t *testing.T) {
+	shared := make(chan int)
+	other := make(chan int)
+	root := struct {
+		Input  chan int
+		output chan int
+		Any    interface{}
+		All    [2]chan int
+		Other  chan int
+		None   chan int
+	}{
+		Input:  shared,
+		output: shared,
+		Any:    shared,
+		All:    [2]chan int{shared, other},
+		Other:  other,
+	}
+
+	defaults := NewDuplicateFinder()
+	defaults.ScanForPointers(root)
+	if isRegistered(defaults, TypedPointerOf(shared)) {
+		t.Errorf("Expected channels not to be registered by default")
+	}
+
+	finder := NewDuplicateFinder(WithChannels())
+	finder.ScanForPointers(root)
+	if !finder.IsDuplicatePointer(shared) || !finder.IsDuplicatePointer(other) {
+		t.Errorf("Expected shared channels to be duplicates")
+	}
+	if finder.ReferenceCounts()[TypedPointerOf(shared)] != 4 {
+		t.Errorf("Expected 4 references to the shared channel but got %v", finder.ReferenceCounts()[TypedPointerOf(shared)])
+	}
+}
+
+func registerFuncsCallback() {}
+
+func TestRegisterFuncs(t *testing.T) {
+	unshared := func() {}
+	root := struct {
+		OnStart func()
+		OnStop  func()
+		hooks   []func()
+		Other   func()
+		None    func()
+	}{
+		OnStart: registerFuncsCallback,
+		OnStop:  registerFuncsCallback,
+		hooks:   []func(){registerFuncsCallback},
+		Other:   unshared,
+	}
+
+	defaults := NewDuplicateFinder()
+	defaults.ScanForPointers(root)
+	if isRegistered(defaults, TypedPointerOf(registerFuncsCallback)) {
+		t.Errorf("Expected funcs not to be registered by default")
+	}
+
+	finder := NewDuplicateFinder(WithFuncs())
+	finder.ScanForPointers(root)
+	if !finder.IsDuplicatePointer(registerFuncsCallback) {
+		t.Errorf("Expected the shared func to be a duplicate")
+	}
+	if finder.IsDuplicatePointer(unshared) {
+		t.Errorf("Expected the unshared func not to be a duplicate")
+	}
+	if finder.ReferenceCounts()[TypedPointerOf(registerFuncsCallback)] != 3 {
+		t.Errorf("Expected 3 references to the shared func but got %v", finder.ReferenceCounts()[TypedPointerOf(registerFuncsCallback)])
+	}
+}
+
+func TestRegisterUnsafePointers(t *testing.T) {
+	v := 1
+	raw := unsafe.Pointer(&v)
+	root := struct {
+		Handle  unsafe.Pointer
+		handles []unsafe.Pointer
+		Typed   *int
+		None    unsafe.Pointer
+	}{
+		Handle:  raw,
+		handles: []unsafe.Pointer{raw},
+		Typed:   &v,
+	}
+
+	defaults := NewDuplicateFinder()
+	defaults.ScanForPointers(root)
+	if isRegistered(defaults, TypedPointerOf(raw)) {
+		t.Errorf("Expected unsafe pointers not to be registered by default")
+	}
+
+	finder := NewDuplicateFinder(WithUnsafePointers())
+	finder.ScanForPointers(root)
+	if !finder.IsDuplicatePointer(raw) {
+		t.Errorf("Expected the shared unsafe pointer to be a duplicate")
+	}
+	if finder.IsDuplicatePointer(&v) {
+		t.Errorf("Expected the typed pointer to be separate from the unsafe pointer")
+	}
+}
+
+func TestRegisterEmptyContainers(t *testing.T) {
+	backing := make([]int, 0, 4)
+	empty := map[string]int{}
+	root := struct {
+		A, B []int
+		C, D map[string]int
+	}{backing, backing[:0], empty, empty}
+
+	finder := NewDuplicateFinder()
+	finder.ScanForPointers(root)
+	if len(finder.records) != 0 {
+		t.Errorf("Expected empty containers not to be registered by default but got %v", finder.OccurrenceCounts())
+	}
+
+	finder = NewDuplicateFinder(WithEmptyContainers())
+	finder.ScanForPointers(root)
+	if !finder.IsDuplicatePointer(backing) || !finder.IsDuplicatePointer(empty) {
+		t.Errorf("Expected shared empty containers to be duplicates")
+	}
+}
+
+type zeroAllocNode struct {
+	Name     string
+	Children []*zeroAllocNode
+	ByName   map[string]*zeroAllocNode
+	Any      interface{}
+	Array    [2]*zeroAllocNode
+}
+
+func TestResetZeroAllocations(t *testing.T) {
+	shared := &zeroAllocNode{Name: "shared"}
+	root := &zeroAllocNode{
+		Children: []*zeroAllocNode{shared, {Name: "a", Any: shared}},
+		ByName:   map[string]*zeroAllocNode{"x": shared, "y": {}},
+		Array:    [2]*zeroAllocNode{shared, nil},
+	}
+
+	finder := NewDuplicateFinder()
+	allocs := testing.AllocsPerRun(100, func() {
+		finder.Reset()
+		finder.ScanForPointers(root)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations but got %v", allocs)
+	}
+	if !finder.IsDuplicatePointer(shared) {
+		t.Errorf("Expected shared to be a duplicate")
+	}
+}
+
+func TestCopiedRoots(t *testing.T) {
+	type holder struct {
+		Value int
+		Ref   *int
+	}
+	v := 1
+	byValue := holder{Ref: &v}
+	byValue.Ref = &byValue.Value
+
+	finder := NewDuplicateFinder()
+	for _, root := range []interface{}{nil, 5, "a", []int{1}, &byValue, [1]*int{&v}, byValue} {
+		finder.ScanForPointers(root)
+	}
+	if copied := finder.CopiedRoots(); copied != 2 {
+		t.Errorf("Expected 2 copied roots but got %v", copied)
+	}
+	if !finder.IsDuplicatePointer(&byValue.Value) {
+		t.Errorf("Expected &byValue.Value to be a duplicate")
+	}
+	if info := finder.Report().ScanInfo(); info.CopiedRoots != 2 {
+		t.Errorf("Expected 2 copied roots in the scan info but got %v", info.CopiedRoots)
+	}
+
+	// The copy's field can't be matched against the pointer to the original.
+	finder.Reset()
+	finder.ScanForPointers(byValue)
+	if finder.IsDuplicatePointer(&byValue.Value) {
+		t.Errorf("Expected no duplicates in a copied root")
+	}
+}
+
+func TestFindDuplicatePointersMatchesFinder(t *testing.T) {
+	type zeroSized struct{}
+	head, _ := newDeepGraph()
+	empty := &zeroSized{}
+	for _, root := range []interface{}{head, newScanTestTree(), []*zeroSized{empty, empty}} {
+		finder := NewDuplicateFinder()
+		finder.ScanForPointers(root)
+		expected := finder.DuplicatePointers
+		if actual := onlyDuplicates(FindDuplicatePointers(root)); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Expected %v but got %v", expected, actual)
+		}
+	}
+}
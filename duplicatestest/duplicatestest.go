@@ -0,0 +1,44 @@
+// Package duplicatestest provides test assertions about sharing.
+package duplicatestest
+
+import (
+	"reflect"
+
+	duplicates "github.com/kstenerud/go-duplicates"
+)
+
+// TestingT is the subset of *testing.T used by the assertions.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertSharedExactly fails the test if the number of duplicated objects of
+// each pointer type in value differs from expected. Types that aren't
+// listed in expected must have no duplicates at all, so that tests can lock in
+// intentional sharing while still catching accidental new sharing.
+func AssertSharedExactly(t TestingT, value interface{}, expected map[reflect.Type]int) bool {
+	t.Helper()
+
+	actual := make(map[reflect.Type]int)
+	for ptr, isDuplicate := range duplicates.FindDuplicatePointers(value) {
+		if isDuplicate {
+			actual[ptr.Type]++
+		}
+	}
+
+	ok := true
+	for sharedType, expectedCount := range expected {
+		if actual[sharedType] != expectedCount {
+			t.Errorf("Expected %v shared object(s) of type %v but found %v", expectedCount, sharedType, actual[sharedType])
+			ok = false
+		}
+	}
+	for sharedType, actualCount := range actual {
+		if _, isExpected := expected[sharedType]; !isExpected {
+			t.Errorf("Found %v unexpected shared object(s) of type %v", actualCount, sharedType)
+			ok = false
+		}
+	}
+	return ok
+}
@@ -0,0 +1,36 @@
+package duplicatestest
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type recordingT struct {
+	errors []string
+}
+
+func (_this *recordingT) Helper() {}
+
+func (_this *recordingT) Errorf(format string, args ...interface{}) {
+	_this.errors = append(_this.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertSharedExactly(t *testing.T) {
+	v1 := 1
+	v2 := 2
+	s := "s"
+	value := []interface{}{&v1, &v1, &v2, &v2, &s, &s}
+	intPtr := reflect.TypeOf(&v1)
+	stringPtr := reflect.TypeOf(&s)
+
+	AssertSharedExactly(t, value, map[reflect.Type]int{intPtr: 2, stringPtr: 1})
+
+	recorder := &recordingT{}
+	if AssertSharedExactly(recorder, value, map[reflect.Type]int{intPtr: 1}) {
+		t.Errorf("Expected the assertion to fail")
+	}
+	if len(recorder.errors) != 2 {
+		t.Errorf("Expected 2 errors but got %v", recorder.errors)
+	}
+}
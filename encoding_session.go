@@ -0,0 +1,129 @@
+package duplicates
+
+import (
+	"reflect"
+)
+
+// EncodeAction tells a single-pass encoder what to do with a pointer value.
+type EncodeAction int
+
+const (
+	// Emit the value in full. The session now remembers it, so any later
+	// occurrence will result in EmitBackReference.
+	EmitValue EncodeAction = iota
+	// Emit a back reference to a value that has already been emitted.
+	EmitBackReference
+)
+
+func (_this EncodeAction) String() string {
+	switch _this {
+	case EmitValue:
+		return "EmitValue"
+	case EmitBackReference:
+		return "EmitBackReference"
+	default:
+		return "EncodeAction(?)"
+	}
+}
+
+// EncodingSession supports streaming encoders that cannot pre-scan their
+// data. Pointers are registered as encoding proceeds, along with the position
+// (as defined by the encoder: byte offset, object index, etc) at which they
+// were emitted. When the same pointer is encountered again, the session
+// reports the position to refer back to.
+//
+// Unlike the two-pass approach (see DuplicateTable), the encoder can't know
+// ahead of time which values will be referenced again, so every referenceable
+// value gets remembered.
+type EncodingSession struct {
+	positions map[TypedPointer]int
+}
+
+func NewEncodingSession() *EncodingSession {
+	_this := &EncodingSession{}
+	_this.Init()
+	return _this
+}
+
+func (_this *EncodingSession) Init() {
+	_this.positions = make(map[TypedPointer]int)
+}
+
+// Encounter informs the session that the encoder has reached pointer and
+// would emit it at position. If pointer has already been emitted, the action
+// is EmitBackReference and backReference holds the position it was emitted at.
+// Otherwise the action is EmitValue and position is remembered for pointer.
+//
+// Nil pointers, empty slices and maps, and values that aren't references at
+// all (anything other than a Chan, Func, Map, Ptr, Slice, or UnsafePointer,
+// including the invalid Value) are never remembered, and always result in
+// EmitValue.
+func (_this *EncodingSession) Encounter(pointer reflect.Value, position int) (action EncodeAction, backReference int) {
+	if !isReferenceable(pointer) {
+		return EmitValue, 0
+	}
+	typedPtr := TypedPointerOfRV(pointer)
+	if backReference, ok := _this.positions[typedPtr]; ok {
+		return EmitBackReference, backReference
+	}
+	_this.positions[typedPtr] = position
+	return EmitValue, 0
+}
+
+// EncounterObject is a convenience wrapper around Encounter for arbitrary
+// pointer-like objects.
+func (_this *EncodingSession) EncounterObject(pointer interface{}, position int) (action EncodeAction, backReference int) {
+	return _this.Encounter(reflect.ValueOf(pointer), position)
+}
+
+// MarkEmitted records that pointer has already been emitted with markerID
+// (which may be a position or any other encoder-defined ID). Encoders that
+// pause and resume (chunked responses, incremental snapshots) can use this to
+// restore which shared objects have already been written.
+//
+// Pointers are identified by type and address, which only mean something
+// within the process that holds the objects, and only for as long as the
+// objects are alive. Emitted state can therefore be handed between sessions
+// in the same process, but not persisted across a restart: an encoder that
+// resumes in a new process must rebuild it from its own stable identifiers
+// (such as the positions it wrote the objects at) as it reloads the objects.
+func (_this *EncodingSession) MarkEmitted(pointer TypedPointer, markerID int) {
+	_this.positions[pointer] = markerID
+}
+
+// EmittedID returns the marker ID (or position) that pointer was emitted
+// with, if it has been emitted.
+func (_this *EncodingSession) EmittedID(pointer TypedPointer) (markerID int, wasEmitted bool) {
+	markerID, wasEmitted = _this.positions[pointer]
+	return
+}
+
+// Emitted returns a copy of every pointer emitted so far and its marker ID,
+// suitable for restoring later via MarkEmitted or RestoreEmitted, within the
+// same process (see MarkEmitted).
+func (_this *EncodingSession) Emitted() map[TypedPointer]int {
+	emitted := make(map[TypedPointer]int, len(_this.positions))
+	for pointer, markerID := range _this.positions {
+		emitted[pointer] = markerID
+	}
+	return emitted
+}
+
+// RestoreEmitted marks every pointer in emitted as having been emitted with
+// its associated marker ID.
+func (_this *EncodingSession) RestoreEmitted(emitted map[TypedPointer]int) {
+	for pointer, markerID := range emitted {
+		_this.MarkEmitted(pointer, markerID)
+	}
+}
+
+func isReferenceable(pointer reflect.Value) bool {
+	switch pointer.Kind() {
+	case reflect.Map, reflect.Slice:
+		return !pointer.IsNil() && pointer.Len() > 0
+	case reflect.Chan, reflect.Func, reflect.Ptr, reflect.UnsafePointer:
+		return !pointer.IsNil()
+	default:
+		return false
+	}
+}
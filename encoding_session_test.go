@@ -0,0 +1,70 @@
+package duplicates
+
+import (
+	"testing"
+)
+
+func TestEncodingSession(t *testing.T) {
+	v1 := 1
+	v2 := 2
+	var nilPtr *int
+	values := []interface{}{&v1, &v2, nilPtr, &v1, nilPtr, []int{}, []int{}, &v2}
+	expected := []struct {
+		action        EncodeAction
+		backReference int
+	}{
+		{EmitValue, 0},
+		{EmitValue, 0},
+		{EmitValue, 0},
+		{EmitBackReference, 0},
+		{EmitValue, 0},
+		{EmitValue, 0},
+		{EmitValue, 0},
+		{EmitBackReference, 1},
+	}
+
+	session := NewEncodingSession()
+	for i, value := range values {
+		action, backReference := session.EncounterObject(value, i)
+		if action != expected[i].action || backReference != expected[i].backReference {
+			t.Errorf("Value %v: Expected %v %v but got %v %v",
+				i, expected[i].action, expected[i].backReference, action, backReference)
+		}
+	}
+
+	if position, wasEmitted := session.EmittedID(TypedPointerOf(&v2)); !wasEmitted || position != 1 {
+		t.Errorf("Expected &v2 to have been emitted at 1 but got %v (%v)", position, wasEmitted)
+	}
+}
+
+func TestEncodingSessionNonPointers(t *testing.T) {
+	session := NewEncodingSession()
+	for i, value := range []interface{}{5, "a", struct{ A *int }{}, nil, 5} {
+		if action, _ := session.EncounterObject(value, i); action != EmitValue {
+			t.Errorf("Value %v: Expected EmitValue but got %v", i, action)
+		}
+	}
+}
+
+func TestEncodingSessionResume(t *testing.T) {
+	v1 := 1
+	v2 := 2
+
+	session := NewEncodingSession()
+	session.EncounterObject(&v1, 10)
+	session.MarkEmitted(TypedPointerOf(&v2), 20)
+	saved := session.Emitted()
+
+	resumed := NewEncodingSession()
+	resumed.RestoreEmitted(saved)
+	if markerID, wasEmitted := resumed.EmittedID(TypedPointerOf(&v1)); !wasEmitted || markerID != 10 {
+		t.Errorf("Expected &v1 to have marker ID 10 but got %v (%v)", markerID, wasEmitted)
+	}
+	if action, backReference := resumed.EncounterObject(&v2, 30); action != EmitBackReference || backReference != 20 {
+		t.Errorf("Expected back reference to 20 but got %v %v", action, backReference)
+	}
+	v3 := 3
+	if _, wasEmitted := resumed.EmittedID(TypedPointerOf(&v3)); wasEmitted {
+		t.Errorf("Expected &v3 to not have been emitted")
+	}
+}
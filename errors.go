@@ -0,0 +1,68 @@
+package duplicates
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// PointerKindError is returned when a value that isn't a pointer, map, slice,
+// chan, func, or unsafe pointer is used where one is required.
+type PointerKindError struct {
+	// The type of the value, or nil if the value was nil.
+	Type reflect.Type
+}
+
+func (_this *PointerKindError) Error() string {
+	if _this.Type == nil {
+		return "duplicates: cannot take the pointer of a nil interface"
+	}
+	return fmt.Sprintf("duplicates: cannot take the pointer of %v (kind %v)", _this.Type, _this.Type.Kind())
+}
+
+const pointerKinds uint = (uint(1) << reflect.Chan) |
+	(uint(1) << reflect.Func) |
+	(uint(1) << reflect.Map) |
+	(uint(1) << reflect.Ptr) |
+	(uint(1) << reflect.Slice) |
+	(uint(1) << reflect.UnsafePointer)
+
+func checkPointerKind(rv reflect.Value) error {
+	if !rv.IsValid() {
+		return &PointerKindError{}
+	}
+	if pointerKinds&(uint(1)<<rv.Kind()) == 0 {
+		return &PointerKindError{Type: rv.Type()}
+	}
+	return nil
+}
+
+// TypedPointerOfE works like TypedPointerOf, except that it returns a
+// *PointerKindError rather than panicking if value isn't a pointer, map,
+// slice, chan, func, or unsafe pointer.
+func TypedPointerOfE(value interface{}) (TypedPointer, error) {
+	return TypedPointerOfRVE(reflect.ValueOf(value))
+}
+
+// TypedPointerOfRVE works like TypedPointerOfRV, except that it returns a
+// *PointerKindError rather than panicking. See TypedPointerOfE.
+func TypedPointerOfRVE(rv reflect.Value) (TypedPointer, error) {
+	if err := checkPointerKind(rv); err != nil {
+		return TypedPointer{}, err
+	}
+	return TypedPointerOfRV(rv), nil
+}
+
+// RegisterPointerE works like RegisterPointer, except that it returns a
+// *PointerKindError rather than panicking if pointer is of the wrong kind.
+func (_this *DuplicateFinder) RegisterPointerE(pointer reflect.Value) (alreadyExists bool, err error) {
+	if err = checkPointerKind(pointer); err != nil {
+		return false, err
+	}
+	return _this.RegisterPointer(pointer), nil
+}
+
+// ScanForPointersE works like ScanForPointers, except that it returns an
+// error rather than panicking. It is the same as TryScanForPointers.
+func (_this *DuplicateFinder) ScanForPointersE(object interface{}) error {
+	return _this.TryScanForPointers(object)
+}
@@ -0,0 +1,52 @@
+package duplicates
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTypedPointerOfE(t *testing.T) {
+	v := 1
+	ptr, err := TypedPointerOfE(&v)
+	if err != nil || ptr != TypedPointerOf(&v) {
+		t.Errorf("Expected %v but got %v (%v)", TypedPointerOf(&v), ptr, err)
+	}
+
+	for _, value := range []interface{}{v, nil, struct{}{}} {
+		_, err := TypedPointerOfE(value)
+		if _, ok := err.(*PointerKindError); !ok {
+			t.Errorf("%v: Expected a *PointerKindError but got %v", value, err)
+		}
+	}
+	if _, err := TypedPointerOfE(v); !strings.Contains(err.Error(), "int") {
+		t.Errorf("Expected the error to name the type but got %v", err)
+	}
+}
+
+func TestRegisterPointerE(t *testing.T) {
+	v := 1
+	finder := NewDuplicateFinder()
+	if _, err := finder.RegisterPointerE(reflect.ValueOf(v)); err == nil {
+		t.Errorf("Expected an error for a non-pointer")
+	}
+	if exists, err := finder.RegisterPointerE(reflect.ValueOf(&v)); exists || err != nil {
+		t.Errorf("Expected a new pointer but got %v (%v)", exists, err)
+	}
+	if exists, err := finder.RegisterPointerE(reflect.ValueOf(&v)); !exists || err != nil {
+		t.Errorf("Expected an existing pointer but got %v (%v)", exists, err)
+	}
+}
+
+func TestScanForPointersE(t *testing.T) {
+	v := 1
+	finder := NewDuplicateFinder()
+	if err := finder.ScanForPointersE([]*int{&v, &v}); err != nil || !finder.IsDuplicatePointer(&v) {
+		t.Errorf("Expected a successful scan but got %v", err)
+	}
+
+	finder, root := newPanicTestFinder(3)
+	if _, ok := finder.ScanForPointersE(root).(*ScanError); !ok {
+		t.Errorf("Expected a panic during the scan to be returned as a *ScanError")
+	}
+}
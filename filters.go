@@ -0,0 +1,107 @@
+package duplicates
+
+import (
+	"reflect"
+)
+
+// SkipTypes makes the finder skip every value of the given types, as well as
+// every pointer to one: they are neither registered nor scanned.
+func (_this *DuplicateFinder) SkipTypes(types ...reflect.Type) {
+	if _this.skippedTypes == nil {
+		_this.skippedTypes = make(map[reflect.Type]bool)
+	}
+	for _, t := range types {
+		_this.skippedTypes[t] = true
+	}
+	_this.typeSkips = nil
+}
+
+// RestrictToTypes makes the finder only register pointers to values of the
+// given types (or of types passed to an earlier call), and slices, maps,
+// channels, and so on whose own type is given, so that only those can be
+// reported as duplicates or counted. Everything else is still followed (so
+// that the allowed values can be reached through it, and so that cycles
+// through it end), but never appears in the results.
+func (_this *DuplicateFinder) RestrictToTypes(types ...reflect.Type) {
+	if _this.allowedTypes == nil {
+		_this.allowedTypes = make(map[reflect.Type]bool)
+	}
+	for _, t := range types {
+		_this.allowedTypes[t] = true
+	}
+}
+
+// Returns true if values of type t must be skipped entirely.
+func (_this *DuplicateFinder) isSkippedType(t reflect.Type) bool {
+	if _this.skippedTypes == nil {
+		return false
+	}
+	if isSkipped, ok := _this.typeSkips[t]; ok {
+		return isSkipped
+	}
+	if _this.typeSkips == nil {
+		_this.typeSkips = make(map[reflect.Type]bool)
+	}
+	isSkipped := _this.skippedTypes[t]
+	if t.Kind() == reflect.Ptr {
+		isSkipped = isSkipped || _this.skippedTypes[t.Elem()]
+	}
+	_this.typeSkips[t] = isSkipped
+	return isSkipped
+}
+
+// Returns true if pointers of type t must not be reported because of
+// RestrictToTypes.
+func (_this *DuplicateFinder) isRestrictedType(t reflect.Type) bool {
+	if _this.allowedTypes == nil || _this.allowedTypes[t] {
+		return false
+	}
+	return t.Kind() != reflect.Ptr || !_this.allowedTypes[t.Elem()]
+}
+
+// Returns true if field i of struct type t must not be scanned, given the
+// fields excluded by tag.
+func (_this *DuplicateFinder) isFieldExcluded(t reflect.Type, plan *typePlan, i int, excluded []bool) bool {
+	if excluded != nil && excluded[i] {
+		return true
+	}
+	if _this.SkipUnexportedFields && !plan.fieldExported[i] {
+		return true
+	}
+	return _this.FieldFilter != nil && !_this.FieldFilter(t.Field(i), _this.pathAt(_this.pathNode))
+}
+
+// Returns which fields of struct type t are excluded by tag, or nil if all of
+// them are scanned.
+func (_this *DuplicateFinder) excludedFieldsOf(t reflect.Type, plan *typePlan) []bool {
+	if _this.TagName == "" || _this.TagName == tagName {
+		return plan.fieldExcluded
+	}
+	if _this.excludedFieldsTag != _this.TagName {
+		_this.excludedFields = nil
+		_this.excludedFieldsTag = _this.TagName
+	}
+	if excluded, ok := _this.excludedFields[t]; ok {
+		return excluded
+	}
+	if _this.excludedFields == nil {
+		_this.excludedFields = make(map[reflect.Type][]bool)
+	}
+	excluded := excludedFieldsByTag(t, _this.TagName)
+	_this.excludedFields[t] = excluded
+	return excluded
+}
+
+// Returns which fields of struct type t are tagged with "-" under the tag
+// name, or nil if none of them are.
+func excludedFieldsByTag(t reflect.Type, name string) (excluded []bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if parseTagOptions(t.Field(i).Tag.Get(name)).excluded {
+			if excluded == nil {
+				excluded = make([]bool, t.NumField())
+			}
+			excluded[i] = true
+		}
+	}
+	return
+}
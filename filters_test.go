@@ -0,0 +1,173 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+)
+
+type filtersNode struct {
+	Name     string
+	Parent   *filtersNode `duplicates:"-"`
+	Children []*filtersNode
+	Cache    *filtersNode `scan:"-"`
+}
+
+func TestExcludedFieldTag(t *testing.T) {
+	root := &filtersNode{Name: "root"}
+	child := &filtersNode{Name: "child", Parent: root, Cache: &filtersNode{Name: "cached"}}
+	root.Children = []*filtersNode{child}
+
+	finder := NewDuplicateFinder()
+	finder.ScanForPointers(root)
+	if finder.DuplicatePointers[TypedPointerOf(root)] {
+		t.Errorf("Expected the excluded Parent field not to be followed")
+	}
+	if isRegistered(finder, TypedPointerOf(&child.Parent)) {
+		t.Errorf("Expected the excluded Parent field not to be registered")
+	}
+	if !isRegistered(finder, TypedPointerOf(child.Cache)) {
+		t.Errorf("Expected Cache to be scanned under the default tag name")
+	}
+}
+
+func TestExcludedFieldCustomTagName(t *testing.T) {
+	root := &filtersNode{Name: "root"}
+	child := &filtersNode{Name: "child", Parent: root, Cache: root}
+	root.Children = []*filtersNode{child}
+
+	finder := NewDuplicateFinder(WithTagName("scan"))
+	finder.ScanForPointers(root)
+	if !finder.DuplicatePointers[TypedPointerOf(root)] {
+		t.Errorf("Expected Parent to be followed under a custom tag name")
+	}
+	if isRegistered(finder, TypedPointerOf(&child.Cache)) {
+		t.Errorf("Expected the excluded Cache field not to be registered")
+	}
+}
+
+func TestFieldFilter(t *testing.T) {
+	shared := &filtersNode{Name: "shared"}
+	root := &filtersNode{Name: "root", Children: []*filtersNode{shared}, Cache: shared}
+
+	var paths []string
+	finder := NewDuplicateFinder(WithPaths(), WithFieldFilter(func(field reflect.StructField, path Path) bool {
+		if field.Name == "Cache" {
+			paths = append(paths, path.String())
+			return false
+		}
+		return true
+	}))
+	finder.ScanForPointers(root)
+	if finder.DuplicatePointers[TypedPointerOf(shared)] {
+		t.Errorf("Expected the filtered Cache field not to be followed")
+	}
+	if len(paths) != 2 || paths[0] != ".Children[0]" || paths[1] != "" {
+		t.Errorf("Expected the filter to see the paths of both structs but got %v", paths)
+	}
+}
+
+type filtersVendor struct {
+	Data *int
+}
+
+type filtersHolder struct {
+	Node   *filtersNode
+	Vendor *filtersVendor
+	Inline filtersVendor
+	Refs   []*int
+}
+
+func TestSkipTypes(t *testing.T) {
+	v := 1
+	vendor := &filtersVendor{Data: &v}
+	holder := &filtersHolder{Vendor: vendor, Inline: filtersVendor{Data: &v}, Refs: []*int{&v}}
+
+	finder := NewDuplicateFinder(WithSkipTypes(reflect.TypeOf(filtersVendor{})))
+	finder.ScanForPointers(holder)
+	for _, ptr := range []TypedPointer{TypedPointerOf(vendor), TypedPointerOf(&holder.Inline)} {
+		if isRegistered(finder, ptr) {
+			t.Errorf("Expected %v to be skipped", ptr)
+		}
+	}
+	if finder.DuplicatePointers[TypedPointerOf(&v)] {
+		t.Errorf("Expected the contents of skipped values not to be scanned")
+	}
+}
+
+func TestRestrictToTypes(t *testing.T) {
+	v := 1
+	node := &filtersNode{Name: "node"}
+	holder := &filtersHolder{Node: node, Vendor: &filtersVendor{Data: &v}, Refs: []*int{&v}}
+
+	finder := NewDuplicateFinder(WithRestrictToTypes(reflect.TypeOf(filtersHolder{}), reflect.TypeOf(filtersNode{})))
+	finder.ScanForPointers([]*filtersHolder{holder, holder})
+	if !finder.DuplicatePointers[TypedPointerOf(holder)] {
+		t.Errorf("Expected allowed types to be scanned")
+	}
+	if !isRegistered(finder, TypedPointerOf(node)) {
+		t.Errorf("Expected allowed types to be registered")
+	}
+	if finder.IsDuplicatePointer(holder.Vendor) {
+		t.Errorf("Expected other struct types not to be reported")
+	}
+	if finder.IsDuplicatePointer(&v) || finder.IsDuplicatePointer(holder.Refs) {
+		t.Errorf("Expected other types not to be reported")
+	}
+	if _, ok := finder.ReferenceCounts()[TypedPointerOf(&v)]; ok {
+		t.Errorf("Expected other types not to be counted")
+	}
+
+	shared := &filtersNode{Name: "shared"}
+	holder = &filtersHolder{Vendor: &filtersVendor{Data: &v}, Refs: []*int{&v}}
+	finder = NewDuplicateFinder(WithRestrictToTypes(reflect.TypeOf(0), reflect.TypeOf([]*int{})))
+	finder.ScanForPointers([]interface{}{holder, holder.Refs, shared, shared})
+	if !finder.IsDuplicatePointer(&v) || !finder.IsDuplicatePointer(holder.Refs) {
+		t.Errorf("Expected the contents of other types to be scanned for allowed types")
+	}
+	if finder.IsDuplicatePointer(shared) {
+		t.Errorf("Expected other struct types not to be reported")
+	}
+}
+
+type filtersOuter struct {
+	Items []*filtersNode
+}
+
+func TestRestrictToTypesNested(t *testing.T) {
+	item := &filtersNode{Name: "item"}
+	outer := &filtersOuter{Items: []*filtersNode{item, item}}
+
+	finder := NewDuplicateFinder(WithRestrictToTypes(reflect.TypeOf(filtersNode{})))
+	finder.ScanForPointers([]*filtersOuter{outer, outer})
+	if !finder.IsDuplicatePointer(item) {
+		t.Errorf("Expected allowed types below other struct types to be found")
+	}
+	if finder.IsDuplicatePointer(outer) {
+		t.Errorf("Expected other struct types not to be reported")
+	}
+}
+
+type filtersEmbedded struct {
+	Shared *int
+}
+
+type filtersExported struct {
+	filtersEmbedded
+	Visible *int
+	hidden  *int
+}
+
+func TestSkipUnexportedFields(t *testing.T) {
+	a, b := 1, 2
+	value := &filtersExported{filtersEmbedded: filtersEmbedded{Shared: &a}, Visible: &a, hidden: &b}
+	root := []*filtersExported{value, {hidden: &b}}
+
+	finder := NewDuplicateFinder(WithSkipUnexportedFields())
+	finder.ScanForPointers(root)
+	if !finder.IsDuplicatePointer(&a) {
+		t.Errorf("Expected sharing through exported and embedded fields to be found")
+	}
+	if isRegistered(finder, TypedPointerOf(&b)) {
+		t.Errorf("Expected unexported fields to be skipped")
+	}
+}
@@ -0,0 +1,46 @@
+package duplicates
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// Fingerprint returns a 64-bit summary of the shape of the sharing in the
+// report. See ShapeDigest.
+func (_this *DuplicateReport) Fingerprint() uint64 {
+	return binary.BigEndian.Uint64(_this.shape[:8])
+}
+
+// ShapeDigest returns a digest of the shape of the sharing in the report: the
+// type of each duplicate, how many times it was seen, whether it was in a
+// cycle, whether it was reachable through exported fields, and whether it was
+// shared across roots. Addresses and discovery order play no part, so the
+// digest is stable across runs, and only changes when the aliasing structure
+// of the scanned objects changes. This makes it suitable for regression tests
+// and monitoring.
+func (_this *DuplicateReport) ShapeDigest() ContentDigest {
+	return _this.shape
+}
+
+func sharingShape(duplicates []*pointerRecord) (digest ContentDigest) {
+	entries := make([]string, 0, len(duplicates))
+	for _, record := range duplicates {
+		entries = append(entries, fmt.Sprintf("%v|%v|%v|%v|%v",
+			record.pointer.Type,
+			record.sightings,
+			record.inCycle,
+			record.exportedPath,
+			record.crossRoot))
+	}
+	sort.Strings(entries)
+
+	h := sha256.New()
+	for _, entry := range entries {
+		h.Write([]byte(entry))
+		h.Write([]byte{0})
+	}
+	h.Sum(digest[:0])
+	return
+}
@@ -0,0 +1,37 @@
+package duplicates
+
+import (
+	"testing"
+)
+
+type fingerprintNode struct {
+	Name  string
+	Peers map[string]*fingerprintNode
+}
+
+func newFingerprintGraph(shareB bool) *fingerprintNode {
+	a := &fingerprintNode{Name: "a"}
+	b := &fingerprintNode{Name: "b"}
+	c := &fingerprintNode{Name: "c"}
+	root := &fingerprintNode{Peers: map[string]*fingerprintNode{"a": a, "b": b, "c": c}}
+	a.Peers = map[string]*fingerprintNode{"a": a}
+	if shareB {
+		c.Peers = map[string]*fingerprintNode{"b": b}
+	}
+	return root
+}
+
+func TestFingerprint(t *testing.T) {
+	expected := FindDuplicates(newFingerprintGraph(true))
+	for i := 0; i < 10; i++ {
+		report := FindDuplicates(newFingerprintGraph(true))
+		if report.Fingerprint() != expected.Fingerprint() || report.ShapeDigest() != expected.ShapeDigest() {
+			t.Fatalf("Expected identically shaped graphs to have identical fingerprints")
+		}
+	}
+
+	changed := FindDuplicates(newFingerprintGraph(false))
+	if changed.Fingerprint() == expected.Fingerprint() {
+		t.Errorf("Expected a change in sharing to change the fingerprint")
+	}
+}
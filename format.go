@@ -0,0 +1,64 @@
+package duplicates
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+var addressRedaction int32
+
+// SetAddressRedaction controls whether raw addresses appear in output. When
+// enabled, reports replace addresses with deterministic pseudo-IDs assigned
+// in scan order, so that logs are reproducible across runs and don't leak
+// ASLR-relevant addresses. TypedPointer.String, which has no scan to draw IDs
+// from, omits the address entirely.
+//
+// This is a global setting, and is safe to change concurrently.
+func SetAddressRedaction(enabled bool) {
+	var value int32
+	if enabled {
+		value = 1
+	}
+	atomic.StoreInt32(&addressRedaction, value)
+}
+
+// IsAddressRedactionEnabled returns true if addresses are being redacted from
+// output (see SetAddressRedaction).
+func IsAddressRedactionEnabled() bool {
+	return atomic.LoadInt32(&addressRedaction) != 0
+}
+
+func (_this TypedPointer) String() string {
+	if IsAddressRedactionEnabled() {
+		return fmt.Sprintf("%v@(redacted)", _this.Type)
+	}
+	return fmt.Sprintf("%v@0x%x", _this.Type, _this.Address())
+}
+
+// FormatPointer renders ptr for output. If address redaction is enabled, the
+// address is replaced by a pseudo-ID that is stable for the same scan input.
+// Pointers of different types at the same address share a pseudo-ID.
+func (_this *DuplicateReport) FormatPointer(ptr TypedPointer) string {
+	if !IsAddressRedactionEnabled() {
+		return ptr.String()
+	}
+	if id, ok := _this.pseudoIDs[ptr.Address()]; ok {
+		return fmt.Sprintf("%v#%d", ptr.Type, id)
+	}
+	return ptr.String()
+}
+
+// String lists the report's duplicates in discovery order.
+func (_this *DuplicateReport) String() string {
+	builder := strings.Builder{}
+	builder.WriteString("[")
+	for i, ptr := range _this.pointers {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(_this.FormatPointer(ptr))
+	}
+	builder.WriteString("]")
+	return builder.String()
+}
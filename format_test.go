@@ -0,0 +1,36 @@
+package duplicates
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAddressRedaction(t *testing.T) {
+	v1 := 1
+	v2 := 2
+	report := FindDuplicates([]*int{&v2, &v1, &v1, &v2})
+
+	SetAddressRedaction(true)
+	defer SetAddressRedaction(false)
+
+	expected := "[*int#1, *int#2]"
+	if report.String() != expected {
+		t.Errorf("Expected %v but got %v", expected, report.String())
+	}
+	expected = "*int@(redacted)"
+	if actual := TypedPointerOf(&v1).String(); actual != expected {
+		t.Errorf("Expected %v but got %v", expected, actual)
+	}
+}
+
+func TestAddressNoRedaction(t *testing.T) {
+	v := 1
+	ptr := TypedPointerOf(&v)
+	expected := fmt.Sprintf("*int@0x%x", ptr.Address())
+	if ptr.String() != expected {
+		t.Errorf("Expected %v but got %v", expected, ptr.String())
+	}
+	if report := FindDuplicates([]*int{&v, &v}); report.String() != "["+expected+"]" {
+		t.Errorf("Expected [%v] but got %v", expected, report.String())
+	}
+}
@@ -0,0 +1,21 @@
+// +build !go1.18
+
+package duplicates
+
+import (
+	"reflect"
+)
+
+type mapIterator struct {
+	mapRangeIterator
+}
+
+type mapRangeIterator interface {
+	Key() reflect.Value
+	Value() reflect.Value
+	Next() bool
+}
+
+func resetMapIterator(iter *mapIterator, v reflect.Value) {
+	iter.mapRangeIterator = mapRange(v)
+}
@@ -0,0 +1,13 @@
+// +build go1.18
+
+package duplicates
+
+import (
+	"reflect"
+)
+
+type mapIterator = reflect.MapIter
+
+func resetMapIterator(iter *mapIterator, v reflect.Value) {
+	iter.Reset(v)
+}
@@ -0,0 +1,96 @@
+package duplicates
+
+import (
+	"strings"
+)
+
+// ReferenceGraph is the graph of references between the pointers found in a
+// scanned value. Its nodes are the pointers (including the addresses of
+// struct fields), and its edges lead from each node to the pointers found in
+// what it refers to.
+type ReferenceGraph struct {
+	// The nodes, in discovery order.
+	Nodes []TypedPointer
+	// The edges, in discovery order. Edges from the scanned value itself
+	// (rather than from a node) have a From of -1.
+	Edges []ReferenceEdge
+
+	nodeIndex map[TypedPointer]int
+	outgoing  map[int][]int
+	incoming  [][]int
+}
+
+// ReferenceEdge is a reference from what one node refers to (or from the
+// scanned value itself) to another node.
+type ReferenceEdge struct {
+	// Indices into the graph's Nodes. From is -1 for a reference from the
+	// scanned value itself.
+	From int
+	To   int
+	// The steps leading from what From refers to (or the scanned value) to
+	// the reference. An edge from a struct field's address to the field's
+	// own value has no steps.
+	Steps []PathStep
+}
+
+// Label renders the edge's steps like a Path, for example ".Children[2]".
+func (_this ReferenceEdge) Label() string {
+	builder := strings.Builder{}
+	for _, step := range _this.Steps {
+		builder.WriteString(step.String())
+	}
+	return builder.String()
+}
+
+// BuildReferenceGraph scans value and builds its reference graph.
+func BuildReferenceGraph(value interface{}) *ReferenceGraph {
+	finder, edges := scanReferenceEdges(value, true, true)
+	graph := &ReferenceGraph{
+		nodeIndex: make(map[TypedPointer]int, len(finder.records)),
+		outgoing:  make(map[int][]int),
+		incoming:  make([][]int, len(finder.records)),
+	}
+	for i, record := range finder.records {
+		graph.Nodes = append(graph.Nodes, record.pointer)
+		graph.nodeIndex[record.pointer] = i
+	}
+	for i, edge := range edges {
+		start := noPathNode
+		if edge.from >= 0 {
+			start = finder.pathsOfRecord(edge.from).first
+		}
+		graph.Edges = append(graph.Edges, ReferenceEdge{
+			From:  edge.from,
+			To:    edge.to,
+			Steps: finder.stepsBetween(start, edge.pathNode),
+		})
+		graph.outgoing[edge.from] = append(graph.outgoing[edge.from], i)
+		graph.incoming[edge.to] = append(graph.incoming[edge.to], i)
+	}
+	return graph
+}
+
+// NodeOf returns the index of ptr's node, if ptr is in the graph.
+func (_this *ReferenceGraph) NodeOf(ptr TypedPointer) (node int, ok bool) {
+	node, ok = _this.nodeIndex[ptr]
+	return
+}
+
+// EdgesFrom returns the edges leading from node (or from the scanned value
+// itself if node is -1), in discovery order.
+func (_this *ReferenceGraph) EdgesFrom(node int) []ReferenceEdge {
+	return _this.edgesAt(_this.outgoing[node])
+}
+
+// EdgesTo returns the edges leading to node, in discovery order.
+func (_this *ReferenceGraph) EdgesTo(node int) []ReferenceEdge {
+	return _this.edgesAt(_this.incoming[node])
+}
+
+func (_this *ReferenceGraph) edgesAt(indices []int) []ReferenceEdge {
+	edges := make([]ReferenceEdge, len(indices))
+	for i, index := range indices {
+		edges[i] = _this.Edges[index]
+	}
+	return edges
+}
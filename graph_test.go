@@ -0,0 +1,51 @@
+package duplicates
+
+import (
+	"testing"
+)
+
+type graphNode struct {
+	Name     string
+	Children []*graphNode
+}
+
+func TestBuildReferenceGraph(t *testing.T) {
+	leaf := &graphNode{Name: "leaf"}
+	root := &graphNode{Name: "root", Children: []*graphNode{leaf, leaf}}
+
+	graph := BuildReferenceGraph(root)
+	if len(graph.Nodes) != 7 {
+		t.Errorf("Expected 7 nodes (2 structs, 2 fields each, and a slice) but got %v", graph.Nodes)
+	}
+
+	rootEdges := graph.EdgesFrom(-1)
+	if len(rootEdges) != 1 || graph.Nodes[rootEdges[0].To] != TypedPointerOf(root) || rootEdges[0].Label() != "" {
+		t.Fatalf("Expected a single edge to root but got %v", rootEdges)
+	}
+
+	leafNode, ok := graph.NodeOf(TypedPointerOf(leaf))
+	if !ok {
+		t.Fatalf("Expected leaf to be in the graph")
+	}
+	sliceNode, _ := graph.NodeOf(TypedPointerOf(root.Children))
+	incoming := graph.EdgesTo(leafNode)
+	if len(incoming) != 2 {
+		t.Fatalf("Expected 2 edges to leaf but got %v", incoming)
+	}
+	for i, edge := range incoming {
+		expected := []string{"[0]", "[1]"}[i]
+		if edge.From != sliceNode || edge.Label() != expected {
+			t.Errorf("Expected edge %v from the slice labeled %v but got %v", i, expected, edge)
+		}
+	}
+
+	childrenNode, _ := graph.NodeOf(TypedPointerOf(&root.Children))
+	outgoing := graph.EdgesFrom(childrenNode)
+	if len(outgoing) != 1 || outgoing[0].To != sliceNode || outgoing[0].Label() != "" {
+		t.Errorf("Expected the Children field to lead to the slice but got %v", outgoing)
+	}
+	rootNode, _ := graph.NodeOf(TypedPointerOf(root))
+	if edges := graph.EdgesTo(childrenNode); len(edges) != 1 || edges[0].From != rootNode || edges[0].Label() != ".Children" {
+		t.Errorf("Expected root to lead to its Children field but got %v", edges)
+	}
+}
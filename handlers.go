@@ -0,0 +1,95 @@
+package duplicates
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// TypeHandler scans a value of the type it was registered for, in place of
+// the finder's own handling (see RegisterTypeHandler). It reports the
+// value's children by calling finder.ScanChild.
+type TypeHandler func(finder *DuplicateFinder, value reflect.Value)
+
+// RegisterTypeHandler makes the finder call handler for every value of type t
+// that it visits, instead of scanning the value itself. This allows
+// overriding how specific types are traversed, for example to walk an
+// intrusive linked list iteratively, or to skip a type's internal caches.
+// If t is a pointer, map, or slice type, the handler is also responsible for
+// registering the value if it should be (see RegisterPointer).
+//
+// A nil handler removes the handler for t.
+func (_this *DuplicateFinder) RegisterTypeHandler(t reflect.Type, handler TypeHandler) {
+	if handler == nil {
+		delete(_this.typeHandlers, t)
+		return
+	}
+	if _this.typeHandlers == nil {
+		_this.typeHandlers = make(map[reflect.Type]TypeHandler)
+	}
+	_this.typeHandlers[t] = handler
+}
+
+// ScanChild scans child as the index-th child of the value currently being
+// handled, exactly as the finder would scan any other value. It must only be
+// called from a TypeHandler.
+//
+// A pausable scan (see NewScan) never pauses while a handler (or
+// EnumerateChildPointers) is running.
+func (_this *DuplicateFinder) ScanChild(index int, child reflect.Value) {
+	_this.scanChild(child, PathStep{Kind: StepElem, Index: index})
+}
+
+// PointerEnumerable can be implemented by struct types that hold children
+// which reflection can't see (handles, pooled objects, storage with erased
+// types, etc). The finder calls EnumerateChildPointers instead of scanning
+// the struct's fields, and scans every value passed to report as a child of
+// the struct.
+type PointerEnumerable interface {
+	EnumerateChildPointers(report func(child reflect.Value))
+}
+
+var pointerEnumerableType = reflect.TypeOf((*PointerEnumerable)(nil)).Elem()
+
+// Scans the children that a struct implementing PointerEnumerable (directly
+// or through a pointer to it) reports, returning false if the value can't be
+// asked for them (because it isn't addressable and only its pointer
+// implements PointerEnumerable).
+func (_this *DuplicateFinder) scanEnumerated(value reflect.Value, plan *typePlan) bool {
+	var receiver reflect.Value
+	switch {
+	case plan.ptrEnumerable && value.CanAddr():
+		receiver = reflect.NewAt(value.Type(), unsafe.Pointer(value.UnsafeAddr()))
+	case plan.enumerable && value.CanInterface():
+		receiver = value
+	case plan.enumerable && value.CanAddr():
+		receiver = reflect.NewAt(value.Type(), unsafe.Pointer(value.UnsafeAddr())).Elem()
+	default:
+		return false
+	}
+
+	_this.handling++
+	defer func() {
+		_this.handling--
+	}()
+	index := 0
+	receiver.Interface().(PointerEnumerable).EnumerateChildPointers(func(child reflect.Value) {
+		_this.ScanChild(index, child)
+		index++
+	})
+	return true
+}
+
+// Calls the handler registered for value's type, if any, returning true if
+// there was one.
+func (_this *DuplicateFinder) handleType(value reflect.Value) bool {
+	handler, ok := _this.typeHandlers[value.Type()]
+	if !ok {
+		return false
+	}
+	_this.handling++
+	defer func() {
+		_this.handling--
+	}()
+	handler(_this, value)
+	return true
+}
@@ -0,0 +1,98 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+type handlerItem struct {
+	Value *int
+	next  *handlerItem
+}
+
+type handlerList struct {
+	head  *handlerItem
+	cache map[int]*int
+}
+
+func scanHandlerList(finder *DuplicateFinder, value reflect.Value) {
+	list := value.Interface().(handlerList)
+	index := 0
+	for item := list.head; item != nil; item = item.next {
+		finder.ScanChild(index, reflect.ValueOf(item.Value))
+		index++
+	}
+}
+
+func TestRegisterTypeHandler(t *testing.T) {
+	a, b, cached := 1, 2, 3
+	list := handlerList{
+		head:  &handlerItem{Value: &a, next: &handlerItem{Value: &b}},
+		cache: map[int]*int{0: &cached},
+	}
+	root := []interface{}{list, &a, &cached}
+
+	finder := NewDuplicateFinder(WithPaths(), WithTypeHandler(reflect.TypeOf(handlerList{}), scanHandlerList))
+	finder.ScanForPointers(root)
+	if !finder.IsDuplicatePointer(&a) {
+		t.Errorf("Expected the handler's children to be scanned")
+	}
+	if finder.IsDuplicatePointer(&cached) {
+		t.Errorf("Expected the handler to replace the scanning of the type")
+	}
+	if path, _ := finder.PathTo(TypedPointerOf(&b)); path.String() != "[0][1]" {
+		t.Errorf("Expected path [0][1] but got %v", path)
+	}
+
+	finder = NewDuplicateFinder(WithTypeHandler(reflect.TypeOf(handlerList{}), scanHandlerList))
+	scan := finder.NewScan(root)
+	for scan.RunNodes(2) != nil {
+	}
+	if !finder.IsDuplicatePointer(&a) || finder.IsDuplicatePointer(&cached) {
+		t.Errorf("Expected a paused scan to use the handler")
+	}
+
+	finder.RegisterTypeHandler(reflect.TypeOf(handlerList{}), nil)
+	finder.Reset()
+	finder.ScanForPointers(root)
+	if !finder.IsDuplicatePointer(&cached) {
+		t.Errorf("Expected removing the handler to restore normal scanning")
+	}
+}
+
+type enumerablePool struct {
+	slots []unsafe.Pointer
+	live  []int
+}
+
+func (_this *enumerablePool) EnumerateChildPointers(report func(child reflect.Value)) {
+	for _, slot := range _this.live {
+		report(reflect.ValueOf((*int)(_this.slots[slot])))
+	}
+}
+
+type enumerableHolder struct {
+	pool enumerablePool
+}
+
+func TestPointerEnumerable(t *testing.T) {
+	a, b, dead := 1, 2, 3
+	holder := &enumerableHolder{pool: enumerablePool{
+		slots: []unsafe.Pointer{unsafe.Pointer(&a), unsafe.Pointer(&dead), unsafe.Pointer(&b)},
+		live:  []int{0, 2},
+	}}
+	root := []interface{}{holder, &a, unsafe.Pointer(&dead)}
+
+	finder := NewDuplicateFinder(WithPaths(), WithUnsafePointers())
+	finder.ScanForPointers(root)
+	if !finder.IsDuplicatePointer(&a) {
+		t.Errorf("Expected the enumerated children to be scanned")
+	}
+	if finder.IsDuplicatePointer(unsafe.Pointer(&dead)) {
+		t.Errorf("Expected enumeration to replace scanning the fields")
+	}
+	if path, _ := finder.PathTo(TypedPointerOf(&b)); path.String() != "[0].pool[1]" {
+		t.Errorf("Expected path [0].pool[1] but got %v", path)
+	}
+}
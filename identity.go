@@ -0,0 +1,48 @@
+package duplicates
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// RawTypedPointer returns a raw TypedPointer (see TypedPointer.Raw) of type t
+// at address, such as the identity of an opaque value.
+func RawTypedPointer(t reflect.Type, address uintptr) TypedPointer {
+	return TypedPointer{Type: t, address: address}
+}
+
+// Address returns the address of the object that the pointer refers to.
+func (_this TypedPointer) Address() uintptr {
+	if _this.Pointer != nil {
+		return uintptr(_this.Pointer)
+	}
+	return _this.address
+}
+
+// Raw returns a TypedPointer that identifies the same object by address
+// alone, and so doesn't keep it alive.
+func (_this TypedPointer) Raw() TypedPointer {
+	return RawTypedPointer(_this.Type, _this.Address())
+}
+
+// IsRaw returns true if the pointer identifies its object by address alone.
+func (_this TypedPointer) IsRaw() bool {
+	return _this.Pointer == nil && _this.address != 0
+}
+
+// Returns ptr as the finder identifies pointers: raw if RawAddresses is set.
+func (_this *DuplicateFinder) identify(ptr TypedPointer) TypedPointer {
+	if _this.RawAddresses {
+		return ptr.Raw()
+	}
+	return ptr
+}
+
+// Returns the typed pointer of the object that rv references, as the finder
+// identifies pointers (see identify).
+func (_this *DuplicateFinder) typedPointerOf(rv reflect.Value) TypedPointer {
+	if _this.RawAddresses {
+		return RawTypedPointer(rv.Type(), rv.Pointer())
+	}
+	return TypedPointer{Type: rv.Type(), Pointer: unsafe.Pointer(rv.Pointer())}
+}
@@ -0,0 +1,87 @@
+package duplicates
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+type identityNode struct {
+	Name string
+}
+
+// Has scan register a fresh object that nothing else refers to, and reports
+// whether it has been collected after a few collections.
+func wasCollected(scan func(object interface{})) bool {
+	collected := make(chan bool, 1)
+	func() {
+		node := &identityNode{Name: "node"}
+		runtime.SetFinalizer(node, func(*identityNode) { collected <- true })
+		scan(node)
+	}()
+	for i := 0; i < 5; i++ {
+		runtime.GC()
+		select {
+		case <-collected:
+			return true
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return false
+}
+
+func TestKeepsObjectsAlive(t *testing.T) {
+	finder := NewDuplicateFinder()
+	if wasCollected(func(object interface{}) { finder.ScanForPointers(object) }) {
+		t.Errorf("Expected the finder to keep the object alive")
+	}
+	runtime.KeepAlive(finder)
+
+	var duplicates map[TypedPointer]bool
+	if wasCollected(func(object interface{}) { duplicates = FindDuplicatePointers([]interface{}{object, object}) }) {
+		t.Errorf("Expected the results to keep the object alive")
+	}
+	runtime.KeepAlive(duplicates)
+
+	var report *DuplicateReport
+	if wasCollected(func(object interface{}) { report = FindDuplicates([]interface{}{object, object}) }) {
+		t.Errorf("Expected the report to keep the object alive")
+	}
+	report.Release()
+	if !wasCollected(func(object interface{}) { report = FindDuplicates([]interface{}{object, object}); report.Release() }) {
+		t.Errorf("Expected a released report to let go of the object")
+	}
+	runtime.KeepAlive(report)
+}
+
+func TestRawAddresses(t *testing.T) {
+	finder := NewDuplicateFinder(WithRawAddresses())
+	if !wasCollected(func(object interface{}) { finder.ScanForPointers(object) }) {
+		t.Errorf("Expected the object to be collected")
+	}
+
+	v := 1
+	finder.ScanForPointers([]*int{&v, &v})
+	if !finder.IsDuplicatePointer(&v) || !finder.DuplicatePointers[TypedPointerOf(&v).Raw()] {
+		t.Errorf("Expected &v to be a duplicate by address")
+	}
+	if report := finder.Report(); !report.IsDuplicate(TypedPointerOf(&v)) || !report.Duplicates()[0].IsRaw() {
+		t.Errorf("Expected the report to hold &v by address")
+	}
+	runtime.KeepAlive(finder)
+}
+
+func TestTypedPointerRaw(t *testing.T) {
+	v := 1
+	ptr := TypedPointerOf(&v)
+	raw := ptr.Raw()
+	if ptr.IsRaw() || !raw.IsRaw() || raw == ptr || raw.Raw() != raw {
+		t.Errorf("Expected %v and its raw form to differ", ptr)
+	}
+	if ptr.Address() != raw.Address() || raw != RawTypedPointer(ptr.Type, ptr.Address()) {
+		t.Errorf("Expected %v and its raw form to have the same address", ptr)
+	}
+	if (TypedPointer{}).Raw() != (TypedPointer{}) {
+		t.Errorf("Expected a nil pointer to be the same in raw form")
+	}
+}
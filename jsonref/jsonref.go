@@ -0,0 +1,112 @@
+//go:build go1.25 && goexperiment.jsonv2
+// +build go1.25,goexperiment.jsonv2
+
+// Package jsonref wires duplicate detection into the encoding/json/v2
+// streaming encoder, so that shared objects are emitted in full only once.
+//
+// The first occurrence of a shared object is emitted as
+// {"$id": N, "$value": ...}, and every later occurrence as {"$ref": N}, where
+// N is the object's marker ID from a duplicates.DuplicateTable. Objects that
+// aren't shared are emitted normally. Since a reference is emitted instead of
+// descending again, cyclic data can also be encoded.
+package jsonref
+
+import (
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+	"errors"
+	"reflect"
+
+	duplicates "github.com/kstenerud/go-duplicates"
+)
+
+// Names of the object members used to mark shared objects.
+const (
+	IDName    = "$id"
+	ValueName = "$value"
+	RefName   = "$ref"
+)
+
+// Marshal encodes value as reference-aware JSON.
+func Marshal(value any, opts ...json.Options) ([]byte, error) {
+	return json.Marshal(value, withReferences(value, opts))
+}
+
+// MarshalEncode encodes value as reference-aware JSON into enc.
+func MarshalEncode(enc *jsontext.Encoder, value any, opts ...json.Options) error {
+	return json.MarshalEncode(enc, value, withReferences(value, opts))
+}
+
+func withReferences(value any, opts []json.Options) json.Options {
+	table := duplicates.FindDuplicatesTable(value)
+	marshalers := Marshalers(table, duplicates.NewEncodingSession())
+	return json.JoinOptions(append(opts, json.WithMarshalers(marshalers))...)
+}
+
+// Marshalers returns json marshalers that emit the duplicates in table as
+// references, using session to keep track of which duplicates have already
+// been emitted. Encoders that write multiple values to the same stream (or
+// resume writing later) can share one session across all of them.
+func Marshalers(table *duplicates.DuplicateTable, session *duplicates.EncodingSession) *json.Marshalers {
+	// The pointer about to be emitted in full, which must be left to the
+	// default encoding when it immediately comes back around to this
+	// marshaler.
+	var emitting duplicates.TypedPointer
+
+	return json.MarshalToFunc(func(enc *jsontext.Encoder, value any) error {
+		rv := reflect.ValueOf(value)
+		switch rv.Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Slice:
+		default:
+			return errors.ErrUnsupported
+		}
+		if rv.IsNil() {
+			return errors.ErrUnsupported
+		}
+
+		ptr := duplicates.TypedPointerOfRV(rv)
+		if ptr == emitting {
+			emitting = duplicates.TypedPointer{}
+			return errors.ErrUnsupported
+		}
+		markerID, isDuplicate := table.MarkerID(ptr)
+		if !isDuplicate {
+			return errors.ErrUnsupported
+		}
+
+		if action, backReference := session.Encounter(rv, markerID); action == duplicates.EmitBackReference {
+			return writeMember(enc, RefName, backReference)
+		}
+
+		if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+			return err
+		}
+		if err := enc.WriteToken(jsontext.String(IDName)); err != nil {
+			return err
+		}
+		if err := enc.WriteToken(jsontext.Int(int64(markerID))); err != nil {
+			return err
+		}
+		if err := enc.WriteToken(jsontext.String(ValueName)); err != nil {
+			return err
+		}
+		emitting = ptr
+		if err := json.MarshalEncode(enc, value, enc.Options()); err != nil {
+			return err
+		}
+		return enc.WriteToken(jsontext.EndObject)
+	})
+}
+
+func writeMember(enc *jsontext.Encoder, name string, id int) error {
+	if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+		return err
+	}
+	if err := enc.WriteToken(jsontext.String(name)); err != nil {
+		return err
+	}
+	if err := enc.WriteToken(jsontext.Int(int64(id))); err != nil {
+		return err
+	}
+	return enc.WriteToken(jsontext.EndObject)
+}
@@ -0,0 +1,52 @@
+//go:build go1.25 && goexperiment.jsonv2
+// +build go1.25,goexperiment.jsonv2
+
+package jsonref
+
+import (
+	"testing"
+)
+
+type node struct {
+	Name string
+	Next *node
+}
+
+func TestMarshalShared(t *testing.T) {
+	shared := &node{Name: "shared"}
+	value := []*node{shared, {Name: "other"}, shared}
+
+	actual, err := Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `[{"$id":0,"$value":{"Name":"shared","Next":null}},{"Name":"other","Next":null},{"$ref":0}]`
+	if string(actual) != expected {
+		t.Errorf("Expected %v but got %v", expected, string(actual))
+	}
+}
+
+func TestMarshalCycle(t *testing.T) {
+	value := &node{Name: "loop"}
+	value.Next = value
+
+	actual, err := Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"$id":0,"$value":{"Name":"loop","Next":{"$ref":0}}}`
+	if string(actual) != expected {
+		t.Errorf("Expected %v but got %v", expected, string(actual))
+	}
+}
+
+func TestMarshalNoDuplicates(t *testing.T) {
+	actual, err := Marshal(map[string][]int{"a": {1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"a":[1,2]}`
+	if string(actual) != expected {
+		t.Errorf("Expected %v but got %v", expected, string(actual))
+	}
+}
@@ -0,0 +1,78 @@
+package duplicates
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// TreatImplementersAsLeaf makes the finder treat every type implementing
+// ifaceType (for example proto.Message) as a leaf: pointers to it are
+// registered, but what they point to is never scanned. A type counts as
+// implementing ifaceType if either it or a pointer to it does.
+//
+// This method panics if ifaceType is not an interface type.
+func (_this *DuplicateFinder) TreatImplementersAsLeaf(ifaceType reflect.Type) {
+	if ifaceType.Kind() != reflect.Interface {
+		panic(fmt.Errorf("duplicates: %v is not an interface type", ifaceType))
+	}
+	_this.leafInterfaces = append(_this.leafInterfaces, ifaceType)
+	_this.leafTypes = nil
+}
+
+// TreatPackagesAsLeaf makes the finder treat every type defined in a package
+// whose import path starts with one of the given prefixes (for example
+// "google.golang.org/protobuf") as a leaf: pointers to it are registered, but
+// what they point to is never scanned. A prefix only matches whole path
+// elements, so "example.com/a" matches "example.com/a/b" but not
+// "example.com/ab".
+func (_this *DuplicateFinder) TreatPackagesAsLeaf(prefixes ...string) {
+	_this.leafPackages = append(_this.leafPackages, prefixes...)
+	_this.leafTypes = nil
+}
+
+// Returns true if values of type t must not be descended into.
+func (_this *DuplicateFinder) isLeafType(t reflect.Type) bool {
+	if len(_this.leafInterfaces) == 0 && len(_this.leafPackages) == 0 {
+		return false
+	}
+	if isLeaf, ok := _this.leafTypes[t]; ok {
+		return isLeaf
+	}
+	if _this.leafTypes == nil {
+		_this.leafTypes = make(map[reflect.Type]bool)
+	}
+	isLeaf := false
+	for _, iface := range _this.leafInterfaces {
+		if t.Implements(iface) || (t.Kind() != reflect.Ptr && reflect.PtrTo(t).Implements(iface)) {
+			isLeaf = true
+			break
+		}
+	}
+	if !isLeaf && len(_this.leafPackages) > 0 {
+		isLeaf = _this.isInLeafPackage(t)
+	}
+	_this.leafTypes[t] = isLeaf
+	return isLeaf
+}
+
+// Returns true if t, or the type that it refers to (for pointers, slices,
+// maps, and arrays), is defined in one of the leaf packages.
+func (_this *DuplicateFinder) isInLeafPackage(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Array:
+		if t.Name() == "" {
+			t = t.Elem()
+		}
+	}
+	pkgPath := t.PkgPath()
+	if pkgPath == "" {
+		return false
+	}
+	for _, prefix := range _this.leafPackages {
+		if pkgPath == prefix || strings.HasPrefix(pkgPath, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package duplicates
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+type leafMessage interface {
+	ProtoMessage()
+}
+
+type leafRequest struct {
+	Payload *string
+}
+
+func (_this *leafRequest) ProtoMessage() {}
+
+type leafEnvelope struct {
+	Request  *leafRequest
+	Embedded leafRequest
+	ByID     map[int]leafRequest
+	Other    *string
+}
+
+func TestTreatImplementersAsLeaf(t *testing.T) {
+	payload := "payload"
+	request := &leafRequest{Payload: &payload}
+	envelope := &leafEnvelope{
+		Request:  request,
+		Embedded: leafRequest{Payload: &payload},
+		ByID:     map[int]leafRequest{1: {Payload: &payload}},
+		Other:    &payload,
+	}
+
+	finder := NewDuplicateFinder()
+	finder.TreatImplementersAsLeaf(reflect.TypeOf((*leafMessage)(nil)).Elem())
+	finder.ScanForPointers([]*leafEnvelope{envelope, envelope})
+
+	if !finder.IsDuplicatePointer(envelope) {
+		t.Errorf("Expected the envelope to be a duplicate")
+	}
+	if _, ok := finder.recordIndex[TypedPointerOf(request)]; !ok {
+		t.Errorf("Expected the leaf pointer to be registered")
+	}
+	if finder.IsDuplicatePointer(&payload) {
+		t.Errorf("Expected the leaves to not be descended into")
+	}
+	if _, ok := finder.recordIndex[TypedPointerOf(&request.Payload)]; ok {
+		t.Errorf("Expected the fields of leaves to not be registered")
+	}
+}
+
+func TestTreatImplementersAsLeafNotInterface(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic for a non-interface type")
+		}
+	}()
+	NewDuplicateFinder().TreatImplementersAsLeaf(reflect.TypeOf(1))
+}
+
+func TestTreatPackagesAsLeaf(t *testing.T) {
+	user := url.User("someone")
+	first := &url.URL{Scheme: "http", User: user}
+	second := &url.URL{Scheme: "https", User: user}
+	root := []*url.URL{first, second, first}
+
+	for _, prefix := range []string{"net", "net/url", "net/"} {
+		finder := NewDuplicateFinder(WithLeafPackages(prefix))
+		finder.ScanForPointers(root)
+		if !finder.IsDuplicatePointer(first) {
+			t.Errorf("%v: Expected pointers to leaf types to be registered", prefix)
+		}
+		if isRegistered(finder, TypedPointerOf(user)) {
+			t.Errorf("%v: Expected leaf types not to be descended into", prefix)
+		}
+	}
+
+	finder := NewDuplicateFinder(WithLeafPackages("ne"))
+	finder.ScanForPointers(root)
+	if !finder.IsDuplicatePointer(user) {
+		t.Errorf("Expected a partial path element not to match")
+	}
+}
@@ -0,0 +1,100 @@
+package duplicates
+
+import (
+	"reflect"
+	"time"
+)
+
+// How many values are visited between checks of a scan's context and time
+// budget.
+const interruptCheckInterval = 1024
+
+// Truncated returns true if a limit (such as MaxDepth) stopped any scan since
+// the finder was initialized from reaching everything, meaning that some
+// duplicates may not have been found.
+func (_this *DuplicateFinder) Truncated() bool {
+	return _this.truncated
+}
+
+// Returns true (marking the results as truncated) if the children of the
+// current position must not be scanned because of MaxDepth.
+func (_this *DuplicateFinder) atDepthLimit() bool {
+	if _this.MaxDepth > 0 && _this.depth >= _this.MaxDepth {
+		_this.truncated = true
+		return true
+	}
+	return false
+}
+
+// TruncatedContainers returns the slices, maps, and arrays (by address) whose
+// elements were cut short by MaxElements, in the order they were found.
+// Arrays held by values that aren't addressable can't be identified, and are
+// not listed.
+func (_this *DuplicateFinder) TruncatedContainers() (containers []TypedPointer) {
+	seen := make(map[TypedPointer]bool)
+	for _, container := range _this.truncatedContainers {
+		if !seen[container] {
+			seen[container] = true
+			containers = append(containers, container)
+		}
+	}
+	return
+}
+
+// Returns how many of a container's count elements must be scanned, noting
+// the container if MaxElements cuts it short.
+func (_this *DuplicateFinder) limitElements(container reflect.Value, count int) int {
+	if _this.MaxElements <= 0 || count <= _this.MaxElements {
+		return count
+	}
+	_this.truncated = true
+	switch {
+	case container.Kind() != reflect.Array:
+		_this.truncatedContainers = append(_this.truncatedContainers, _this.typedPointerOf(container))
+	case container.CanAddr():
+		_this.truncatedContainers = append(_this.truncatedContainers, _this.typedPointerOf(container.Addr()))
+	}
+	return _this.MaxElements
+}
+
+// Returns true if the scan must periodically check whether it has been
+// interrupted.
+func (_this *DuplicateFinder) isInterruptible() bool {
+	return _this.ctx != nil || _this.MaxDuration > 0
+}
+
+// Stops the scan if its context is done or its time budget has run out.
+func (_this *DuplicateFinder) checkInterrupts() {
+	if _this.ctx != nil {
+		if err := _this.ctx.Err(); err != nil {
+			_this.ctxErr = err
+			_this.truncated = true
+			_this.stopScan()
+		}
+	}
+	if _this.MaxDuration > 0 && time.Now().After(_this.deadline) {
+		_this.truncated = true
+		_this.stopScan()
+	}
+}
+
+// Abandons the current scan, keeping what was found so far.
+func (_this *DuplicateFinder) stopScan() {
+	_this.stopping = true
+	panic("duplicates: scan stopped")
+}
+
+// Recovers from stopScan at the root of a scan, restoring the state that the
+// abandoned scan left behind.
+func (_this *DuplicateFinder) recoverStoppedScan() {
+	if !_this.stopping {
+		return
+	}
+	recover()
+	_this.stopping = false
+	_this.discardFrames(0)
+	_this.unwindReferents(0)
+	_this.upgrading = false
+	_this.steps = _this.steps[:0]
+	_this.clearQueue()
+}
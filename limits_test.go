@@ -0,0 +1,126 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type limitsNode struct {
+	Next  *limitsNode
+	Value *int
+}
+
+func TestMaxDepth(t *testing.T) {
+	v := 1
+	// Depths: pointer 0, struct 1, .Next 2, struct 3, .Value 4
+	root := &limitsNode{Value: &v, Next: &limitsNode{Value: &v}}
+
+	finder := NewDuplicateFinder(WithMaxDepth(3))
+	finder.ScanForPointers(root)
+	if finder.IsDuplicatePointer(&v) {
+		t.Errorf("Expected the second &v to be beyond the depth limit")
+	}
+	if !finder.Truncated() || !finder.Report().ScanInfo().Truncated {
+		t.Errorf("Expected the results to be marked as truncated")
+	}
+
+	finder = NewDuplicateFinder(WithMaxDepth(4))
+	finder.ScanForPointers(root)
+	if !finder.IsDuplicatePointer(&v) {
+		t.Errorf("Expected &v to be a duplicate within the depth limit")
+	}
+}
+
+func TestMaxNodes(t *testing.T) {
+	values := make([]int, 10)
+	pointers := []*int{}
+	for i := range values {
+		pointers = append(pointers, &values[i])
+	}
+	pointers = append(pointers, &values[0])
+
+	finder := NewDuplicateFinder(WithMaxNodes(5))
+	finder.ScanForPointers(pointers)
+	if finder.NodesVisited() != 5 || !finder.Truncated() {
+		t.Errorf("Expected the scan to stop after 5 nodes but got %v (truncated: %v)", finder.NodesVisited(), finder.Truncated())
+	}
+	if len(finder.records) != 5 {
+		t.Errorf("Expected the pointers found before stopping to be kept but got %v", len(finder.records))
+	}
+	if finder.IsDuplicatePointer(&values[0]) {
+		t.Errorf("Expected the last element not to have been reached")
+	}
+
+	// The finder remains usable.
+	finder.MaxNodes = 0
+	finder.ScanForPointers(&values[0])
+	if !finder.IsDuplicatePointer(&values[0]) {
+		t.Errorf("Expected a later scan to carry on normally")
+	}
+}
+
+func TestMaxNodesSkipPanickingValues(t *testing.T) {
+	finder := NewDuplicateFinder(WithMaxNodes(2), WithSkipPanickingValues())
+	err := finder.TryScanForPointers([]*int{new(int), new(int), new(int)})
+	if err != nil || !finder.Truncated() {
+		t.Errorf("Expected the stop not to be reported as a panic but got %v", err)
+	}
+}
+
+func TestMaxDuration(t *testing.T) {
+	nodes := make([]*int, interruptCheckInterval*3)
+	for i := range nodes {
+		nodes[i] = new(int)
+	}
+
+	finder := NewDuplicateFinder(WithMaxDuration(time.Nanosecond))
+	finder.ScanForPointers(nodes)
+	if !finder.Truncated() || finder.NodesVisited() >= len(nodes) {
+		t.Errorf("Expected the scan to run out of time but visited %v nodes", finder.NodesVisited())
+	}
+
+	finder = NewDuplicateFinder(WithMaxDuration(time.Hour))
+	finder.ScanForPointers(nodes)
+	if finder.Truncated() {
+		t.Errorf("Expected the scan to complete within its budget")
+	}
+}
+
+func TestMaxElements(t *testing.T) {
+	values := make([]int, 5)
+	elements := make([]*int, 5)
+	for i := range elements {
+		elements[i] = &values[i]
+	}
+	root := &struct {
+		Slice []*int
+		Map   map[int]*int
+		Array [3]*int
+		Tail  *int
+	}{
+		Slice: elements,
+		Map:   map[int]*int{0: &values[3], 1: &values[3], 2: &values[3], 3: &values[3]},
+		Array: [3]*int{&values[0], &values[1], &values[4]},
+		Tail:  &values[4],
+	}
+
+	finder := NewDuplicateFinder(WithMaxElements(2), WithSortedMapKeys())
+	finder.ScanForPointers(root)
+	if !finder.Truncated() {
+		t.Errorf("Expected the results to be truncated")
+	}
+	if !finder.IsDuplicatePointer(&values[0]) || !finder.IsDuplicatePointer(&values[3]) {
+		t.Errorf("Expected sharing among the first elements to be found")
+	}
+	if finder.IsDuplicatePointer(&values[4]) {
+		t.Errorf("Expected elements past the limit not to be scanned")
+	}
+	expected := []TypedPointer{TypedPointerOf(elements), TypedPointerOf(root.Map), TypedPointerOf(&root.Array)}
+	if !reflect.DeepEqual(finder.TruncatedContainers(), expected) {
+		t.Errorf("Expected %v but got %v", expected, finder.TruncatedContainers())
+	}
+	if !reflect.DeepEqual(finder.Report().TruncatedContainers(), expected) {
+		t.Errorf("Expected the report to list the truncated containers")
+	}
+}
@@ -0,0 +1,157 @@
+// Package msgpackref maps duplicate pointers to MessagePack extension types,
+// so that shared objects can be written once and referred to afterwards.
+//
+// Each reference marker is a fixext 4 extension of a configurable type, whose
+// payload is a big-endian uint32: the low 31 bits hold the reference ID, and
+// the high bit distinguishes a definition (0: the shared object's value
+// follows immediately after the marker) from a reference (1: the marker
+// stands in for the object, which was defined earlier).
+//
+// This package doesn't depend on any particular MessagePack library. Encoders
+// query a Referencer for each pointer-like value and write the returned
+// marker bytes, and decoders feed the extensions they encounter to a
+// Resolver.
+package msgpackref
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+
+	duplicates "github.com/kstenerud/go-duplicates"
+)
+
+// DefaultExtType is the extension type used when none is specified.
+const DefaultExtType int8 = 0x44
+
+const (
+	fixExt4        = 0xd6
+	referenceFlag  = 0x80000000
+	maxReferenceID = referenceFlag - 1
+	markerLength   = 6
+)
+
+// AppendDefinition appends a marker defining reference ID id to buf. The
+// shared object's value must be written immediately afterwards.
+func AppendDefinition(buf []byte, extType int8, id int) []byte {
+	return appendMarker(buf, extType, uint32(id))
+}
+
+// AppendReference appends a marker referring back to the object defined with
+// reference ID id.
+func AppendReference(buf []byte, extType int8, id int) []byte {
+	return appendMarker(buf, extType, uint32(id)|referenceFlag)
+}
+
+func appendMarker(buf []byte, extType int8, payload uint32) []byte {
+	buf = append(buf, fixExt4, byte(extType), 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(buf[len(buf)-4:], payload)
+	return buf
+}
+
+// ParsePayload decodes the payload of a reference marker extension.
+func ParsePayload(data []byte) (id int, isReference bool, err error) {
+	if len(data) != 4 {
+		return 0, false, fmt.Errorf("msgpackref: expected a 4 byte payload but got %v bytes", len(data))
+	}
+	payload := binary.BigEndian.Uint32(data)
+	return int(payload &^ referenceFlag), payload&referenceFlag != 0, nil
+}
+
+// Referencer decides, on the encoding side, which values are written in full
+// and which are written as references. Reference IDs are the marker IDs of a
+// duplicates.DuplicateTable.
+type Referencer struct {
+	ExtType int8
+	table   *duplicates.DuplicateTable
+	session *duplicates.EncodingSession
+	buffer  [markerLength]byte
+}
+
+// NewReferencer scans value for duplicates and returns a Referencer for
+// encoding it.
+func NewReferencer(value interface{}, extType int8) *Referencer {
+	return NewReferencerFromTable(duplicates.FindDuplicatesTable(value), extType)
+}
+
+// NewReferencerFromTable returns a Referencer that allocates reference IDs
+// from an existing table.
+func NewReferencerFromTable(table *duplicates.DuplicateTable, extType int8) *Referencer {
+	return &Referencer{
+		ExtType: extType,
+		table:   table,
+		session: duplicates.NewEncodingSession(),
+	}
+}
+
+// Query is called by the encoder for every pointer, map, or slice it is about
+// to encode. If the value isn't shared, marker is nil and the value is written
+// normally. Otherwise marker must be written first, and if action is
+// EmitBackReference the value itself must then be skipped.
+//
+// The returned marker is only valid until the next call to Query.
+func (_this *Referencer) Query(value reflect.Value) (marker []byte, action duplicates.EncodeAction) {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+	default:
+		return nil, duplicates.EmitValue
+	}
+	if value.IsNil() {
+		return nil, duplicates.EmitValue
+	}
+	id, isDuplicate := _this.table.MarkerIDOfRV(value)
+	if !isDuplicate || id > maxReferenceID {
+		return nil, duplicates.EmitValue
+	}
+
+	action, backReference := _this.session.Encounter(value, id)
+	if action == duplicates.EmitBackReference {
+		return AppendReference(_this.buffer[:0], _this.ExtType, backReference), action
+	}
+	return AppendDefinition(_this.buffer[:0], _this.ExtType, id), action
+}
+
+// Resolver resolves reference markers on the decoding side.
+type Resolver struct {
+	ExtType int8
+	objects map[int]interface{}
+}
+
+func NewResolver(extType int8) *Resolver {
+	return &Resolver{
+		ExtType: extType,
+		objects: make(map[int]interface{}),
+	}
+}
+
+// Define records the object decoded immediately after a definition marker
+// with reference ID id.
+func (_this *Resolver) Define(id int, object interface{}) {
+	_this.objects[id] = object
+}
+
+// Resolve returns the object previously defined with reference ID id.
+func (_this *Resolver) Resolve(id int) (object interface{}, err error) {
+	object, ok := _this.objects[id]
+	if !ok {
+		return nil, fmt.Errorf("msgpackref: reference to undefined ID %v", id)
+	}
+	return object, nil
+}
+
+// HandleExt is called by the decoder for every extension it encounters. If
+// the extension is a reference marker, isMarker is true. For a reference,
+// object holds the object referred to. For a definition, the decoder must
+// decode the next value and pass it to Define(id, ...).
+func (_this *Resolver) HandleExt(extType int8, data []byte) (isMarker bool, id int, isReference bool, object interface{}, err error) {
+	if extType != _this.ExtType {
+		return false, 0, false, nil, nil
+	}
+	if id, isReference, err = ParsePayload(data); err != nil {
+		return true, 0, false, nil, err
+	}
+	if isReference {
+		object, err = _this.Resolve(id)
+	}
+	return true, id, isReference, object, err
+}
@@ -0,0 +1,69 @@
+package msgpackref
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	duplicates "github.com/kstenerud/go-duplicates"
+)
+
+func TestMarkers(t *testing.T) {
+	definition := AppendDefinition(nil, 5, 3)
+	if !bytes.Equal(definition, []byte{0xd6, 5, 0, 0, 0, 3}) {
+		t.Errorf("Unexpected definition marker %x", definition)
+	}
+	reference := AppendReference(nil, 5, 3)
+	if !bytes.Equal(reference, []byte{0xd6, 5, 0x80, 0, 0, 3}) {
+		t.Errorf("Unexpected reference marker %x", reference)
+	}
+
+	id, isReference, err := ParsePayload(reference[2:])
+	if err != nil || id != 3 || !isReference {
+		t.Errorf("Expected reference to 3 but got %v %v %v", id, isReference, err)
+	}
+	if _, _, err := ParsePayload([]byte{1}); err == nil {
+		t.Errorf("Expected an error for a short payload")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	shared := &[]int{1, 2}
+	other := &[]int{3}
+	value := []*[]int{shared, other, shared}
+
+	referencer := NewReferencer(value, DefaultExtType)
+	var markers [][]byte
+	var actions []duplicates.EncodeAction
+	for _, elem := range value {
+		marker, action := referencer.Query(reflect.ValueOf(elem))
+		markers = append(markers, append([]byte(nil), marker...))
+		actions = append(actions, action)
+	}
+
+	if markers[1] != nil || actions[1] != duplicates.EmitValue {
+		t.Errorf("Expected no marker for an unshared value")
+	}
+	if actions[0] != duplicates.EmitValue || actions[2] != duplicates.EmitBackReference {
+		t.Errorf("Expected a definition and then a reference but got %v", actions)
+	}
+
+	resolver := NewResolver(DefaultExtType)
+	isMarker, id, isReference, _, err := resolver.HandleExt(int8(markers[0][1]), markers[0][2:])
+	if err != nil || !isMarker || isReference {
+		t.Fatalf("Expected a definition but got %v %v %v", isMarker, isReference, err)
+	}
+	resolver.Define(id, shared)
+
+	_, _, isReference, object, err := resolver.HandleExt(int8(markers[2][1]), markers[2][2:])
+	if err != nil || !isReference || object != shared {
+		t.Errorf("Expected a reference to the shared object but got %v %v %v", isReference, object, err)
+	}
+
+	if isMarker, _, _, _, _ := resolver.HandleExt(1, []byte{0, 0, 0, 0}); isMarker {
+		t.Errorf("Expected a foreign extension type to be ignored")
+	}
+	if _, err := resolver.Resolve(99); err == nil {
+		t.Errorf("Expected an error resolving an undefined ID")
+	}
+}
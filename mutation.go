@@ -0,0 +1,212 @@
+package duplicates
+
+import (
+	"strings"
+)
+
+// MutationObserver is a path that would observe a mutation, and the shared
+// object through which it observes it.
+type MutationObserver struct {
+	// The path, in the same form as Path.String().
+	Path string
+	// The shared object that both the mutated path and Path run through.
+	Shared TypedPointer
+}
+
+// MutationImpact scans root and reports every other path that would observe
+// an in-place mutation of the value at target because of sharing. target is
+// a path in the same form as Path.String() (for example
+// ".Servers[2].TLSConfig"). If nothing is reported, it is safe to modify the
+// value at target in place without affecting anything else in root.
+//
+// A mutation is observed through any object that contains the target (and is
+// shared), as well as through any object inside the target (that is also
+// referenced from elsewhere). Observers are reported in discovery order.
+func MutationImpact(root interface{}, target string) (observers []MutationObserver) {
+	finder := NewDuplicateFinder()
+	finder.RecordPaths = true
+
+	type sighting struct {
+		record int
+		node   int32
+	}
+	var sightings []sighting
+	// The path nodes at which each record was sighted, first sighting first,
+	// and the records sighted at each path node.
+	recordNodes := make(map[int][]int32)
+	nodeRecords := make(map[int32][]int)
+	finder.sightingHook = func(index int) {
+		node := finder.pathNode
+		sightings = append(sightings, sighting{record: index, node: node})
+		recordNodes[index] = append(recordNodes[index], node)
+		nodeRecords[node] = append(nodeRecords[node], index)
+	}
+	finder.ScanForPointers(root)
+
+	resolved := finder.resolveMutationTarget(target, recordNodes, nodeRecords)
+
+	// Path nodes are always added after their parents, so whether a node is
+	// inside the target (or inside the path as requested) can be worked out
+	// in one pass.
+	insideTarget := make([]bool, len(finder.pathNodes))
+	insideRequested := make([]bool, len(finder.pathNodes))
+	isInside := func(inside []bool, node int32, ancestor int32) bool {
+		if node == noPathNode {
+			return ancestor == noPathNode
+		}
+		return inside[node]
+	}
+	for i, pathNode := range finder.pathNodes {
+		insideTarget[i] = resolved.rest == "" &&
+			(int32(i) == resolved.node || isInside(insideTarget, pathNode.parent, resolved.node))
+		insideRequested[i] = resolved.isRequested &&
+			(int32(i) == resolved.requested || isInside(insideRequested, pathNode.parent, resolved.requested))
+	}
+	isInsideTarget := func(node int32) bool {
+		return resolved.rest == "" && isInside(insideTarget, node, resolved.node)
+	}
+	isObservable := func(node int32) bool {
+		return !isInsideTarget(node) &&
+			!(resolved.isRequested && isInside(insideRequested, node, resolved.requested))
+	}
+
+	// The records whose objects contain the target, and the steps from each
+	// of them down to the target.
+	suffixes := make(map[int]string)
+	for node := resolved.node; ; node = finder.pathNodes[node].parent {
+		for _, record := range nodeRecords[node] {
+			if _, ok := suffixes[record]; !ok && recordNodes[record][0] == node {
+				suffixes[record] = stepsString(finder.stepsBetween(node, resolved.node)) + resolved.rest
+			}
+		}
+		if node == noPathNode {
+			break
+		}
+	}
+
+	seen := make(map[string]bool)
+	observed := make(map[int]bool)
+	addObserver := func(node int32, suffix string, record int) {
+		path := finder.pathAt(node).String() + suffix
+		if seen[path] {
+			return
+		}
+		seen[path] = true
+		observers = append(observers, MutationObserver{Path: path, Shared: finder.records[record].pointer})
+	}
+	for _, s := range sightings {
+		suffix, contains := suffixes[s.record]
+		if observed[s.record] || (!contains && !isInsideTarget(s.node)) {
+			continue
+		}
+		observed[s.record] = true
+		for i, other := range recordNodes[s.record] {
+			if !isObservable(other) {
+				continue
+			}
+			if contains {
+				// The object contains the target. Seen from where the target
+				// was requested, that's the target itself.
+				if other == resolved.jumpedFrom || (i == 0 && !resolved.jumped) {
+					continue
+				}
+				addObserver(other, suffix, s.record)
+			} else {
+				// The object is inside the target.
+				addObserver(other, "", s.record)
+			}
+		}
+	}
+	return
+}
+
+// A mutation target located in the recorded paths.
+type mutationTarget struct {
+	// The path node of the target, reached through first sightings only. If
+	// the target is part of a value that wasn't visited on its own (such as
+	// an element of a []int), this is the node of the closest visited value
+	// containing it, and rest holds the steps from there.
+	node int32
+	rest string
+	// The path node at which the target was requested, if it exists (the
+	// requested path may run through a later sighting of an object that
+	// wasn't scanned into).
+	requested   int32
+	isRequested bool
+	// The last later sighting that was replaced by a first sighting on the
+	// way to node.
+	jumpedFrom int32
+	jumped     bool
+}
+
+// Finds the path node at target, which is in the same form as Path.String().
+// Only the first sighting of an object is scanned into, so a target reached
+// through a later sighting continues from the first one instead.
+func (_this *DuplicateFinder) resolveMutationTarget(target string,
+	recordNodes map[int][]int32,
+	nodeRecords map[int32][]int) (resolved mutationTarget) {
+
+	children := make(map[int32][]int32)
+	for i, pathNode := range _this.pathNodes {
+		children[pathNode.parent] = append(children[pathNode.parent], int32(i))
+	}
+
+	// A node matching the start of target, and the rest of target below it.
+	type position struct {
+		node       int32
+		rest       string
+		jumpedFrom int32
+		jumped     bool
+	}
+	stack := []position{{node: noPathNode, rest: target}}
+	closest := stack[0]
+	for len(stack) > 0 {
+		at := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if at.rest == "" {
+			resolved.requested, resolved.isRequested = at.node, true
+		}
+		for _, record := range nodeRecords[at.node] {
+			if first := recordNodes[record][0]; first != at.node {
+				at.jumpedFrom, at.jumped = at.node, true
+				at.node = first
+				break
+			}
+		}
+		if at.rest == "" {
+			closest = at
+			break
+		}
+		matched := false
+		nodes := children[at.node]
+		for i := len(nodes) - 1; i >= 0; i-- {
+			step := _this.pathNodes[nodes[i]].step.String()
+			rest := at.rest
+			if step != "" {
+				if !strings.HasPrefix(rest, step) {
+					continue
+				}
+				rest = rest[len(step):]
+				if rest != "" && rest[0] != '.' && rest[0] != '[' && rest[0] != '{' {
+					continue
+				}
+			}
+			stack = append(stack, position{node: nodes[i], rest: rest, jumpedFrom: at.jumpedFrom, jumped: at.jumped})
+			matched = true
+		}
+		if !matched && len(at.rest) < len(closest.rest) {
+			closest = at
+		}
+	}
+	resolved.node, resolved.rest = closest.node, closest.rest
+	resolved.jumpedFrom, resolved.jumped = closest.jumpedFrom, closest.jumped
+	return
+}
+
+func stepsString(steps []PathStep) string {
+	builder := strings.Builder{}
+	for _, step := range steps {
+		builder.WriteString(step.String())
+	}
+	return builder.String()
+}
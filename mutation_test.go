@@ -0,0 +1,91 @@
+package duplicates
+
+import (
+	"testing"
+)
+
+type mutationServer struct {
+	Name   string
+	Config *mutationConfig
+}
+
+type mutationConfig struct {
+	Timeout int
+	Limits  []int
+}
+
+type mutationRoot struct {
+	Servers []*mutationServer
+	Default *mutationConfig
+	Limits  []int
+}
+
+func describeObservers(observers []MutationObserver) []string {
+	paths := []string{}
+	for _, observer := range observers {
+		paths = append(paths, observer.Path)
+	}
+	return paths
+}
+
+func TestMutationImpact(t *testing.T) {
+	config := &mutationConfig{Limits: []int{1, 2}}
+	root := &mutationRoot{
+		Servers: []*mutationServer{{Name: "a", Config: config}, {Name: "b", Config: config}, {Name: "c"}},
+		Default: config,
+		Limits:  config.Limits,
+	}
+
+	assertObservers := func(target string, expected ...string) {
+		actual := describeObservers(MutationImpact(root, target))
+		if len(actual) != len(expected) {
+			t.Errorf("%v: expected %v but got %v", target, expected, actual)
+			return
+		}
+		for i := range expected {
+			if actual[i] != expected[i] {
+				t.Errorf("%v: expected %v but got %v", target, expected, actual)
+				return
+			}
+		}
+	}
+
+	assertObservers(".Servers[0].Config.Timeout", ".Servers[1].Config.Timeout", ".Default.Timeout")
+	// A target reached through a later sighting is rewritten to the first.
+	assertObservers(".Default.Timeout", ".Servers[0].Config.Timeout", ".Servers[1].Config.Timeout")
+	// Sharing inside the target.
+	assertObservers(".Default", ".Servers[0].Config", ".Servers[1].Config", ".Limits")
+	assertObservers(".Servers[0].Config.Limits[1]", ".Servers[1].Config.Limits[1]", ".Default.Limits[1]", ".Limits[1]")
+	assertObservers(".Servers[2].Name")
+	assertObservers(".Servers[0].Name")
+}
+
+func TestMutationImpactFieldAddress(t *testing.T) {
+	type holder struct {
+		Value int
+		Ref   *int
+	}
+	h := &holder{}
+	h.Ref = &h.Value
+
+	observers := MutationImpact(h, ".Value")
+	if len(observers) != 1 || observers[0].Path != ".Ref" || observers[0].Shared != TypedPointerOf(&h.Value) {
+		t.Errorf("Expected the mutation to be observed through .Ref but got %v", observers)
+	}
+}
+
+func TestMutationImpactImplicitSteps(t *testing.T) {
+	type holder struct {
+		A *mutationConfig
+		B map[string]*mutationConfig
+		I interface{}
+	}
+	config := &mutationConfig{}
+	h := &holder{A: config, B: map[string]*mutationConfig{"x": config}, I: config}
+
+	actual := describeObservers(MutationImpact(h, `.B["x"].Timeout`))
+	expected := []string{".A.Timeout", ".I.Timeout"}
+	if len(actual) != len(expected) || actual[0] != expected[0] || actual[1] != expected[1] {
+		t.Errorf("Expected %v but got %v", expected, actual)
+	}
+}
@@ -0,0 +1,28 @@
+package duplicates
+
+import (
+	"reflect"
+)
+
+// Registers an opaque value (see isOpaqueType) by its identity, if it has
+// one. Opaque values are never descended into.
+func (_this *DuplicateFinder) visitOpaque(value reflect.Value) {
+	identity, hasIdentity := opaqueIdentity(value)
+	if !hasIdentity {
+		return
+	}
+	typedPtr := RawTypedPointer(value.Type(), identity)
+	if _this.withoutRecords {
+		_this.registerWithoutRecord(typedPtr)
+		return
+	}
+	if index, ok := _this.recordIndex[typedPtr]; ok {
+		if !_this.upgrading {
+			_this.recordSighting(index)
+			_this.onSighting(index)
+		}
+		return
+	}
+	_this.registerPointer(typedPtr, value)
+	_this.onSighting(len(_this.records) - 1)
+}
@@ -0,0 +1,49 @@
+//go:build js && wasm
+// +build js,wasm
+
+package duplicates
+
+import (
+	"reflect"
+	"syscall/js"
+)
+
+var (
+	jsValueType = reflect.TypeOf(js.Value{})
+	jsFuncType  = reflect.TypeOf(js.Func{})
+)
+
+// Returns true if values of type t are handles to objects outside of Go's
+// memory, which must not be descended into. A js.Value is a reference to a
+// JavaScript value, and its internals are bookkeeping that would otherwise
+// show up as spurious sharing.
+func isOpaqueType(t reflect.Type) bool {
+	return t == jsValueType || t == jsFuncType
+}
+
+// The layout of js.Value's reference: JavaScript objects are encoded as NaNs
+// with a type flag in the upper bits and an ID in the lower bits (see
+// syscall/js).
+const (
+	jsNaNHead          = 0x7FF80000
+	jsTypeFlagObject   = 1
+	jsTypeFlagFunction = 4
+)
+
+// Returns the ID that JavaScript assigned to the object or function that an
+// opaque value refers to. Every js.Value referring to the same object has the
+// same ID. Numbers, strings, and other primitives have no identity.
+func opaqueIdentity(value reflect.Value) (identity uintptr, hasIdentity bool) {
+	if value.Type() == jsFuncType {
+		value = value.Field(0)
+	}
+	ref := value.FieldByName("ref").Uint()
+	typeFlag := (ref >> 32) & 7
+	if (ref>>32)&jsNaNHead != jsNaNHead {
+		return 0, false
+	}
+	if typeFlag != jsTypeFlagObject && typeFlag != jsTypeFlagFunction {
+		return 0, false
+	}
+	return uintptr(ref), true
+}
@@ -0,0 +1,39 @@
+//go:build js && wasm
+// +build js,wasm
+
+package duplicates
+
+import (
+	"reflect"
+	"syscall/js"
+	"testing"
+)
+
+type jsHolder struct {
+	Document js.Value
+	Body     js.Value
+	Number   js.Value
+	Other    js.Value
+}
+
+func TestJSValuesAreOpaque(t *testing.T) {
+	document := js.Global().Get("Object").New()
+	holder := &jsHolder{
+		Document: document,
+		Body:     document,
+		Number:   js.ValueOf(1),
+		Other:    js.ValueOf(1),
+	}
+
+	finder := NewDuplicateFinder()
+	finder.ScanForPointers(holder)
+	identity, _ := opaqueIdentity(reflect.ValueOf(document))
+	if !finder.DuplicatePointers[RawTypedPointer(jsValueType, identity)] {
+		t.Errorf("Expected the shared JavaScript object to be a duplicate")
+	}
+	for ptr, isDuplicate := range finder.DuplicatePointers {
+		if isDuplicate && ptr.Type != jsValueType {
+			t.Errorf("Expected only JavaScript objects to be duplicates but got %v", ptr)
+		}
+	}
+}
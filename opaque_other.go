@@ -0,0 +1,20 @@
+//go:build !(js && wasm)
+// +build !js !wasm
+
+package duplicates
+
+import (
+	"reflect"
+)
+
+// Returns true if values of type t are handles to objects outside of Go's
+// memory, which must not be descended into.
+func isOpaqueType(t reflect.Type) bool {
+	return false
+}
+
+// Returns the identity of the object that an opaque value refers to, if it
+// has one.
+func opaqueIdentity(value reflect.Value) (identity uintptr, hasIdentity bool) {
+	return 0, false
+}
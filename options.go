@@ -0,0 +1,271 @@
+package duplicates
+
+import (
+	"reflect"
+	"time"
+)
+
+// Option configures a DuplicateFinder when passed to NewDuplicateFinder. Each
+// option has the same effect as setting the corresponding field or calling
+// the corresponding method of the finder.
+type Option func(finder *DuplicateFinder)
+
+// WithCapacity pre-sizes the finder's storage (see InitWithCapacity).
+func WithCapacity(expectedPointers int) Option {
+	return func(finder *DuplicateFinder) {
+		finder.InitWithCapacity(expectedPointers)
+	}
+}
+
+// WithPlanCache makes the finder use cache for its per-type plans.
+func WithPlanCache(cache *PlanCache) Option {
+	return func(finder *DuplicateFinder) {
+		finder.plans = cache
+	}
+}
+
+// WithRetainValues sets RetainValues.
+func WithRetainValues() Option {
+	return func(finder *DuplicateFinder) {
+		finder.RetainValues = true
+	}
+}
+
+// WithRawAddresses sets RawAddresses.
+func WithRawAddresses() Option {
+	return func(finder *DuplicateFinder) {
+		finder.RawAddresses = true
+	}
+}
+
+// WithIgnoreEmbeddedAliases sets IgnoreEmbeddedAliases.
+func WithIgnoreEmbeddedAliases() Option {
+	return func(finder *DuplicateFinder) {
+		finder.IgnoreEmbeddedAliases = true
+	}
+}
+
+// WithArraySliceAliases sets MatchArraySliceAliases.
+func WithArraySliceAliases() Option {
+	return func(finder *DuplicateFinder) {
+		finder.MatchArraySliceAliases = true
+	}
+}
+
+// WithSliceExtents sets RecordSliceExtents.
+func WithSliceExtents() Option {
+	return func(finder *DuplicateFinder) {
+		finder.RecordSliceExtents = true
+	}
+}
+
+// WithPaths sets RecordPaths.
+func WithPaths() Option {
+	return func(finder *DuplicateFinder) {
+		finder.RecordPaths = true
+	}
+}
+
+// WithFieldStats sets RecordFieldStats.
+func WithFieldStats() Option {
+	return func(finder *DuplicateFinder) {
+		finder.RecordFieldStats = true
+	}
+}
+
+// WithContentIdentity sets IdentifyByContent.
+func WithContentIdentity() Option {
+	return func(finder *DuplicateFinder) {
+		finder.IdentifyByContent = true
+	}
+}
+
+// WithZeroSizedPointers sets ZeroSizedPointers.
+func WithZeroSizedPointers(policy ZeroSizePolicy) Option {
+	return func(finder *DuplicateFinder) {
+		finder.ZeroSizedPointers = policy
+	}
+}
+
+// WithSortedMapKeys sets SortMapKeys.
+func WithSortedMapKeys() Option {
+	return func(finder *DuplicateFinder) {
+		finder.SortMapKeys = true
+	}
+}
+
+// WithSkipPanickingValues sets SkipPanickingValues.
+func WithSkipPanickingValues() Option {
+	return func(finder *DuplicateFinder) {
+		finder.SkipPanickingValues = true
+	}
+}
+
+// WithLeafInterface calls TreatImplementersAsLeaf.
+func WithLeafInterface(ifaceType reflect.Type) Option {
+	return func(finder *DuplicateFinder) {
+		finder.TreatImplementersAsLeaf(ifaceType)
+	}
+}
+
+// WithLeafPackages calls TreatPackagesAsLeaf.
+func WithLeafPackages(prefixes ...string) Option {
+	return func(finder *DuplicateFinder) {
+		finder.TreatPackagesAsLeaf(prefixes...)
+	}
+}
+
+// WithTypeHandler calls RegisterTypeHandler.
+func WithTypeHandler(t reflect.Type, handler TypeHandler) Option {
+	return func(finder *DuplicateFinder) {
+		finder.RegisterTypeHandler(t, handler)
+	}
+}
+
+// WithAllowedSharedPointers calls AllowSharedPointers.
+func WithAllowedSharedPointers(pointers ...interface{}) Option {
+	return func(finder *DuplicateFinder) {
+		finder.AllowSharedPointers(pointers...)
+	}
+}
+
+// WithAllowedSharedTypes calls AllowSharedTypes.
+func WithAllowedSharedTypes(types ...reflect.Type) Option {
+	return func(finder *DuplicateFinder) {
+		finder.AllowSharedTypes(types...)
+	}
+}
+
+// WithSkipTypes calls SkipTypes.
+func WithSkipTypes(types ...reflect.Type) Option {
+	return func(finder *DuplicateFinder) {
+		finder.SkipTypes(types...)
+	}
+}
+
+// WithRestrictToTypes calls RestrictToTypes.
+func WithRestrictToTypes(types ...reflect.Type) Option {
+	return func(finder *DuplicateFinder) {
+		finder.RestrictToTypes(types...)
+	}
+}
+
+// WithMaxDepth sets MaxDepth.
+func WithMaxDepth(maxDepth int) Option {
+	return func(finder *DuplicateFinder) {
+		finder.MaxDepth = maxDepth
+	}
+}
+
+// WithMaxNodes sets MaxNodes.
+func WithMaxNodes(maxNodes int) Option {
+	return func(finder *DuplicateFinder) {
+		finder.MaxNodes = maxNodes
+	}
+}
+
+// WithMaxElements sets MaxElements.
+func WithMaxElements(maxElements int) Option {
+	return func(finder *DuplicateFinder) {
+		finder.MaxElements = maxElements
+	}
+}
+
+// WithMaxDuration sets MaxDuration.
+func WithMaxDuration(maxDuration time.Duration) Option {
+	return func(finder *DuplicateFinder) {
+		finder.MaxDuration = maxDuration
+	}
+}
+
+// WithProgress sets OnProgress and ProgressInterval.
+func WithProgress(interval int, callback func(progress Progress)) Option {
+	return func(finder *DuplicateFinder) {
+		finder.ProgressInterval = interval
+		finder.OnProgress = callback
+	}
+}
+
+// WithOnPointer sets OnPointer.
+func WithOnPointer(callback func(ptr TypedPointer, path Path, duplicate bool) VisitAction) Option {
+	return func(finder *DuplicateFinder) {
+		finder.OnPointer = callback
+	}
+}
+
+// WithTagName sets TagName.
+func WithTagName(name string) Option {
+	return func(finder *DuplicateFinder) {
+		finder.TagName = name
+	}
+}
+
+// WithFieldFilter sets FieldFilter.
+func WithFieldFilter(filter func(field reflect.StructField, path Path) bool) Option {
+	return func(finder *DuplicateFinder) {
+		finder.FieldFilter = filter
+	}
+}
+
+// WithSkipUnexportedFields sets SkipUnexportedFields.
+func WithSkipUnexportedFields() Option {
+	return func(finder *DuplicateFinder) {
+		finder.SkipUnexportedFields = true
+	}
+}
+
+// WithUnsafeReads sets UnsafeReads.
+func WithUnsafeReads() Option {
+	return func(finder *DuplicateFinder) {
+		finder.UnsafeReads = true
+	}
+}
+
+// WithMapKeys sets ScanMapKeys.
+func WithMapKeys() Option {
+	return func(finder *DuplicateFinder) {
+		finder.ScanMapKeys = true
+	}
+}
+
+// WithChannels sets RegisterChannels.
+func WithChannels() Option {
+	return func(finder *DuplicateFinder) {
+		finder.RegisterChannels = true
+	}
+}
+
+// WithFuncs sets RegisterFuncs.
+func WithFuncs() Option {
+	return func(finder *DuplicateFinder) {
+		finder.RegisterFuncs = true
+	}
+}
+
+// WithUnsafePointers sets RegisterUnsafePointers.
+func WithUnsafePointers() Option {
+	return func(finder *DuplicateFinder) {
+		finder.RegisterUnsafePointers = true
+	}
+}
+
+// WithEmptyContainers sets RegisterEmptyContainers.
+func WithEmptyContainers() Option {
+	return func(finder *DuplicateFinder) {
+		finder.RegisterEmptyContainers = true
+	}
+}
+
+// WithOrder sets Order.
+func WithOrder(order TraversalOrder) Option {
+	return func(finder *DuplicateFinder) {
+		finder.Order = order
+	}
+}
+
+// WithRevisit sets Revisit.
+func WithRevisit(policy RevisitPolicy) Option {
+	return func(finder *DuplicateFinder) {
+		finder.Revisit = policy
+	}
+}
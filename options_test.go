@@ -0,0 +1,40 @@
+package duplicates
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestNewDuplicateFinderOptions(t *testing.T) {
+	cache := NewPlanCache()
+	finder := NewDuplicateFinder(
+		WithCapacity(50),
+		WithPlanCache(cache),
+		WithRetainValues(),
+		WithIgnoreEmbeddedAliases(),
+		WithArraySliceAliases(),
+		WithPaths(),
+		WithFieldStats(),
+		WithContentIdentity(),
+		WithZeroSizedPointers(ZeroSizeGroup),
+		WithSortedMapKeys(),
+		WithSkipPanickingValues(),
+		WithLeafInterface(reflect.TypeOf((*fmt.Stringer)(nil)).Elem()),
+	)
+
+	if !finder.RetainValues || !finder.IgnoreEmbeddedAliases || !finder.MatchArraySliceAliases ||
+		!finder.RecordPaths || !finder.RecordFieldStats || !finder.IdentifyByContent ||
+		finder.ZeroSizedPointers != ZeroSizeGroup || !finder.SortMapKeys || !finder.SkipPanickingValues {
+		t.Errorf("Expected all options to be set")
+	}
+	if cap(finder.records) < 50 || finder.plans != cache || len(finder.leafInterfaces) != 1 {
+		t.Errorf("Expected the capacity, plan cache, and leaf interface to be set")
+	}
+
+	v := 1
+	finder.ScanForPointers([]*int{&v, &v})
+	if !finder.IsDuplicatePointer(&v) {
+		t.Errorf("Expected a configured finder to find duplicates")
+	}
+}
@@ -0,0 +1,34 @@
+package duplicates
+
+// DefinitionOrder scans value and returns its duplicates (shared objects) in
+// an order where every shared object comes after all of the shared objects it
+// refers to, directly or indirectly. This suits encoders that emit each
+// shared object's definition before any reference to it.
+//
+// Shared objects that are part of a reference cycle can't be fully ordered
+// this way, and are flagged in cyclic. Each cycle's shared objects are kept
+// together in discovery order, after everything the cycle refers to.
+func DefinitionOrder(value interface{}) (order []TypedPointer, cyclic map[TypedPointer]bool) {
+	finder, edges := scanReferenceEdges(value, false, false)
+	selfReferring := make(map[int]bool)
+	for _, edge := range edges {
+		if edge.from == edge.to {
+			selfReferring[edge.from] = true
+		}
+	}
+
+	cyclic = make(map[TypedPointer]bool)
+	for _, component := range tarjan(adjacency(len(finder.records), edges, nil)) {
+		isCyclic := len(component) > 1 || selfReferring[component[0]]
+		for _, ptr := range finder.pointersOf(component) {
+			if !finder.DuplicatePointers[ptr] {
+				continue
+			}
+			order = append(order, ptr)
+			if isCyclic {
+				cyclic[ptr] = true
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,40 @@
+package duplicates
+
+import (
+	"testing"
+)
+
+type orderNode struct {
+	Name string
+	Refs []*orderNode
+}
+
+func TestDefinitionOrder(t *testing.T) {
+	leaf := &orderNode{Name: "leaf"}
+	middle := &orderNode{Name: "middle", Refs: []*orderNode{leaf}}
+	a := &orderNode{Name: "a"}
+	b := &orderNode{Name: "b", Refs: []*orderNode{a, middle}}
+	a.Refs = []*orderNode{b}
+	root := []*orderNode{a, middle, leaf, b}
+
+	order, cyclic := DefinitionOrder(root)
+	position := make(map[*orderNode]int)
+	for i, ptr := range order {
+		for _, node := range []*orderNode{leaf, middle, a, b} {
+			if ptr == TypedPointerOf(node) {
+				position[node] = i + 1
+			}
+		}
+	}
+	for _, node := range []*orderNode{leaf, middle, a, b} {
+		if position[node] == 0 {
+			t.Fatalf("Expected %v to be ordered but got %v", node.Name, order)
+		}
+	}
+	if position[leaf] > position[middle] || position[middle] > position[a] || position[middle] > position[b] {
+		t.Errorf("Expected leaf, then middle, then the cycle but got %v", order)
+	}
+	if !cyclic[TypedPointerOf(a)] || !cyclic[TypedPointerOf(b)] || cyclic[TypedPointerOf(middle)] || cyclic[TypedPointerOf(leaf)] {
+		t.Errorf("Expected only a and b to be flagged as cyclic but got %v", cyclic)
+	}
+}
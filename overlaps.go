@@ -0,0 +1,142 @@
+package duplicates
+
+import (
+	"reflect"
+)
+
+// OverlapKind describes how two pointers of different types to the same
+// address are related.
+type OverlapKind int
+
+const (
+	// The inner object is the embedded (anonymous) first field of the outer
+	// struct, possibly through further embedded first fields. This is how Go
+	// lays out embedding, and is normally not sharing at all.
+	OverlapEmbedded OverlapKind = iota
+	// The inner object is the first field of the outer struct (at any depth),
+	// and at least one of the fields along the way is a named field.
+	OverlapFirstField
+	// The inner object is the first element of the outer array or slice (at
+	// any depth, possibly through first fields).
+	OverlapFirstElement
+	// The objects aren't nested, meaning that the same memory was found as
+	// unrelated types (which usually means that it was converted using
+	// unsafe, or that a zero-sized object ends where another one starts).
+	OverlapUnrelated
+)
+
+func (_this OverlapKind) String() string {
+	switch _this {
+	case OverlapEmbedded:
+		return "Embedded"
+	case OverlapFirstField:
+		return "FirstField"
+	case OverlapFirstElement:
+		return "FirstElement"
+	case OverlapUnrelated:
+		return "Unrelated"
+	default:
+		return "OverlapKind(?)"
+	}
+}
+
+// PointerOverlap is a pair of registered pointers of different types to the
+// same address.
+type PointerOverlap struct {
+	// The pointer to the enclosing object, and the pointer to the object at
+	// its start. If the objects are unrelated, Outer is simply the one that
+	// was found first.
+	Outer TypedPointer
+	Inner TypedPointer
+	Kind  OverlapKind
+}
+
+// OverlappingPointers returns every pair of registered pointers that have
+// the same address but different types, classified by how their objects are
+// related. Pairs are grouped by address in discovery order, and an address
+// where n types were found has a pair for each two of them.
+//
+// Since TypedPointer includes the type, such pointers are never confused
+// with each other (or reported as duplicates of each other). This lists them
+// for consumers that need to know which objects contain which.
+func (_this *DuplicateFinder) OverlappingPointers() (overlaps []PointerOverlap) {
+	for _, coincidence := range _this.addressCoincidences() {
+		types := coincidence.Types
+		for i := 0; i < len(types); i++ {
+			for j := i + 1; j < len(types); j++ {
+				a := coincidence.pointers[i]
+				b := coincidence.pointers[j]
+				if kind, ok := overlapKind(a.Type, b.Type); ok {
+					overlaps = append(overlaps, PointerOverlap{Outer: a, Inner: b, Kind: kind})
+				} else if kind, ok := overlapKind(b.Type, a.Type); ok {
+					overlaps = append(overlaps, PointerOverlap{Outer: b, Inner: a, Kind: kind})
+				} else {
+					overlaps = append(overlaps, PointerOverlap{Outer: a, Inner: b, Kind: OverlapUnrelated})
+				}
+			}
+		}
+	}
+	return
+}
+
+// Returns how the referent of pointer type inner lies at the start of the
+// referent of pointer type outer, if it does.
+func overlapKind(outer, inner reflect.Type) (kind OverlapKind, ok bool) {
+	if !isDataPointerKind(outer.Kind()) || !isDataPointerKind(inner.Kind()) {
+		return
+	}
+	outerReferent := outer.Elem()
+	innerReferent := inner.Elem()
+	if outer.Kind() == reflect.Slice {
+		// What a slice refers to is its first element.
+		kind, ok = referentOverlap(outerReferent, innerReferent)
+		return maxOverlap(kind, OverlapFirstElement), ok
+	}
+	if outerReferent == innerReferent {
+		// A slice isn't at the start of its own first element.
+		return
+	}
+	return referentOverlap(outerReferent, innerReferent)
+}
+
+func isDataPointerKind(kind reflect.Kind) bool {
+	return kind == reflect.Ptr || kind == reflect.Slice
+}
+
+// Returns how a value of type inner lies at the start of a value of type
+// outer, if it does. A type lies at the start of itself as OverlapEmbedded,
+// which is the least of the kinds.
+func referentOverlap(outer, inner reflect.Type) (kind OverlapKind, ok bool) {
+	if outer == inner {
+		return OverlapEmbedded, true
+	}
+	switch outer.Kind() {
+	case reflect.Struct:
+		for i := 0; i < outer.NumField(); i++ {
+			field := outer.Field(i)
+			if field.Offset != 0 {
+				break
+			}
+			if kind, ok = referentOverlap(field.Type, inner); ok {
+				if !field.Anonymous {
+					kind = maxOverlap(kind, OverlapFirstField)
+				}
+				return kind, true
+			}
+		}
+	case reflect.Array:
+		if outer.Len() > 0 {
+			if kind, ok = referentOverlap(outer.Elem(), inner); ok {
+				return maxOverlap(kind, OverlapFirstElement), true
+			}
+		}
+	}
+	return
+}
+
+func maxOverlap(a, b OverlapKind) OverlapKind {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,70 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+type overlapBase struct {
+	ID int
+}
+
+type overlapDerived struct {
+	overlapBase
+	Name string
+}
+
+type overlapNamed struct {
+	Base overlapBase
+}
+
+func describeOverlaps(overlaps []PointerOverlap) (described []string) {
+	for _, overlap := range overlaps {
+		described = append(described, overlap.Outer.Type.String()+" > "+overlap.Inner.Type.String()+": "+overlap.Kind.String())
+	}
+	return
+}
+
+func TestOverlappingPointers(t *testing.T) {
+	derived := &overlapDerived{}
+	named := &overlapNamed{}
+	elements := []overlapBase{{}, {}}
+	finder := NewDuplicateFinder()
+	finder.ScanForPointers([]interface{}{derived, named, elements, &elements[0]})
+
+	expected := []string{
+		"*duplicates.overlapDerived > *duplicates.overlapBase: Embedded",
+		"*duplicates.overlapDerived > *int: FirstField",
+		"*duplicates.overlapBase > *int: FirstField",
+		"*duplicates.overlapNamed > *duplicates.overlapBase: FirstField",
+		"*duplicates.overlapNamed > *int: FirstField",
+		"*duplicates.overlapBase > *int: FirstField",
+		"[]duplicates.overlapBase > *int: FirstElement",
+		"[]duplicates.overlapBase > *duplicates.overlapBase: FirstElement",
+		"*duplicates.overlapBase > *int: FirstField",
+	}
+	if actual := describeOverlaps(finder.OverlappingPointers()); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected\n%v\nbut got\n%v", expected, actual)
+	}
+}
+
+func TestOverlappingPointersUnrelated(t *testing.T) {
+	base := &overlapBase{}
+	other := (*float64)(unsafe.Pointer(base))
+	finder := NewDuplicateFinder()
+	finder.ScanForPointers([]interface{}{other, base})
+
+	overlaps := finder.OverlappingPointers()
+	expected := []string{
+		"*float64 > *duplicates.overlapBase: Unrelated",
+		"*float64 > *int: Unrelated",
+		"*duplicates.overlapBase > *int: FirstField",
+	}
+	if actual := describeOverlaps(overlaps); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v but got %v", expected, actual)
+	}
+	if overlaps[0].Outer != TypedPointerOf(other) || overlaps[0].Inner != TypedPointerOf(base) {
+		t.Errorf("Expected the pointers in discovery order but got %v", overlaps[0])
+	}
+}
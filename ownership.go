@@ -0,0 +1,84 @@
+package duplicates
+
+import (
+	"fmt"
+)
+
+// Rule descriptions for ownership violations.
+const (
+	RuleMultipleOwners = "owned object must have only one owner"
+	RuleSoleBorrower   = "borrowed reference must not be the only reference"
+)
+
+// OwnershipViolations scans value and checks the ownership annotations on its
+// pointer fields:
+//
+//	Parent *Node `duplicates:"borrowed"`
+//	Config *Config `duplicates:"owned"`
+//
+// A violation is reported for every object referenced by more than one
+// "owned" field, and for every object whose only reference is a "borrowed"
+// field.
+func OwnershipViolations(value interface{}) (violations []Violation) {
+	finder := NewDuplicateFinder()
+	finder.RecordPaths = true
+
+	type ownership struct {
+		owners       int
+		secondOwner  int32
+		borrowers    int
+		borrowerNode int32
+	}
+	ownerships := make(map[int]*ownership)
+
+	finder.sightingHook = func(index int) {
+		referrer := finder.referrer
+		if referrer.container == nil {
+			return
+		}
+		tags := finder.plans.planFor(referrer.container).fieldTags[referrer.index]
+		if !tags.owned && !tags.borrowed {
+			return
+		}
+		o := ownerships[index]
+		if o == nil {
+			o = &ownership{}
+			ownerships[index] = o
+		}
+		if tags.owned {
+			o.owners++
+			if o.owners == 2 {
+				o.secondOwner = finder.pathNode
+			}
+		}
+		if tags.borrowed {
+			o.borrowers++
+			o.borrowerNode = finder.pathNode
+		}
+	}
+	finder.ScanForPointers(value)
+
+	for index, record := range finder.records {
+		o := ownerships[index]
+		if o == nil {
+			continue
+		}
+		if o.owners > 1 {
+			violations = append(violations, Violation{
+				Rule:    RuleMultipleOwners,
+				Pointer: record.pointer,
+				Path:    finder.pathAt(o.secondOwner),
+				Message: fmt.Sprintf("%v is owned by %v fields", record.pointer, o.owners),
+			})
+		}
+		if o.borrowers == 1 && record.sightings == 1 {
+			violations = append(violations, Violation{
+				Rule:    RuleSoleBorrower,
+				Pointer: record.pointer,
+				Path:    finder.pathAt(o.borrowerNode),
+				Message: fmt.Sprintf("%v is only referenced by a borrowed field", record.pointer),
+			})
+		}
+	}
+	return
+}
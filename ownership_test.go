@@ -0,0 +1,55 @@
+package duplicates
+
+import (
+	"testing"
+)
+
+type ownershipConfig struct {
+	Name string
+}
+
+type ownershipService struct {
+	Config *ownershipConfig  `duplicates:"owned"`
+	Parent *ownershipService `duplicates:"borrowed"`
+	Plain  *ownershipConfig
+}
+
+func TestOwnershipViolationsNone(t *testing.T) {
+	root := &ownershipService{Config: &ownershipConfig{}}
+	child := &ownershipService{Config: &ownershipConfig{}, Parent: root}
+	if violations := OwnershipViolations([]*ownershipService{root, child}); len(violations) != 0 {
+		t.Errorf("Expected no violations but got %v", violations)
+	}
+}
+
+func TestOwnershipViolationsMultipleOwners(t *testing.T) {
+	config := &ownershipConfig{}
+	services := []*ownershipService{{Config: config}, {Config: config}, {Plain: config}}
+
+	violations := OwnershipViolations(services)
+	if len(violations) != 1 {
+		t.Fatalf("Expected 1 violation but got %v", violations)
+	}
+	if violations[0].Rule != RuleMultipleOwners || violations[0].Pointer != TypedPointerOf(config) {
+		t.Errorf("Unexpected violation %v", violations[0])
+	}
+	if violations[0].Path.String() != "[1].Config" {
+		t.Errorf("Expected path [1].Config but got %v", violations[0].Path)
+	}
+}
+
+func TestOwnershipViolationsSoleBorrower(t *testing.T) {
+	orphan := &ownershipService{}
+	child := &ownershipService{Parent: orphan}
+
+	violations := OwnershipViolations(child)
+	if len(violations) != 1 {
+		t.Fatalf("Expected 1 violation but got %v", violations)
+	}
+	if violations[0].Rule != RuleSoleBorrower || violations[0].Pointer != TypedPointerOf(orphan) {
+		t.Errorf("Unexpected violation %v", violations[0])
+	}
+	if violations[0].Path.String() != ".Parent" {
+		t.Errorf("Expected path .Parent but got %v", violations[0].Path)
+	}
+}
@@ -0,0 +1,159 @@
+package duplicates
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+// ScanError describes a panic that occurred while scanning, and where in the
+// scanned object it happened.
+type ScanError struct {
+	// The index of the root being scanned (counting every root scanned by
+	// the finder).
+	Root int
+	// The steps from the root to the value being scanned when the panic
+	// occurred.
+	Steps []PathStep
+	// The value that was recovered from the panic.
+	Recovered interface{}
+}
+
+// Path renders the steps to the value that panicked, for example
+// ".Handlers[2].Callback".
+func (_this *ScanError) Path() string {
+	builder := strings.Builder{}
+	for _, step := range _this.Steps {
+		builder.WriteString(step.String())
+	}
+	return builder.String()
+}
+
+func (_this *ScanError) Error() string {
+	path := _this.Path()
+	if path == "" {
+		path = "(root)"
+	}
+	return fmt.Sprintf("duplicates: panic while scanning root %v at %v: %v", _this.Root, path, _this.Recovered)
+}
+
+// Unwrap returns the recovered panic value if it was an error.
+func (_this *ScanError) Unwrap() error {
+	err, _ := _this.Recovered.(error)
+	return err
+}
+
+// ScanErrors holds every panic that was skipped over during a scan with
+// SkipPanickingValues set.
+type ScanErrors []*ScanError
+
+func (_this ScanErrors) Error() string {
+	if len(_this) == 1 {
+		return _this[0].Error()
+	}
+	return fmt.Sprintf("%v (and %v more)", _this[0].Error(), len(_this)-1)
+}
+
+// TryScanForPointers works like ScanForPointers, except that any panic during
+// the scan is recovered and returned as a *ScanError identifying where it
+// happened. Everything registered up to that point is kept.
+//
+// If SkipPanickingValues is set, the value that panicked is skipped and the
+// scan carries on, and all recovered panics are returned as ScanErrors.
+func (_this *DuplicateFinder) TryScanForPointers(object interface{}) (err error) {
+	rootIndex := _this.rootCount
+	_this.rootCount++
+	_this.containing = true
+	_this.steps = _this.steps[:0]
+	_this.scanErrors = nil
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = _this.newScanError(recovered)
+			_this.discardFrames(0)
+			_this.unwindReferents(0)
+			_this.upgrading = false
+		} else if len(_this.scanErrors) > 0 {
+			err = ScanErrors(_this.scanErrors)
+		}
+		_this.containing = false
+		_this.scanErrors = nil
+	}()
+	_this.scanRoot(reflect.ValueOf(object), rootIndex)
+	return
+}
+
+// The scan state at the start of a value scanned with SkipPanickingValues, to
+// restore if scanning the value panics.
+type containment struct {
+	// The index of the value's opContained frame.
+	frame          int
+	depth          int
+	pathNode       int32
+	referrer       fieldRef
+	referrerParent unsafe.Pointer
+	exported       bool
+	stepCount      int
+	referentCount  int
+}
+
+// Marks the start of a value whose scanning is skipped if it panics.
+func (_this *DuplicateFinder) pushContainment() {
+	_this.containments = append(_this.containments, containment{
+		frame:          len(_this.frames),
+		depth:          _this.depth,
+		pathNode:       _this.pathNode,
+		referrer:       _this.referrer,
+		referrerParent: _this.referrerParent,
+		exported:       _this.exported,
+		stepCount:      len(_this.steps),
+		referentCount:  len(_this.referents),
+	})
+	_this.pushFrame(scanFrame{op: opContained})
+}
+
+// Does the next piece of work of the innermost frame, and if that panics,
+// records the panic and skips to the end of the innermost contained value so
+// that scanning can continue with the next value. Panics that aren't within a
+// contained value above base are passed on.
+func (_this *DuplicateFinder) stepContained(base int) {
+	defer func() {
+		if _this.stopping {
+			// Let the scan stop.
+			return
+		}
+		if recovered := recover(); recovered != nil {
+			last := len(_this.containments) - 1
+			if last < 0 || _this.containments[last].frame < base {
+				panic(recovered)
+			}
+			contained := _this.containments[last]
+			_this.scanErrors = append(_this.scanErrors, _this.newScanError(recovered))
+			_this.discardFrames(contained.frame)
+			_this.unwindReferents(contained.referentCount)
+			_this.depth = contained.depth
+			_this.pathNode = contained.pathNode
+			_this.referrer = contained.referrer
+			_this.referrerParent = contained.referrerParent
+			_this.exported = contained.exported
+			_this.steps = _this.steps[:contained.stepCount]
+		}
+	}()
+	_this.stepFrame()
+}
+
+func (_this *DuplicateFinder) newScanError(recovered interface{}) *ScanError {
+	return &ScanError{
+		Root:      _this.rootIndex,
+		Steps:     append([]PathStep(nil), _this.steps...),
+		Recovered: recovered,
+	}
+}
+
+// Ends referents until only count remain, after a panic skipped their
+// endReferent calls.
+func (_this *DuplicateFinder) unwindReferents(count int) {
+	for len(_this.referents) > count {
+		_this.endReferent()
+	}
+}
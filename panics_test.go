@@ -0,0 +1,88 @@
+package duplicates
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type panicTestItem struct {
+	Value *string
+	Bad   *float64
+}
+
+type panicTestRoot struct {
+	Items []panicTestItem
+	Tail  *string
+}
+
+func newPanicTestFinder(items int) (*DuplicateFinder, *panicTestRoot) {
+	shared := "shared"
+	bad := 1.0
+	root := &panicTestRoot{Tail: &shared}
+	for i := 0; i < items; i++ {
+		root.Items = append(root.Items, panicTestItem{Value: &shared})
+	}
+	root.Items[1].Bad = &bad
+
+	finder := NewDuplicateFinder()
+	finder.sightingHook = func(index int) {
+		if finder.records[index].pointer.Type == reflect.TypeOf(&bad) {
+			panic("bad value")
+		}
+	}
+	return finder, root
+}
+
+func TestTryScanForPointersAborts(t *testing.T) {
+	finder, root := newPanicTestFinder(3)
+	err := finder.TryScanForPointers(root)
+	scanErr, ok := err.(*ScanError)
+	if !ok {
+		t.Fatalf("Expected a *ScanError but got %v", err)
+	}
+	if scanErr.Path() != ".Items[1].Bad" {
+		t.Errorf("Expected path .Items[1].Bad but got %v", scanErr.Path())
+	}
+	if !strings.Contains(scanErr.Error(), "bad value") {
+		t.Errorf("Expected the panic value in %q", scanErr.Error())
+	}
+	if !finder.IsDuplicatePointer(root.Tail) {
+		t.Errorf("Expected results found before the panic to be kept")
+	}
+	for _, record := range finder.records {
+		if record.scanning {
+			t.Errorf("Expected %v to no longer be marked as scanning", record.pointer)
+		}
+	}
+}
+
+func TestTryScanForPointersSkips(t *testing.T) {
+	finder, root := newPanicTestFinder(4)
+	finder.SkipPanickingValues = true
+	err := finder.TryScanForPointers(root)
+	scanErrs, ok := err.(ScanErrors)
+	if !ok || len(scanErrs) != 1 {
+		t.Fatalf("Expected a single skipped value but got %v", err)
+	}
+	if scanErrs[0].Path() != ".Items[1].Bad" {
+		t.Errorf("Expected path .Items[1].Bad but got %v", scanErrs[0].Path())
+	}
+	if _, ok := finder.recordIndex[TypedPointerOf(&root.Items[3].Value)]; !ok {
+		t.Errorf("Expected the scan to continue past the panicking value")
+	}
+	if !finder.IsDuplicatePointer(root.Tail) {
+		t.Errorf("Expected the scan to reach the Tail field")
+	}
+}
+
+func TestTryScanForPointersNoPanic(t *testing.T) {
+	v := 1
+	finder := NewDuplicateFinder()
+	if err := finder.TryScanForPointers([]*int{&v, &v}); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+	if !finder.IsDuplicatePointer(&v) {
+		t.Errorf("Expected &v to be a duplicate")
+	}
+}
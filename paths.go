@@ -0,0 +1,222 @@
+package duplicates
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PathStep is a single step from a value to one of its children. Steps are
+// index-based so that recording them doesn't allocate; names are only looked
+// up when the path is rendered.
+type PathStep struct {
+	// Kind uses the same step kinds as TypePath.
+	Kind TypePathStepKind
+	// The field index (StepField) or element index (StepElem).
+	Index int
+	// The struct type containing the field (StepField), or the dynamic type
+	// of the interface's value (StepTypeAssertion).
+	Container reflect.Type
+	// The map key (StepMapKey and StepMapValue).
+	Key reflect.Value
+}
+
+// FieldName returns the name of the field this step refers to, or "" if this
+// is not a StepField step.
+func (_this PathStep) FieldName() string {
+	if _this.Kind != StepField {
+		return ""
+	}
+	return defaultPlanCache.planFor(_this.Container).fieldNames[_this.Index]
+}
+
+func (_this PathStep) String() string {
+	switch _this.Kind {
+	case StepField:
+		return "." + _this.FieldName()
+	case StepElem:
+		return "[" + strconv.Itoa(_this.Index) + "]"
+	case StepMapValue:
+		return "[" + formatMapKey(_this.Key) + "]"
+	case StepMapKey:
+		return "{" + formatMapKey(_this.Key) + "}"
+	default:
+		// Pointer dereferences and interfaces are implicit, like in Go
+		// selectors.
+		return ""
+	}
+}
+
+func formatMapKey(key reflect.Value) string {
+	switch key.Kind() {
+	case reflect.String:
+		return strconv.Quote(key.String())
+	case reflect.Interface:
+		if !key.IsNil() {
+			return formatMapKey(key.Elem())
+		}
+	}
+	if key.CanInterface() {
+		return fmt.Sprint(key.Interface())
+	}
+	return key.Type().String()
+}
+
+const noPathNode int32 = -1
+
+type pathNode struct {
+	parent int32
+	step   PathStep
+}
+
+func (_this *DuplicateFinder) appendPathNode(parent int32, step PathStep) int32 {
+	_this.pathNodes = append(_this.pathNodes, pathNode{parent: parent, step: step})
+	return int32(len(_this.pathNodes) - 1)
+}
+
+// Path is the route from a scanned root to a value. Paths are stored as links
+// into a shared arena, and are only converted to steps or strings on demand.
+type Path struct {
+	nodes []pathNode
+	node  int32
+}
+
+func (_this *DuplicateFinder) pathAt(node int32) Path {
+	return Path{nodes: _this.pathNodes, node: node}
+}
+
+// Len returns the number of steps in the path.
+func (_this Path) Len() int {
+	count := 0
+	for node := _this.node; node != noPathNode; node = _this.nodes[node].parent {
+		count++
+	}
+	return count
+}
+
+// Steps returns the steps of the path, from the root onwards.
+func (_this Path) Steps() []PathStep {
+	steps := make([]PathStep, _this.Len())
+	i := len(steps) - 1
+	for node := _this.node; node != noPathNode; node = _this.nodes[node].parent {
+		steps[i] = _this.nodes[node].step
+		i--
+	}
+	return steps
+}
+
+// String renders the path relative to its root, for example
+// ".Employees[3].Manager".
+func (_this Path) String() string {
+	builder := strings.Builder{}
+	for _, step := range _this.Steps() {
+		builder.WriteString(step.String())
+	}
+	return builder.String()
+}
+
+// StringFrom renders the path starting from a root with the given name, for
+// example "Root.Employees[3].Manager".
+func (_this Path) StringFrom(root string) string {
+	return root + _this.String()
+}
+
+// GoExpression renders the path as a Go expression accessing the value,
+// relative to a variable named root. For example, "root.Servers[2].TLSConfig"
+// or "root.Handler.(*server.Handler).Name". Map keys, which can't be reached
+// by an expression, are rendered as "{key}".
+func (_this Path) GoExpression(root string) string {
+	expression := root
+	steps := _this.Steps()
+	for i, step := range steps {
+		switch step.Kind {
+		case StepField:
+			expression += "." + step.FieldName()
+		case StepElem:
+			expression += "[" + strconv.Itoa(step.Index) + "]"
+		case StepMapValue:
+			expression += "[" + goMapKey(step.Key) + "]"
+		case StepMapKey:
+			// Keys can't be accessed by an expression, so this is only a hint.
+			expression += "{" + goMapKey(step.Key) + "}"
+		case StepTypeAssertion:
+			expression += ".(" + step.Container.String() + ")"
+		case StepPointerElem:
+			// Selectors dereference pointers implicitly, but nothing else does.
+			if i+1 == len(steps) {
+				expression = "*" + expression
+			} else if steps[i+1].Kind != StepField {
+				expression = "(*" + expression + ")"
+			}
+		}
+	}
+	return expression
+}
+
+func goMapKey(key reflect.Value) string {
+	if key.Kind() == reflect.Interface && !key.IsNil() {
+		key = key.Elem()
+	}
+	switch key.Kind() {
+	case reflect.String:
+		return strconv.Quote(key.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10)
+	case reflect.Bool:
+		return strconv.FormatBool(key.Bool())
+	}
+	if key.CanInterface() {
+		return fmt.Sprintf("%#v", key.Interface())
+	}
+	return formatMapKey(key)
+}
+
+// PathTo returns the path at which ptr was first found. This only works if the
+// finder had RecordPaths set while scanning.
+func (_this *DuplicateFinder) PathTo(ptr TypedPointer) (path Path, ok bool) {
+	if !_this.RecordPaths {
+		return
+	}
+	index, ok := _this.recordIndex[_this.identify(ptr)]
+	if !ok {
+		return
+	}
+	return _this.pathAt(_this.pathsOfRecord(index).first), true
+}
+
+// RepeatPathTo returns the path at which ptr was found for the second time,
+// which together with PathTo shows where the sharing is. This only works if
+// the finder had RecordPaths set while scanning, and ptr is a duplicate.
+func (_this *DuplicateFinder) RepeatPathTo(ptr TypedPointer) (path Path, ok bool) {
+	if !_this.RecordPaths {
+		return
+	}
+	index, ok := _this.recordIndex[_this.identify(ptr)]
+	if !ok || _this.records[index].sightings < 2 {
+		return path, false
+	}
+	return _this.pathAt(_this.pathsOfRecord(index).repeat), true
+}
+
+// Explain returns the paths at which the duplicate ptr was found: the path at
+// which it was first found, followed by every later one. This only works if
+// the finder had RecordPaths set while scanning. Duplicates that are only
+// duplicates by aliasing (see MatchArraySliceAliases) may have been found at
+// a single path.
+func (_this *DuplicateFinder) Explain(ptr TypedPointer) (paths []Path) {
+	ptr = _this.identify(ptr)
+	if !_this.RecordPaths || !_this.DuplicatePointers[ptr] {
+		return
+	}
+	index := _this.recordIndex[ptr]
+	paths = append(paths, _this.pathAt(_this.pathsOfRecord(index).first))
+	for _, sighting := range _this.repeatSightings {
+		if sighting.record == index {
+			paths = append(paths, _this.pathAt(sighting.pathNode))
+		}
+	}
+	return
+}
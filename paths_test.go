@@ -0,0 +1,189 @@
+package duplicates
+
+import (
+	"testing"
+)
+
+type pathEmployee struct {
+	Name    string
+	Manager *pathEmployee
+}
+
+type pathCompany struct {
+	Employees []*pathEmployee
+	ByName    map[string]*pathEmployee
+}
+
+func TestPathTo(t *testing.T) {
+	boss := &pathEmployee{Name: "boss"}
+	worker := &pathEmployee{Name: "worker", Manager: boss}
+	company := &pathCompany{
+		Employees: []*pathEmployee{boss, worker},
+		ByName:    map[string]*pathEmployee{"w": worker},
+	}
+
+	finder := NewDuplicateFinder()
+	finder.RecordPaths = true
+	finder.ScanForPointers(company)
+
+	expected := map[interface{}]string{
+		company:         "",
+		boss:            ".Employees[0]",
+		worker:          ".Employees[1]",
+		&worker.Name:    ".Employees[1].Name",
+		&company.ByName: ".ByName",
+	}
+	for ptr, expectedPath := range expected {
+		path, ok := finder.PathTo(TypedPointerOf(ptr))
+		if !ok {
+			t.Errorf("Expected a path to %v", expectedPath)
+			continue
+		}
+		if path.String() != expectedPath {
+			t.Errorf("Expected path %v but got %v", expectedPath, path)
+		}
+	}
+}
+
+func TestRepeatPathTo(t *testing.T) {
+	boss := &pathEmployee{Name: "boss"}
+	worker := &pathEmployee{Name: "worker", Manager: boss}
+	company := &pathCompany{
+		Employees: []*pathEmployee{boss, worker},
+		ByName:    map[string]*pathEmployee{"w": worker},
+	}
+
+	finder := NewDuplicateFinder()
+	finder.RecordPaths = true
+	finder.ScanForPointers(company)
+
+	expected := map[*pathEmployee]string{
+		boss:   ".Employees[1].Manager",
+		worker: `.ByName["w"]`,
+	}
+	for ptr, expectedPath := range expected {
+		path, ok := finder.RepeatPathTo(TypedPointerOf(ptr))
+		if !ok || path.String() != expectedPath {
+			t.Errorf("Expected repeat path %v but got %v (%v)", expectedPath, path, ok)
+		}
+	}
+	if _, ok := finder.RepeatPathTo(TypedPointerOf(company)); ok {
+		t.Errorf("Expected no repeat path for a pointer that was only found once")
+	}
+
+	report := finder.Report()
+	if path, ok := report.RepeatPathTo(TypedPointerOf(boss)); !ok || path.String() != ".Employees[1].Manager" {
+		t.Errorf("Expected the report's repeat path to be .Employees[1].Manager but got %v", path)
+	}
+}
+
+func TestPathStringFrom(t *testing.T) {
+	boss := &pathEmployee{Name: "boss"}
+	company := &pathCompany{Employees: []*pathEmployee{{Manager: boss}}}
+
+	finder := NewDuplicateFinder()
+	finder.RecordPaths = true
+	finder.ScanForPointers(company)
+
+	path, _ := finder.PathTo(TypedPointerOf(boss))
+	if actual := path.StringFrom("Root"); actual != "Root.Employees[0].Manager" {
+		t.Errorf("Expected Root.Employees[0].Manager but got %v", actual)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	boss := &pathEmployee{Name: "boss"}
+	company := &pathCompany{
+		Employees: []*pathEmployee{boss, {Manager: boss}, {Manager: boss}},
+		ByName:    map[string]*pathEmployee{"boss": boss},
+	}
+
+	finder := NewDuplicateFinder()
+	finder.RecordPaths = true
+	finder.ScanForPointers(company)
+
+	actual := []string{}
+	for _, path := range finder.Explain(TypedPointerOf(boss)) {
+		actual = append(actual, path.String())
+	}
+	expected := []string{".Employees[0]", ".Employees[1].Manager", ".Employees[2].Manager", `.ByName["boss"]`}
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected %v but got %v", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("Expected %v but got %v", expected, actual)
+			break
+		}
+	}
+	if paths := finder.Explain(TypedPointerOf(company)); len(paths) != 0 {
+		t.Errorf("Expected no explanation for a pointer that isn't a duplicate")
+	}
+}
+
+func TestPathToMapKey(t *testing.T) {
+	v := 1
+	finder := NewDuplicateFinder()
+	finder.RecordPaths = true
+	finder.ScanForPointers(map[string]*int{"key": &v})
+
+	path, _ := finder.PathTo(TypedPointerOf(&v))
+	if path.String() != `["key"]` {
+		t.Errorf("Expected path [\"key\"] but got %v", path)
+	}
+}
+
+func TestPathToNotRecorded(t *testing.T) {
+	v := 1
+	finder := NewDuplicateFinder()
+	finder.ScanForPointers(&v)
+	if _, ok := finder.PathTo(TypedPointerOf(&v)); ok {
+		t.Errorf("Expected no path when paths aren't recorded")
+	}
+}
+
+type pathServer struct {
+	TLSConfig *string
+}
+
+type pathConfig struct {
+	Servers  []*pathServer
+	Handler  interface{}
+	Backends *[]*pathServer
+	ByID     map[int]*pathServer
+	Indirect **string
+}
+
+func TestPathGoExpression(t *testing.T) {
+	tls := "tls"
+	servers := []*pathServer{{}, {}, {TLSConfig: &tls}}
+	handler := &pathServer{}
+	backend := &pathServer{}
+	byID := &pathServer{}
+	indirect := new(string)
+	config := &pathConfig{
+		Servers:  servers,
+		Handler:  handler,
+		Backends: &[]*pathServer{backend},
+		ByID:     map[int]*pathServer{7: byID},
+		Indirect: &indirect,
+	}
+
+	finder := NewDuplicateFinder()
+	finder.RecordPaths = true
+	finder.ScanForPointers(config)
+
+	expected := map[interface{}]string{
+		&tls:               "root.Servers[2].TLSConfig",
+		&handler.TLSConfig: "root.Handler.(*duplicates.pathServer).TLSConfig",
+		backend:            "(*root.Backends)[0]",
+		byID:               "root.ByID[7]",
+		indirect:           "*root.Indirect",
+	}
+	for ptr, expectedExpression := range expected {
+		path, _ := finder.PathTo(TypedPointerOf(ptr))
+		if expression := path.GoExpression("root"); expression != expectedExpression {
+			t.Errorf("Expected %v but got %v", expectedExpression, expression)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package duplicates
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// PlanCache holds per-type scanning plans, which are expensive to compute
+// but never change. A PlanCache is safe for concurrent use, and can be shared
+// by any number of finders and sessions.
+type PlanCache struct {
+	plans sync.Map
+}
+
+func NewPlanCache() *PlanCache {
+	return &PlanCache{}
+}
+
+var defaultPlanCache = NewPlanCache()
+
+// NewSession creates a new named scan session that uses this plan cache.
+func (_this *PlanCache) NewSession(name string) *Session {
+	session := &Session{Name: name}
+	session.Init()
+	session.plans = _this
+	return session
+}
+
+// Session is a named, isolated scan. Each session keeps its own record of
+// visited pointers, but shares its per-type plans with every other session
+// created from the same PlanCache. This allows a server handling many
+// concurrent encodes to benefit from cached plans without any cross-request
+// contamination of results.
+//
+// A single Session must not be used concurrently.
+type Session struct {
+	DuplicateFinder
+	Name string
+}
+
+type typePlan struct {
+	// Whether the element type (of a pointer, slice, map, or array) can
+	// contain pointers.
+	elemScannable bool
+
+	// The kind of the element type (of a pointer, slice, map, or array).
+	elemKind reflect.Kind
+
+	// Whether the key type of a map can contain pointers.
+	keyScannable bool
+
+	// Indices of the struct fields that can contain pointers. Fields of
+	// addressable structs are always scanned because their addresses are
+	// registered.
+	scannableFields []int
+
+	// Indices of the struct fields that can contain pointers or hold a
+	// channel, func, or unsafe pointer.
+	referenceFields []int
+
+	// Whether each struct field is visible to encoders: exported, or an
+	// embedded struct whose exported fields are promoted.
+	fieldExported []bool
+
+	// The name of each struct field.
+	fieldNames []string
+
+	// The options in each struct field's "duplicates" tag.
+	fieldTags []tagOptions
+
+	// Whether the struct type, or a pointer to it, implements
+	// PointerEnumerable.
+	enumerable    bool
+	ptrEnumerable bool
+
+	// Which struct fields are excluded by their "duplicates" tag, or nil if
+	// none are.
+	fieldExcluded []bool
+}
+
+func (_this *PlanCache) planFor(t reflect.Type) *typePlan {
+	if plan, ok := _this.plans.Load(t); ok {
+		return plan.(*typePlan)
+	}
+	plan, _ := _this.plans.LoadOrStore(t, newTypePlan(t))
+	return plan.(*typePlan)
+}
+
+func newTypePlan(t reflect.Type) *typePlan {
+	plan := &typePlan{}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Array:
+		plan.elemScannable = isScannableKind(t.Elem().Kind())
+		plan.elemKind = t.Elem().Kind()
+		if t.Kind() == reflect.Map {
+			plan.keyScannable = isScannableKind(t.Key().Kind())
+		}
+	case reflect.Struct:
+		plan.fieldExported = make([]bool, t.NumField())
+		plan.fieldNames = make([]string, t.NumField())
+		plan.fieldTags = make([]tagOptions, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if isScannableKind(field.Type.Kind()) {
+				plan.scannableFields = append(plan.scannableFields, i)
+			}
+			if isScannableKind(field.Type.Kind()) || isReferentialKind(field.Type.Kind()) {
+				plan.referenceFields = append(plan.referenceFields, i)
+			}
+			plan.fieldExported[i] = isExportedField(field)
+			plan.fieldNames[i] = field.Name
+			plan.fieldTags[i] = parseTagOptions(field.Tag.Get(tagName))
+		}
+		plan.fieldExcluded = excludedFieldsByTag(t, tagName)
+		plan.enumerable = t.Implements(pointerEnumerableType)
+		plan.ptrEnumerable = !plan.enumerable && reflect.PtrTo(t).Implements(pointerEnumerableType)
+	}
+	return plan
+}
+
+func isExportedField(field reflect.StructField) bool {
+	if field.PkgPath == "" {
+		return true
+	}
+	if !field.Anonymous {
+		return false
+	}
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType.Kind() == reflect.Struct
+}
+
+const tagName = "duplicates"
+
+type tagOptions struct {
+	owned    bool
+	borrowed bool
+	// The field is tagged "-", and must not be scanned.
+	excluded bool
+}
+
+func parseTagOptions(tag string) (options tagOptions) {
+	for _, option := range strings.Split(tag, ",") {
+		switch strings.TrimSpace(option) {
+		case "owned":
+			options.owned = true
+		case "borrowed":
+			options.borrowed = true
+		case "-":
+			options.excluded = true
+		}
+	}
+	return
+}
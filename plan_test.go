@@ -0,0 +1,38 @@
+package duplicates
+
+import (
+	"testing"
+)
+
+func TestSessionsAreIsolated(t *testing.T) {
+	v := 1
+	cache := NewPlanCache()
+	session1 := cache.NewSession("first")
+	session2 := cache.NewSession("second")
+
+	session1.ScanForPointers([]*int{&v, &v})
+	session2.ScanForPointers([]*int{&v})
+
+	if !session1.IsDuplicatePointer(&v) {
+		t.Errorf("Expected &v to be a duplicate in session %v", session1.Name)
+	}
+	if session2.IsDuplicatePointer(&v) {
+		t.Errorf("Expected &v to not be a duplicate in session %v", session2.Name)
+	}
+}
+
+func TestSessionsSharePlans(t *testing.T) {
+	cache := NewPlanCache()
+	cache.NewSession("first").ScanForPointers(&DuplicatesTestStruct{})
+	session := cache.NewSession("second")
+	session.ScanForPointers(&DuplicatesTestStruct{})
+
+	count := 0
+	cache.plans.Range(func(_, _ interface{}) bool {
+		count++
+		return true
+	})
+	if count == 0 {
+		t.Errorf("Expected the plan cache to be populated")
+	}
+}
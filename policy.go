@@ -0,0 +1,124 @@
+package duplicates
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Violation describes a breach of a sharing policy rule.
+type Violation struct {
+	// A description of the rule that was violated.
+	Rule string
+	// The shared object that violates the rule.
+	Pointer TypedPointer
+	// Where the violation was found.
+	Path Path
+	// A human-readable explanation.
+	Message string
+}
+
+func (_this Violation) String() string {
+	return fmt.Sprintf("%v: %v", _this.Rule, _this.Message)
+}
+
+// Policy is a set of sharing rules that values can be evaluated against,
+// turning the finder into an enforcement tool rather than just a reporter.
+type Policy struct {
+	rules []policyRule
+}
+
+type policyRule interface {
+	describe() string
+	evaluate(finder *DuplicateFinder, violations []Violation) []Violation
+}
+
+func NewPolicy() *Policy {
+	return &Policy{}
+}
+
+// ForbidSharing adds a rule that pointers of type pointerType (for example
+// reflect.TypeOf((*Session)(nil))) must never be shared.
+func (_this *Policy) ForbidSharing(pointerType reflect.Type) *Policy {
+	return _this.LimitFanIn(pointerType, 1)
+}
+
+// LimitFanIn adds a rule that no object of type pointerType may be referenced
+// more than maxFanIn times.
+func (_this *Policy) LimitFanIn(pointerType reflect.Type, maxFanIn int) *Policy {
+	_this.rules = append(_this.rules, &fanInRule{pointerType: pointerType, maxFanIn: maxFanIn})
+	return _this
+}
+
+// ForbidCyclesUnder adds a rule that no reference cycle may be closed anywhere
+// beneath the field fieldName of structType.
+func (_this *Policy) ForbidCyclesUnder(structType reflect.Type, fieldName string) *Policy {
+	_this.rules = append(_this.rules, &cycleRule{structType: structType, fieldName: fieldName})
+	return _this
+}
+
+// Evaluate scans value and returns every violation of the policy's rules, in
+// the order the rules were added.
+func (_this *Policy) Evaluate(value interface{}) (violations []Violation) {
+	finder := NewDuplicateFinder()
+	finder.RecordPaths = true
+	finder.ScanForPointers(value)
+	for _, rule := range _this.rules {
+		violations = rule.evaluate(finder, violations)
+	}
+	return
+}
+
+type fanInRule struct {
+	pointerType reflect.Type
+	maxFanIn    int
+}
+
+func (_this *fanInRule) describe() string {
+	if _this.maxFanIn == 1 {
+		return fmt.Sprintf("%v must never be shared", _this.pointerType)
+	}
+	return fmt.Sprintf("fan-in of %v must be <= %v", _this.pointerType, _this.maxFanIn)
+}
+
+func (_this *fanInRule) evaluate(finder *DuplicateFinder, violations []Violation) []Violation {
+	for i, record := range finder.records {
+		if record.pointer.Type != _this.pointerType || int(record.sightings) <= _this.maxFanIn {
+			continue
+		}
+		violations = append(violations, Violation{
+			Rule:    _this.describe(),
+			Pointer: record.pointer,
+			Path:    finder.pathAt(finder.pathsOfRecord(i).first),
+			Message: fmt.Sprintf("%v is referenced %v times", record.pointer, record.sightings),
+		})
+	}
+	return violations
+}
+
+type cycleRule struct {
+	structType reflect.Type
+	fieldName  string
+}
+
+func (_this *cycleRule) describe() string {
+	return fmt.Sprintf("cycles are forbidden under %v.%v", _this.structType, _this.fieldName)
+}
+
+func (_this *cycleRule) evaluate(finder *DuplicateFinder, violations []Violation) []Violation {
+	for _, backRef := range finder.backReferences {
+		path := finder.pathAt(backRef.pathNode)
+		for _, step := range path.Steps() {
+			if step.Kind == StepField && step.Container == _this.structType && step.FieldName() == _this.fieldName {
+				ptr := finder.records[backRef.record].pointer
+				violations = append(violations, Violation{
+					Rule:    _this.describe(),
+					Pointer: ptr,
+					Path:    path,
+					Message: fmt.Sprintf("%v refers back to %v", path, ptr),
+				})
+				break
+			}
+		}
+	}
+	return violations
+}
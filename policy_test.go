@@ -0,0 +1,63 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+)
+
+type policySession struct {
+	ID int
+}
+
+type policyNode struct {
+	Children []*policyNode
+	Parent   *policyNode
+	Session  *policySession
+}
+
+func TestPolicyFanIn(t *testing.T) {
+	session := &policySession{}
+	root := &policyNode{Session: session}
+	for i := 0; i < 3; i++ {
+		root.Children = append(root.Children, &policyNode{Session: session})
+	}
+	sessionType := reflect.TypeOf(session)
+
+	if violations := NewPolicy().LimitFanIn(sessionType, 4).Evaluate(root); len(violations) != 0 {
+		t.Errorf("Expected no violations but got %v", violations)
+	}
+
+	violations := NewPolicy().LimitFanIn(sessionType, 3).Evaluate(root)
+	if len(violations) != 1 {
+		t.Fatalf("Expected 1 violation but got %v", violations)
+	}
+	if violations[0].Pointer != TypedPointerOf(session) || violations[0].Path.String() != ".Children[0].Session" {
+		t.Errorf("Unexpected violation %v at %v", violations[0], violations[0].Path)
+	}
+
+	if violations := NewPolicy().ForbidSharing(sessionType).Evaluate(&policyNode{Session: session}); len(violations) != 0 {
+		t.Errorf("Expected no violations but got %v", violations)
+	}
+	if violations := NewPolicy().ForbidSharing(sessionType).Evaluate(root); len(violations) != 1 {
+		t.Errorf("Expected 1 violation but got %v", violations)
+	}
+}
+
+func TestPolicyCycles(t *testing.T) {
+	root := &policyNode{}
+	child := &policyNode{Parent: root}
+	root.Children = []*policyNode{child}
+	nodeType := reflect.TypeOf(policyNode{})
+
+	if violations := NewPolicy().ForbidCyclesUnder(nodeType, "Session").Evaluate(root); len(violations) != 0 {
+		t.Errorf("Expected no violations but got %v", violations)
+	}
+
+	violations := NewPolicy().ForbidCyclesUnder(nodeType, "Children").Evaluate(root)
+	if len(violations) != 1 {
+		t.Fatalf("Expected 1 violation but got %v", violations)
+	}
+	if violations[0].Pointer != TypedPointerOf(root) || violations[0].Path.String() != ".Children[0].Parent" {
+		t.Errorf("Unexpected violation %v at %v", violations[0], violations[0].Path)
+	}
+}
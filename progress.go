@@ -0,0 +1,45 @@
+package duplicates
+
+import (
+	"time"
+)
+
+// The number of values visited between progress reports if ProgressInterval
+// isn't set.
+const defaultProgressInterval = 100000
+
+// Progress is a snapshot of the running totals of a scan, as passed to
+// OnProgress.
+type Progress struct {
+	// The index of the root being scanned (counting every root scanned by
+	// the finder).
+	Root int
+	// The number of values visited by all scans since the finder was
+	// initialized.
+	NodesVisited int
+	// The number of distinct pointers registered so far.
+	PointersRegistered int
+	// The deepest level reached below a root so far.
+	MaxDepth int
+	// The time spent in the current scan so far.
+	Elapsed time.Duration
+}
+
+// Returns true if OnProgress must be called after the current value.
+func (_this *DuplicateFinder) isProgressDue() bool {
+	interval := _this.ProgressInterval
+	if interval <= 0 {
+		interval = defaultProgressInterval
+	}
+	return _this.nodesVisited%interval == 0
+}
+
+func (_this *DuplicateFinder) reportProgress() {
+	_this.OnProgress(Progress{
+		Root:               _this.rootIndex,
+		NodesVisited:       _this.nodesVisited,
+		PointersRegistered: len(_this.records),
+		MaxDepth:           _this.maxDepth,
+		Elapsed:            time.Since(_this.scanStart),
+	})
+}
@@ -0,0 +1,27 @@
+package duplicates
+
+import (
+	"testing"
+)
+
+func TestOnProgress(t *testing.T) {
+	nodes := make([]*int, 25)
+	for i := range nodes {
+		nodes[i] = new(int)
+	}
+
+	var reports []Progress
+	finder := NewDuplicateFinder(WithProgress(10, func(progress Progress) {
+		reports = append(reports, progress)
+	}))
+	finder.ScanForPointers(nodes)
+	if len(reports) != 2 {
+		t.Fatalf("Expected 2 progress reports but got %v", len(reports))
+	}
+	if reports[0].NodesVisited != 10 || reports[1].NodesVisited != 20 {
+		t.Errorf("Expected reports at 10 and 20 nodes but got %+v", reports)
+	}
+	if reports[1].PointersRegistered <= reports[0].PointersRegistered {
+		t.Errorf("Expected running totals to grow but got %+v", reports)
+	}
+}
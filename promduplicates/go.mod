@@ -0,0 +1,24 @@
+module github.com/kstenerud/go-duplicates/promduplicates
+
+go 1.21
+
+require (
+	github.com/kstenerud/go-duplicates v0.0.0-20261016100656-279fb15e0036
+	github.com/prometheus/client_golang v1.20.5
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/kylelemons/godebug v1.1.0 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/client_model v0.6.1 // indirect
+	github.com/prometheus/common v0.55.0 // indirect
+	github.com/prometheus/procfs v0.15.1 // indirect
+	golang.org/x/sys v0.22.0 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+)
+
+// Builds within this repository use the main module next to it. Modules that
+// depend on this one get the version required above.
+replace github.com/kstenerud/go-duplicates => ../
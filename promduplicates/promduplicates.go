@@ -0,0 +1,94 @@
+// Package promduplicates exposes duplicate finder activity as Prometheus
+// metrics, so that services scanning objects in their request paths can
+// alert on anomalies (unusually slow scans, or sudden increases in sharing).
+//
+// This package lives in its own module so that the main module doesn't
+// depend on the Prometheus client.
+package promduplicates
+
+import (
+	"time"
+
+	duplicates "github.com/kstenerud/go-duplicates"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics is a prometheus.Collector that records the scans made through it.
+// It is safe for concurrent use (although each finder must only be used by
+// one goroutine at a time).
+type Metrics struct {
+	scans        prometheus.Counter
+	nodesVisited prometheus.Counter
+	duration     prometheus.Histogram
+	duplicates   prometheus.Histogram
+}
+
+// NewMetrics creates a set of scan metrics, with names prefixed by namespace
+// (if not empty). Register it with a prometheus.Registerer to export it.
+func NewMetrics(namespace string) *Metrics {
+	return &Metrics{
+		scans: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "duplicates",
+			Name:      "scans_total",
+			Help:      "Number of objects scanned for duplicate pointers.",
+		}),
+		nodesVisited: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "duplicates",
+			Name:      "nodes_visited_total",
+			Help:      "Number of values visited while scanning.",
+		}),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "duplicates",
+			Name:      "scan_duration_seconds",
+			Help:      "Time taken to scan an object.",
+			Buckets:   prometheus.ExponentialBuckets(0.00001, 4, 10),
+		}),
+		duplicates: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "duplicates",
+			Name:      "duplicates_per_scan",
+			Help:      "Number of new duplicate pointers found by a scan.",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+		}),
+	}
+}
+
+func (_this *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	_this.scans.Describe(ch)
+	_this.nodesVisited.Describe(ch)
+	_this.duration.Describe(ch)
+	_this.duplicates.Describe(ch)
+}
+
+func (_this *Metrics) Collect(ch chan<- prometheus.Metric) {
+	_this.scans.Collect(ch)
+	_this.nodesVisited.Collect(ch)
+	_this.duration.Collect(ch)
+	_this.duplicates.Collect(ch)
+}
+
+// ScanForPointers scans object using finder, recording the scan's metrics.
+func (_this *Metrics) ScanForPointers(finder *duplicates.DuplicateFinder, object interface{}) {
+	// A finder only ever adds duplicates to DuplicatePointers.
+	duplicatesBefore := len(finder.DuplicatePointers)
+	nodesBefore := finder.NodesVisited()
+	start := time.Now()
+
+	finder.ScanForPointers(object)
+
+	_this.duration.Observe(time.Since(start).Seconds())
+	_this.scans.Inc()
+	_this.nodesVisited.Add(float64(finder.NodesVisited() - nodesBefore))
+	_this.duplicates.Observe(float64(len(finder.DuplicatePointers) - duplicatesBefore))
+}
+
+// FindDuplicatePointers works like duplicates.FindDuplicatePointers,
+// recording the scan's metrics.
+func (_this *Metrics) FindDuplicatePointers(value interface{}) (duplicatePtrs map[duplicates.TypedPointer]bool) {
+	finder := duplicates.NewDuplicateFinder()
+	_this.ScanForPointers(finder, value)
+	return finder.DuplicatePointers
+}
@@ -0,0 +1,37 @@
+package promduplicates
+
+import (
+	"strings"
+	"testing"
+
+	duplicates "github.com/kstenerud/go-duplicates"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics("test")
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(metrics)
+
+	v1 := 1
+	v2 := 2
+	finder := duplicates.NewDuplicateFinder()
+	metrics.ScanForPointers(finder, []*int{&v1, &v1, &v2})
+	metrics.ScanForPointers(finder, []*int{&v2})
+
+	expected := `
+# HELP test_duplicates_scans_total Number of objects scanned for duplicate pointers.
+# TYPE test_duplicates_scans_total counter
+test_duplicates_scans_total 2
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "test_duplicates_scans_total"); err != nil {
+		t.Error(err)
+	}
+	if nodes := testutil.ToFloat64(metrics.nodesVisited); nodes != float64(finder.NodesVisited()) {
+		t.Errorf("Expected %v nodes visited but got %v", finder.NodesVisited(), nodes)
+	}
+	if count := testutil.CollectAndCount(metrics, "test_duplicates_duplicates_per_scan"); count != 1 {
+		t.Errorf("Expected a duplicates histogram but got %v metrics", count)
+	}
+}
@@ -0,0 +1,20 @@
+package duplicates
+
+// FindReferencesTo scans root and returns the paths of every struct field,
+// slice or array element, map value, and interface within root that holds
+// target, in discovery order. root itself isn't counted as a reference.
+func FindReferencesTo(root interface{}, target TypedPointer) (references []Path) {
+	finder := NewDuplicateFinder()
+	finder.RecordPaths = true
+	finder.sightingHook = func(index int) {
+		// Registering the address of a field is not a reference to it.
+		if finder.registeringField || finder.depth == 0 {
+			return
+		}
+		if finder.records[index].pointer == target {
+			references = append(references, finder.pathAt(finder.pathNode))
+		}
+	}
+	finder.ScanForPointers(root)
+	return
+}
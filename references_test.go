@@ -0,0 +1,51 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+)
+
+type referencesNode struct {
+	Name  string
+	Ref   *referencesNode
+	Any   interface{}
+	List  []*referencesNode
+	ByKey map[string]*referencesNode
+}
+
+func TestFindReferencesTo(t *testing.T) {
+	target := &referencesNode{Name: "target"}
+	root := &referencesNode{
+		Ref:   target,
+		Any:   target,
+		List:  []*referencesNode{nil, target},
+		ByKey: map[string]*referencesNode{"k": target},
+	}
+	target.Ref = root
+	root.List[0] = &referencesNode{Any: &root.Name}
+
+	actual := []string{}
+	for _, path := range FindReferencesTo(root, TypedPointerOf(target)) {
+		actual = append(actual, path.String())
+	}
+	expected := []string{".Ref", ".Any", ".List[1]", `.ByKey["k"]`}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v but got %v", expected, actual)
+	}
+
+	actual = []string{}
+	for _, path := range FindReferencesTo(root, TypedPointerOf(root)) {
+		actual = append(actual, path.String())
+	}
+	if !reflect.DeepEqual(actual, []string{".Ref.Ref"}) {
+		t.Errorf("Expected the root to be referenced from .Ref.Ref but got %v", actual)
+	}
+
+	actual = []string{}
+	for _, path := range FindReferencesTo(root, TypedPointerOf(&root.Name)) {
+		actual = append(actual, path.String())
+	}
+	if !reflect.DeepEqual(actual, []string{".List[0].Any"}) {
+		t.Errorf("Expected the field to be referenced from .List[0].Any but got %v", actual)
+	}
+}
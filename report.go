@@ -0,0 +1,403 @@
+package duplicates
+
+import (
+	"reflect"
+	"sort"
+)
+
+// DuplicateInfo describes a single duplicate pointer in a DuplicateReport.
+type DuplicateInfo struct {
+	// The order in which this duplicate was discovered relative to the other
+	// reported duplicates, starting from 0. This matches the marker ID
+	// assigned by DuplicateTable.
+	Index int
+
+	// An estimate of how likely this sharing is to cause problems, for
+	// sorting large reports by likely impact. See RiskScore.
+	Risk float64
+
+	// The number of times this duplicate was encountered during the scan.
+	Count int
+
+	// True if this duplicate was found again from within what it refers to
+	// (a reference cycle), rather than only from separate branches.
+	InCycle bool
+
+	// True if at least one path from a root reaches this duplicate
+	// exclusively through exported fields (and is thus visible to encoders
+	// such as encoding/json).
+	ExportedPath bool
+
+	// The index of the root (counting each call to ScanForPointers) that this
+	// duplicate was first found from.
+	Root int
+
+	// True if this duplicate was found from more than one root (cross-root
+	// sharing), false if all sightings came from a single root (internal
+	// sharing).
+	CrossRoot bool
+
+	// The indices of all roots this duplicate was found from, in ascending
+	// order (see DuplicateFinder.RootsOf).
+	Roots []int
+}
+
+// DuplicateReport holds the results of a scan. Consumers should access the
+// results via its methods rather than relying on how they are stored.
+//
+// A report's pointers keep the duplicate objects alive (see TypedPointer),
+// guaranteeing that their reported addresses remain valid until Release is
+// called, unless the finder had RawAddresses set while scanning. If the finder
+// had RetainValues set, the report also holds the objects' values (see
+// ValueFor).
+type DuplicateReport struct {
+	pointers []TypedPointer
+	infos    map[TypedPointer]*DuplicateInfo
+	values   map[TypedPointer]reflect.Value
+	paths    map[TypedPointer]Path
+	// The paths of the second sightings.
+	repeatPaths map[TypedPointer]Path
+
+	coincidences []AddressCoincidence
+
+	// Deterministic IDs for every scanned address, used in place of the
+	// addresses themselves when address redaction is enabled.
+	pseudoIDs map[uintptr]int
+
+	fieldStats []FieldStat
+	zeroSized  []TypedPointer
+
+	// A digest of the address-free shape of the sharing.
+	shape ContentDigest
+
+	scanInfo            ScanInfo
+	truncatedContainers []TypedPointer
+
+	// Whether the pointers are raw (see DuplicateFinder.RawAddresses).
+	rawAddresses bool
+}
+
+// ScanInfo describes the scans that a DuplicateReport was built from.
+type ScanInfo struct {
+	// The number of roots scanned (calls to ScanForPointers and similar).
+	Roots int
+	// The number of distinct pointers registered.
+	Pointers int
+	// The number of values visited.
+	NodesVisited int
+	// True if a limit stopped the scans from reaching everything (see
+	// DuplicateFinder.Truncated).
+	Truncated bool
+	// The number of roots that were scanned from a copy (see
+	// DuplicateFinder.CopiedRoots).
+	CopiedRoots int
+}
+
+// AddressCoincidence lists the different pointer types that were found at the
+// same address. A struct, its first field, and that field's first field (and
+// so on) all legally share one address, as does a slice with its first
+// element.
+type AddressCoincidence struct {
+	Address uintptr
+	// The pointer types found at Address, in the order they were discovered
+	// (which for nested objects means outermost first).
+	Types []reflect.Type
+	// True if the types are all views of nested objects starting at Address
+	// (a struct and its first field, an array or slice and its first element,
+	// and so on), which is normal memory layout rather than sharing. If false,
+	// the same memory was found as unrelated types, which usually means that
+	// it was converted using unsafe.
+	Nested bool
+
+	// The pointers found at Address, one for each of Types.
+	pointers []TypedPointer
+}
+
+// FindDuplicates scans value for duplicate pointers and returns a report of
+// the results. See FindDuplicatePointers.
+func FindDuplicates(value interface{}) *DuplicateReport {
+	finder := NewDuplicateFinder()
+	finder.ScanForPointers(value)
+	return finder.Report()
+}
+
+// Report builds a DuplicateReport from the duplicates found so far. The report
+// does not change if the finder is used again afterwards.
+func (_this *DuplicateFinder) Report() *DuplicateReport {
+	report := &DuplicateReport{
+		infos:        make(map[TypedPointer]*DuplicateInfo),
+		rawAddresses: _this.RawAddresses,
+	}
+	duplicates := _this.reportedDuplicates()
+	for _, record := range duplicates {
+		ptr := record.pointer
+		report.infos[ptr] = &DuplicateInfo{
+			Index:        len(report.pointers),
+			Risk:         record.riskScore(),
+			Count:        int(record.sightings),
+			InCycle:      record.inCycle,
+			ExportedPath: record.exportedPath,
+			Root:         int(record.firstRoot),
+			CrossRoot:    record.crossRoot,
+			Roots:        _this.RootsOf(ptr),
+		}
+		if _this.RecordPaths {
+			if report.paths == nil {
+				report.paths = make(map[TypedPointer]Path)
+				report.repeatPaths = make(map[TypedPointer]Path)
+			}
+			paths := _this.pathsOfRecord(_this.recordIndex[ptr])
+			report.paths[ptr] = _this.pathAt(paths.first)
+			if record.sightings > 1 {
+				report.repeatPaths[ptr] = _this.pathAt(paths.repeat)
+			}
+		}
+		if value, ok := _this.values[ptr]; ok {
+			if report.values == nil {
+				report.values = make(map[TypedPointer]reflect.Value)
+			}
+			report.values[ptr] = value
+		}
+		report.pointers = append(report.pointers, ptr)
+	}
+	report.coincidences = _this.addressCoincidences()
+	report.pseudoIDs = _this.pseudoIDs()
+	report.fieldStats = _this.fieldStats()
+	report.shape = sharingShape(duplicates)
+	report.zeroSized = _this.zeroSizedDuplicates()
+	report.truncatedContainers = _this.TruncatedContainers()
+	report.scanInfo = ScanInfo{
+		Roots:        _this.rootCount,
+		Pointers:     len(_this.records),
+		NodesVisited: _this.nodesVisited,
+		Truncated:    _this.truncated,
+		CopiedRoots:  _this.copiedRoots,
+	}
+	return report
+}
+
+// Assigns IDs to all registered addresses in discovery order.
+func (_this *DuplicateFinder) pseudoIDs() map[uintptr]int {
+	ids := make(map[uintptr]int)
+	for _, record := range _this.records {
+		ptr := record.pointer
+		if _, ok := ids[ptr.Address()]; !ok {
+			ids[ptr.Address()] = len(ids)
+		}
+	}
+	return ids
+}
+
+// Returns the duplicates that should appear in reports and tables, in
+// discovery order.
+func (_this *DuplicateFinder) reportedDuplicates() (duplicates []*pointerRecord) {
+	for i := range _this.records {
+		record := &_this.records[i]
+		if _this.DuplicatePointers[record.pointer] {
+			duplicates = append(duplicates, record)
+		}
+	}
+	return
+}
+
+func (_this *DuplicateFinder) addressCoincidences() (coincidences []AddressCoincidence) {
+	indices := make(map[uintptr]int)
+	for _, record := range _this.records {
+		ptr := record.pointer
+		index, ok := indices[ptr.Address()]
+		if !ok {
+			indices[ptr.Address()] = len(coincidences)
+			coincidences = append(coincidences, AddressCoincidence{Address: ptr.Address()})
+			index = len(coincidences) - 1
+		}
+		coincidences[index].Types = append(coincidences[index].Types, ptr.Type)
+		coincidences[index].pointers = append(coincidences[index].pointers, ptr)
+	}
+
+	count := 0
+	for _, coincidence := range coincidences {
+		if len(coincidence.Types) > 1 {
+			coincidence.Nested = areNestedReferents(coincidence.Types)
+			coincidences[count] = coincidence
+			count++
+		}
+	}
+	return coincidences[:count]
+}
+
+// Returns true if the referents of pointerTypes can be ordered so that each
+// one starts the one before it.
+func areNestedReferents(pointerTypes []reflect.Type) bool {
+	referents := make([]reflect.Type, len(pointerTypes))
+	for i, pointerType := range pointerTypes {
+		switch pointerType.Kind() {
+		case reflect.Ptr, reflect.Slice:
+			referents[i] = pointerType.Elem()
+		default:
+			return false
+		}
+	}
+	sort.SliceStable(referents, func(i, j int) bool {
+		return referents[i].Size() > referents[j].Size()
+	})
+	for i := 1; i < len(referents); i++ {
+		if !startsWith(referents[i-1], referents[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns true if a value of type outer begins with a value of type inner.
+func startsWith(outer, inner reflect.Type) bool {
+	if outer == inner {
+		return true
+	}
+	switch outer.Kind() {
+	case reflect.Struct:
+		for i := 0; i < outer.NumField(); i++ {
+			field := outer.Field(i)
+			if field.Offset != 0 {
+				break
+			}
+			if startsWith(field.Type, inner) {
+				return true
+			}
+		}
+	case reflect.Array:
+		return outer.Len() > 0 && startsWith(outer.Elem(), inner)
+	}
+	return false
+}
+
+// Returns ptr as the report's pointers are identified.
+func (_this *DuplicateReport) identify(ptr TypedPointer) TypedPointer {
+	if _this.rawAddresses {
+		return ptr.Raw()
+	}
+	return ptr
+}
+
+// Len returns the number of duplicates in the report.
+func (_this *DuplicateReport) Len() int {
+	return len(_this.pointers)
+}
+
+// ScanInfo returns information about the scans that the report was built
+// from.
+func (_this *DuplicateReport) ScanInfo() ScanInfo {
+	return _this.scanInfo
+}
+
+// TruncatedContainers returns the containers whose elements were cut short by
+// MaxElements (see DuplicateFinder.TruncatedContainers).
+func (_this *DuplicateReport) TruncatedContainers() []TypedPointer {
+	return _this.truncatedContainers
+}
+
+// Duplicates returns the report's duplicates in discovery order.
+func (_this *DuplicateReport) Duplicates() []TypedPointer {
+	duplicates := make([]TypedPointer, len(_this.pointers))
+	copy(duplicates, _this.pointers)
+	return duplicates
+}
+
+// IsDuplicate returns true if ptr is a duplicate in this report.
+func (_this *DuplicateReport) IsDuplicate(ptr TypedPointer) bool {
+	_, ok := _this.infos[_this.identify(ptr)]
+	return ok
+}
+
+// Info returns the information about the duplicate ptr, if it is one.
+func (_this *DuplicateReport) Info(ptr TypedPointer) (info DuplicateInfo, isDuplicate bool) {
+	if infoPtr := _this.infos[_this.identify(ptr)]; infoPtr != nil {
+		return *infoPtr, true
+	}
+	return
+}
+
+// ForEachDuplicate calls callback for every duplicate in the report, in
+// discovery order, stopping early if callback returns false.
+func (_this *DuplicateReport) ForEachDuplicate(callback func(ptr TypedPointer, info DuplicateInfo) (shouldContinue bool)) {
+	for _, ptr := range _this.pointers {
+		if !callback(ptr, *_this.infos[ptr]) {
+			return
+		}
+	}
+}
+
+// ValueFor returns the live value of the duplicate ptr, allowing the actual
+// shared object to be inspected or modified. This only works if the finder
+// had RetainValues set while scanning.
+func (_this *DuplicateReport) ValueFor(ptr TypedPointer) (value reflect.Value, ok bool) {
+	value, ok = _this.values[_this.identify(ptr)]
+	return
+}
+
+// PathTo returns the path at which the duplicate ptr was first found. This only
+// works if the finder had RecordPaths set while scanning.
+func (_this *DuplicateReport) PathTo(ptr TypedPointer) (path Path, ok bool) {
+	path, ok = _this.paths[_this.identify(ptr)]
+	return
+}
+
+// RepeatPathTo returns the path at which the duplicate ptr was found for the
+// second time. This only works if the finder had RecordPaths set while
+// scanning.
+func (_this *DuplicateReport) RepeatPathTo(ptr TypedPointer) (path Path, ok bool) {
+	path, ok = _this.repeatPaths[_this.identify(ptr)]
+	return
+}
+
+// HoldsReferences returns true if the report is keeping its duplicate objects
+// alive (see Release).
+func (_this *DuplicateReport) HoldsReferences() bool {
+	return _this.values != nil || !_this.rawAddresses
+}
+
+// Release drops the report's references to the duplicate objects, allowing
+// them to be garbage collected. After this, ValueFor will no longer find
+// anything, and the pointers in the report are raw (see TypedPointer.Raw),
+// and should be treated as identifiers only.
+func (_this *DuplicateReport) Release() {
+	_this.values = nil
+	if _this.rawAddresses {
+		return
+	}
+	_this.rawAddresses = true
+	rawPointers := func(pointers []TypedPointer) {
+		for i, ptr := range pointers {
+			pointers[i] = ptr.Raw()
+		}
+	}
+	rawPaths := func(paths map[TypedPointer]Path) map[TypedPointer]Path {
+		if paths == nil {
+			return nil
+		}
+		raw := make(map[TypedPointer]Path, len(paths))
+		for ptr, path := range paths {
+			raw[ptr.Raw()] = path
+		}
+		return raw
+	}
+	rawPointers(_this.pointers)
+	rawPointers(_this.zeroSized)
+	rawPointers(_this.truncatedContainers)
+	for _, coincidence := range _this.coincidences {
+		rawPointers(coincidence.pointers)
+	}
+	infos := make(map[TypedPointer]*DuplicateInfo, len(_this.infos))
+	for ptr, info := range _this.infos {
+		infos[ptr.Raw()] = info
+	}
+	_this.infos = infos
+	_this.paths = rawPaths(_this.paths)
+	_this.repeatPaths = rawPaths(_this.repeatPaths)
+}
+
+// AddressCoincidences returns every address at which more than one pointer
+// type was found during the scan, in discovery order. These are not sharing as
+// such, but frequently confuse consumers of the raw TypedPointer keys.
+func (_this *DuplicateReport) AddressCoincidences() []AddressCoincidence {
+	return _this.coincidences
+}
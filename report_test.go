@@ -0,0 +1,340 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+func TestReportForEachDuplicate(t *testing.T) {
+	v1 := 1
+	v2 := 2
+	v3 := 3
+	report := FindDuplicates([]*int{&v3, &v1, &v3, &v2, &v1, &v2})
+
+	expected := []*int{&v3, &v1, &v2}
+	if report.Len() != len(expected) {
+		t.Fatalf("Expected %v duplicates but got %v", len(expected), report.Len())
+	}
+
+	index := 0
+	report.ForEachDuplicate(func(ptr TypedPointer, info DuplicateInfo) bool {
+		if ptr != TypedPointerOf(expected[index]) || info.Index != index {
+			t.Errorf("Duplicate %v: Expected %v but got %v (%v)", index, expected[index], ptr, info.Index)
+		}
+		index++
+		return true
+	})
+
+	count := 0
+	report.ForEachDuplicate(func(ptr TypedPointer, info DuplicateInfo) bool {
+		count++
+		return false
+	})
+	if count != 1 {
+		t.Errorf("Expected iteration to stop after 1 duplicate but got %v", count)
+	}
+}
+
+func TestReportValueFor(t *testing.T) {
+	v := 1
+	other := 2
+	finder := NewDuplicateFinder()
+	finder.RetainValues = true
+	finder.ScanForPointers([]*int{&v, &other, &v})
+	report := finder.Report()
+
+	value, ok := report.ValueFor(TypedPointerOf(&v))
+	if !ok {
+		t.Fatalf("Expected a value for &v")
+	}
+	value.Elem().SetInt(100)
+	if v != 100 {
+		t.Errorf("Expected v to have been modified via the retained value")
+	}
+	if _, ok := report.ValueFor(TypedPointerOf(&other)); ok {
+		t.Errorf("Expected no value for non-duplicate &other")
+	}
+
+	if _, ok := FindDuplicates([]*int{&v, &v}).ValueFor(TypedPointerOf(&v)); ok {
+		t.Errorf("Expected no value when values aren't retained")
+	}
+}
+
+func TestReportRelease(t *testing.T) {
+	v := 1
+	finder := NewDuplicateFinder()
+	finder.RetainValues = true
+	finder.ScanForPointers([]*int{&v, &v})
+	report := finder.Report()
+
+	if !report.HoldsReferences() {
+		t.Errorf("Expected the report to hold references")
+	}
+	report.Release()
+	if report.HoldsReferences() {
+		t.Errorf("Expected the report to not hold references after release")
+	}
+	if _, ok := report.ValueFor(TypedPointerOf(&v)); ok {
+		t.Errorf("Expected no value after release")
+	}
+	if !report.IsDuplicate(TypedPointerOf(&v)) {
+		t.Errorf("Expected &v to still be reported as a duplicate")
+	}
+}
+
+type coincidenceInner struct {
+	Value int
+}
+
+type coincidenceOuter struct {
+	Inner coincidenceInner
+	Other int
+}
+
+func TestReportAddressCoincidences(t *testing.T) {
+	v := &coincidenceOuter{}
+	coincidences := FindDuplicates(v).AddressCoincidences()
+	if len(coincidences) != 1 {
+		t.Fatalf("Expected 1 coincidence but got %v", coincidences)
+	}
+
+	expected := []reflect.Type{
+		reflect.TypeOf(v),
+		reflect.TypeOf(&v.Inner),
+		reflect.TypeOf(&v.Inner.Value),
+	}
+	coincidence := coincidences[0]
+	if coincidence.Address != reflect.ValueOf(v).Pointer() {
+		t.Errorf("Expected address %x but got %x", reflect.ValueOf(v).Pointer(), coincidence.Address)
+	}
+	if !reflect.DeepEqual(coincidence.Types, expected) {
+		t.Errorf("Expected %v but got %v", expected, coincidence.Types)
+	}
+	if !coincidence.Nested {
+		t.Errorf("Expected the coincidence to be nested")
+	}
+}
+
+func TestReportAddressCoincidencesNotNested(t *testing.T) {
+	v := &coincidenceOuter{}
+	other := (*[2]int)(unsafe.Pointer(v))
+	coincidences := FindDuplicates([]interface{}{v, other, other[:]}).AddressCoincidences()
+	if len(coincidences) != 1 {
+		t.Fatalf("Expected 1 coincidence but got %v", coincidences)
+	}
+	if coincidences[0].Nested {
+		t.Errorf("Expected %v not to be nested", coincidences[0].Types)
+	}
+
+	array := &[3]coincidenceOuter{}
+	coincidences = FindDuplicates([]interface{}{array, array[:]}).AddressCoincidences()
+	if len(coincidences) == 0 || len(coincidences[0].Types) != 4 || !coincidences[0].Nested {
+		t.Errorf("Expected an array, its slice, and its first element's fields to be nested but got %v", coincidences)
+	}
+}
+
+type embeddedAliasBase struct {
+	self *embeddedAliasBase
+}
+
+type embeddedAliasDerived struct {
+	embeddedAliasBase
+	Value int
+}
+
+func TestReportIgnoreEmbeddedAliases(t *testing.T) {
+	v := &embeddedAliasDerived{}
+	v.self = &v.embeddedAliasBase
+
+	if !FindDuplicates(v).IsDuplicate(TypedPointerOf(&v.embeddedAliasBase)) {
+		t.Errorf("Expected the embedded field to be a duplicate by default")
+	}
+
+	finder := NewDuplicateFinder()
+	finder.IgnoreEmbeddedAliases = true
+	finder.ScanForPointers(v)
+	if finder.Report().Len() != 0 {
+		t.Errorf("Expected the embedded alias to be suppressed")
+	}
+	if finder.Freeze().Len() != 0 {
+		t.Errorf("Expected the embedded alias to be suppressed from the table")
+	}
+	if finder.IsDuplicatePointer(&v.embeddedAliasBase) {
+		t.Errorf("Expected the embedded alias to be suppressed from DuplicatePointers")
+	}
+}
+
+type embeddedAliasOwner struct {
+	Base *embeddedAliasBase
+}
+
+func TestReportIgnoreEmbeddedAliasesSharing(t *testing.T) {
+	x := &embeddedAliasDerived{}
+	owners := []*embeddedAliasOwner{{Base: &x.embeddedAliasBase}, {Base: &x.embeddedAliasBase}}
+	base := TypedPointerOf(&x.embeddedAliasBase)
+
+	for _, root := range []interface{}{[]interface{}{owners, x}, []interface{}{x, owners}, owners} {
+		finder := NewDuplicateFinder(WithIgnoreEmbeddedAliases())
+		finder.ScanForPointers(root)
+		if !finder.DuplicatePointers[base] {
+			t.Errorf("Expected the embedded field shared by two owners to be a duplicate")
+		}
+		if !finder.Report().IsDuplicate(base) {
+			t.Errorf("Expected the report to list the embedded field shared by two owners")
+		}
+		if table := finder.Freeze(); table.Len() != 1 || table.Pointer(0) != base {
+			t.Errorf("Expected the table to hold only the embedded field")
+		}
+	}
+}
+
+type exportedPathInner struct {
+	Shared *int
+}
+
+type exportedPathStruct struct {
+	Public      *int
+	AlsoPublic  *int
+	private     *int
+	alsoPrivate *int
+	hidden      *exportedPathInner
+	Visible     *exportedPathInner
+	embeddedPathStruct
+}
+
+type embeddedPathStruct struct {
+	Promoted *int
+}
+
+func TestReportExportedPath(t *testing.T) {
+	public := 1
+	private := 2
+	late := 3
+	inner := &exportedPathInner{Shared: &late}
+	v := &exportedPathStruct{
+		Public:             &public,
+		AlsoPublic:         &public,
+		private:            &private,
+		alsoPrivate:        &private,
+		hidden:             inner,
+		Visible:            inner,
+		embeddedPathStruct: embeddedPathStruct{Promoted: &late},
+	}
+	report := FindDuplicates(v)
+
+	for _, ptr := range []interface{}{&public, &late, inner} {
+		info, isDuplicate := report.Info(TypedPointerOf(ptr))
+		if !isDuplicate || !info.ExportedPath {
+			t.Errorf("Expected %v to be a duplicate reachable through exported fields", ptr)
+		}
+	}
+	info, isDuplicate := report.Info(TypedPointerOf(&private))
+	if !isDuplicate {
+		t.Fatalf("Expected &private to be a duplicate")
+	}
+	if info.ExportedPath {
+		t.Errorf("Expected &private to not be reachable through exported fields")
+	}
+}
+
+func TestReportRootAttribution(t *testing.T) {
+	internal := 1
+	shared := 2
+	finder := NewDuplicateFinder()
+	finder.ScanForPointers([]*int{&internal, &internal})
+	finder.ScanForPointers(&shared)
+	finder.ScanForPointers([]*int{&shared})
+	report := finder.Report()
+
+	info, _ := report.Info(TypedPointerOf(&internal))
+	if info.CrossRoot || info.Root != 0 {
+		t.Errorf("Expected &internal to be internal to root 0 but got %v %v", info.CrossRoot, info.Root)
+	}
+	info, _ = report.Info(TypedPointerOf(&shared))
+	if !info.CrossRoot || info.Root != 1 {
+		t.Errorf("Expected &shared to cross roots from root 1 but got %v %v", info.CrossRoot, info.Root)
+	}
+}
+
+func TestReportScanInfo(t *testing.T) {
+	v := 1
+	finder := NewDuplicateFinder()
+	finder.ScanForPointers([]*int{&v, &v})
+	finder.ScanForPointers(&v)
+
+	expected := ScanInfo{Roots: 2, Pointers: 2, NodesVisited: 4}
+	if info := finder.Report().ScanInfo(); info != expected {
+		t.Errorf("Expected %+v but got %+v", expected, info)
+	}
+}
+
+func TestReportDuplicatesSortedMapKeys(t *testing.T) {
+	values := make([]int, 10)
+	m := make(map[int]*int)
+	for i := range values {
+		m[i] = &values[i]
+	}
+	root := []interface{}{m, m}
+	for i := range values {
+		root = append(root, &values[len(values)-1-i])
+	}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		finder := NewDuplicateFinder()
+		finder.SortMapKeys = true
+		finder.ScanForPointers(root)
+		duplicates := finder.Report().Duplicates()
+		if len(duplicates) != len(values)+1 || duplicates[0] != TypedPointerOf(m) {
+			t.Fatalf("Expected the map and its values to be duplicates but got %v", duplicates)
+		}
+		for i := range values {
+			if duplicates[i+1] != TypedPointerOf(&values[i]) {
+				t.Fatalf("Expected duplicate %v to be %v but got %v", i+1, TypedPointerOf(&values[i]), duplicates[i+1])
+			}
+		}
+	}
+}
+
+type sortedMapKey struct {
+	Name    string
+	Pointer *int
+}
+
+func TestReportDuplicatesSortedPointerKeys(t *testing.T) {
+	keys := make([]int, 3)
+	values := make([]int, 7)
+	byPointer := make(map[*int]*int)
+	byStruct := make(map[sortedMapKey]*int)
+	for i := range keys {
+		byPointer[&keys[i]] = &values[i]
+		byStruct[sortedMapKey{Name: "a", Pointer: &keys[i]}] = &values[i+3]
+	}
+	byStruct[sortedMapKey{Name: "b", Pointer: &keys[0]}] = &values[6]
+
+	// The keys are found in the reverse of their address order, before the
+	// maps are scanned.
+	var root []interface{}
+	for i := range keys {
+		root = append(root, &keys[len(keys)-1-i])
+	}
+	root = append(root, byPointer, byStruct)
+	for i := range values {
+		root = append(root, &values[i])
+	}
+	expected := []*int{&values[2], &values[1], &values[0], &values[5], &values[4], &values[3], &values[6]}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		finder := NewDuplicateFinder(WithSortedMapKeys())
+		finder.ScanForPointers(root)
+		duplicates := finder.Report().Duplicates()
+		if len(duplicates) != len(expected) {
+			t.Fatalf("Expected %v duplicates but got %v", len(expected), duplicates)
+		}
+		for i, value := range expected {
+			if duplicates[i] != TypedPointerOf(value) {
+				t.Fatalf("Expected duplicate %v to be %v but got %v", i, TypedPointerOf(value), duplicates[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package duplicates
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// Repro is a minimal, freshly allocated copy of a scanned object that still
+// demonstrates how one of its duplicate pointers is shared. It is intended to
+// be dropped into a unit test or a bug report.
+type Repro struct {
+	// A copy of the root, of the same type as the original root. Only the
+	// references along the two paths to the shared object are kept; all other
+	// references are nil. Scalar content (numbers, strings, etc) of every
+	// copied object is kept so that the copy remains recognizable.
+	Root interface{}
+	// The copy of the shared pointer.
+	Shared TypedPointer
+	// The first two paths at which the shared pointer was found.
+	Paths [2]Path
+}
+
+// ExtractRepro scans root, and builds a Repro demonstrating how duplicate is
+// shared. root must not be modified while the Repro is being built.
+func ExtractRepro(root interface{}, duplicate TypedPointer) (repro *Repro, err error) {
+	finder := NewDuplicateFinder()
+	finder.RecordPaths = true
+	var sightings []int32
+	finder.sightingHook = func(index int) {
+		if len(sightings) < 2 && finder.records[index].pointer == duplicate {
+			sightings = append(sightings, finder.pathNode)
+		}
+	}
+	finder.ScanForPointers(root)
+	if len(sightings) < 2 {
+		return nil, fmt.Errorf("duplicates: %v is not a duplicate within %v", duplicate, reflect.TypeOf(root))
+	}
+
+	repro = &Repro{}
+	for i, node := range sightings {
+		repro.Paths[i] = finder.pathAt(node)
+	}
+	// Build a path ending in the shared object itself before any path
+	// ending in a pointer into it, so that the pointer can refer to the copy.
+	order := []int{0, 1}
+	if endsInField(repro.Paths[1]) && !endsInField(repro.Paths[0]) {
+		order = []int{1, 0}
+	}
+
+	builder := reproBuilder{maps: make(map[uintptr]reflect.Value)}
+	original := reflect.ValueOf(root)
+	clone := reflect.New(original.Type()).Elem()
+	copyScalars(clone, original)
+	for _, i := range order {
+		end, err := builder.follow(original, clone, repro.Paths[i].Steps())
+		if err != nil {
+			return nil, err
+		}
+		if end.Type() == duplicate.Type {
+			repro.Shared = TypedPointerOfRV(end)
+		} else {
+			repro.Shared = TypedPointerOfRV(end.Addr())
+		}
+	}
+	repro.Root = clone.Interface()
+	return
+}
+
+func endsInField(path Path) bool {
+	return path.node != noPathNode && path.nodes[path.node].step.Kind == StepField
+}
+
+// Builds copies of the objects along paths, keeping track of which memory
+// has been copied to where so that aliasing is preserved.
+type reproBuilder struct {
+	regions []reproRegion
+	maps    map[uintptr]reflect.Value
+}
+
+type reproRegion struct {
+	start uintptr
+	end   uintptr
+	clone unsafe.Pointer
+	// Keeps the copy alive and typed.
+	value reflect.Value
+}
+
+// Copies the objects along steps from original into clone, returning the copy
+// of the value at the end of the path.
+func (_this *reproBuilder) follow(original, clone reflect.Value, steps []PathStep) (end reflect.Value, err error) {
+	if original.Kind() == reflect.Interface {
+		if original.IsNil() {
+			return clone, nil
+		}
+		elem := original.Elem()
+		if len(steps) > 0 && steps[0].Kind == StepTypeAssertion {
+			steps = steps[1:]
+		}
+		cloneElem := reflect.New(elem.Type()).Elem()
+		if !clone.IsNil() && clone.Elem().Type() == elem.Type() {
+			cloneElem.Set(clone.Elem())
+		} else {
+			copyScalars(cloneElem, elem)
+		}
+		end, err = _this.follow(elem, cloneElem, steps)
+		settable(clone).Set(cloneElem)
+		return
+	}
+
+	switch original.Kind() {
+	case reflect.Ptr:
+		if !original.IsNil() {
+			settable(clone).Set(_this.clonePointer(original))
+		}
+	case reflect.Slice:
+		if !original.IsNil() {
+			settable(clone).Set(_this.cloneSlice(original))
+		}
+	case reflect.Map:
+		if !original.IsNil() {
+			settable(clone).Set(_this.cloneMap(original))
+		}
+	}
+	if len(steps) == 0 {
+		return clone, nil
+	}
+
+	step := steps[0]
+	switch {
+	case step.Kind == StepPointerElem && original.Kind() == reflect.Ptr:
+		return _this.follow(original.Elem(), clone.Elem(), steps[1:])
+	case step.Kind == StepField && original.Kind() == reflect.Struct:
+		return _this.follow(original.Field(step.Index), clone.Field(step.Index), steps[1:])
+	case step.Kind == StepElem && (original.Kind() == reflect.Slice || original.Kind() == reflect.Array):
+		return _this.follow(original.Index(step.Index), clone.Index(step.Index), steps[1:])
+	case step.Kind == StepMapValue && original.Kind() == reflect.Map:
+		// The map may have been reached through unexported fields.
+		cloneMap := settable(clone)
+		elem := original.MapIndex(step.Key)
+		cloneKey := copyMapKey(step.Key)
+		cloneElem := reflect.New(elem.Type()).Elem()
+		if existing := cloneMap.MapIndex(cloneKey); existing.IsValid() {
+			cloneElem.Set(existing)
+		} else {
+			copyScalars(cloneElem, elem)
+		}
+		end, err = _this.follow(elem, cloneElem, steps[1:])
+		cloneMap.SetMapIndex(cloneKey, cloneElem)
+		return
+	}
+	return end, fmt.Errorf("duplicates: cannot follow path step %v from %v", step, original.Type())
+}
+
+// Returns the copy of the object that pointer points to, copying it if it
+// isn't already part of a copied object.
+func (_this *reproBuilder) clonePointer(pointer reflect.Value) reflect.Value {
+	elemType := pointer.Type().Elem()
+	if clone, ok := _this.findRegion(pointer.Pointer(), elemType.Size()); ok {
+		return reflect.NewAt(elemType, clone).Convert(pointer.Type())
+	}
+	clone := reflect.New(elemType)
+	copyScalars(clone.Elem(), pointer.Elem())
+	_this.addRegion(pointer.Pointer(), elemType.Size(), clone)
+	return clone.Convert(pointer.Type())
+}
+
+// Returns a copy of slice, sharing the copy of its backing array with any
+// other slices of it.
+func (_this *reproBuilder) cloneSlice(slice reflect.Value) reflect.Value {
+	elemType := slice.Type().Elem()
+	arrayType := reflect.ArrayOf(slice.Cap(), elemType)
+	var array reflect.Value
+	if clone, ok := _this.findRegion(slice.Pointer(), arrayType.Size()); ok {
+		array = reflect.NewAt(arrayType, clone)
+	} else {
+		array = reflect.New(arrayType)
+		for i := 0; i < slice.Len(); i++ {
+			copyScalars(array.Elem().Index(i), slice.Index(i))
+		}
+		_this.addRegion(slice.Pointer(), arrayType.Size(), array)
+	}
+	return array.Elem().Slice3(0, slice.Len(), slice.Cap()).Convert(slice.Type())
+}
+
+func (_this *reproBuilder) cloneMap(m reflect.Value) reflect.Value {
+	if clone, ok := _this.maps[m.Pointer()]; ok {
+		return clone
+	}
+	clone := reflect.MakeMap(m.Type())
+	_this.maps[m.Pointer()] = clone
+	return clone
+}
+
+func (_this *reproBuilder) findRegion(address, size uintptr) (clone unsafe.Pointer, ok bool) {
+	for _, region := range _this.regions {
+		if address >= region.start && address+size <= region.end && (size > 0 || address < region.end) {
+			return unsafe.Pointer(uintptr(region.clone) + (address - region.start)), true
+		}
+	}
+	return
+}
+
+func (_this *reproBuilder) addRegion(address, size uintptr, clone reflect.Value) {
+	_this.regions = append(_this.regions, reproRegion{
+		start: address,
+		end:   address + size,
+		clone: unsafe.Pointer(clone.Pointer()),
+		value: clone,
+	})
+}
+
+// Returns a version of value (which must be addressable) that can be set even
+// if it was reached through unexported fields.
+func settable(value reflect.Value) reflect.Value {
+	if value.CanSet() {
+		return value
+	}
+	return reflect.NewAt(value.Type(), unsafe.Pointer(value.UnsafeAddr())).Elem()
+}
+
+// Copies everything from src to dst (which must be addressable) that isn't a
+// reference to another object.
+func copyScalars(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Bool:
+		settable(dst).SetBool(src.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		settable(dst).SetInt(src.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		settable(dst).SetUint(src.Uint())
+	case reflect.Float32, reflect.Float64:
+		settable(dst).SetFloat(src.Float())
+	case reflect.Complex64, reflect.Complex128:
+		settable(dst).SetComplex(src.Complex())
+	case reflect.String:
+		settable(dst).SetString(src.String())
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			copyScalars(dst.Index(i), src.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < src.NumField(); i++ {
+			copyScalars(dst.Field(i), src.Field(i))
+		}
+	}
+}
+
+// Returns a copy of a map key that can be used with SetMapIndex even if it
+// was reached through unexported fields. Pointer keys keep pointing to the
+// original objects.
+func copyMapKey(key reflect.Value) reflect.Value {
+	if key.CanInterface() {
+		return key
+	}
+	clone := reflect.New(key.Type()).Elem()
+	if key.Kind() == reflect.Ptr && !key.IsNil() {
+		clone.Set(reflect.NewAt(key.Type().Elem(), unsafe.Pointer(key.Pointer())).Convert(key.Type()))
+	} else {
+		copyScalars(clone, key)
+	}
+	return clone
+}
@@ -0,0 +1,110 @@
+package duplicates
+
+import (
+	"testing"
+)
+
+type reproNode struct {
+	Name     string
+	Count    int
+	Shared   *reproNode
+	Others   []*reproNode
+	ByName   map[string]*reproNode
+	nameRef  *string
+	Unshared *reproNode
+}
+
+func TestExtractRepro(t *testing.T) {
+	shared := &reproNode{Name: "shared", Count: 5}
+	root := &reproNode{
+		Name:     "root",
+		Others:   []*reproNode{{Name: "a"}, {Name: "b", Shared: shared}},
+		ByName:   map[string]*reproNode{"x": shared, "y": {Name: "y"}},
+		Unshared: &reproNode{Name: "unshared"},
+	}
+
+	repro, err := ExtractRepro(root, TypedPointerOf(shared))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repro.Paths[0].String() != ".Others[1].Shared" || repro.Paths[1].String() != `.ByName["x"]` {
+		t.Errorf("Unexpected paths %v, %v", repro.Paths[0], repro.Paths[1])
+	}
+
+	clone := repro.Root.(*reproNode)
+	if clone == root || clone.Name != "root" {
+		t.Fatalf("Expected a fresh copy of the root")
+	}
+	if clone.Unshared != nil || clone.Others[0] != nil || clone.ByName["y"] != nil {
+		t.Errorf("Expected references off the paths to be dropped")
+	}
+	clonedShared := clone.Others[1].Shared
+	if clonedShared == shared || clonedShared != clone.ByName["x"] {
+		t.Errorf("Expected the copies to share a fresh object")
+	}
+	if clonedShared.Name != "shared" || clonedShared.Count != 5 {
+		t.Errorf("Expected scalar content to be copied but got %+v", clonedShared)
+	}
+	if repro.Shared != TypedPointerOf(clonedShared) {
+		t.Errorf("Expected Shared to be %v but got %v", TypedPointerOf(clonedShared), repro.Shared)
+	}
+	if !FindDuplicatePointers(repro.Root)[repro.Shared] {
+		t.Errorf("Expected the repro to demonstrate the duplicate")
+	}
+}
+
+func TestExtractReproFieldAddress(t *testing.T) {
+	root := &reproNode{Name: "root"}
+	root.nameRef = &root.Name
+
+	repro, err := ExtractRepro(root, TypedPointerOf(&root.Name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := repro.Root.(*reproNode)
+	if clone == root || clone.nameRef != &clone.Name {
+		t.Errorf("Expected the copy's nameRef to point to its own Name")
+	}
+	if repro.Shared != TypedPointerOf(&clone.Name) {
+		t.Errorf("Expected Shared to be %v but got %v", TypedPointerOf(&clone.Name), repro.Shared)
+	}
+}
+
+func TestExtractReproUnexportedMap(t *testing.T) {
+	type holder struct {
+		byName map[string]*reproNode
+		Shared *reproNode
+	}
+	shared := &reproNode{Name: "shared"}
+	root := &holder{byName: map[string]*reproNode{"x": shared}, Shared: shared}
+
+	repro, err := ExtractRepro(root, TypedPointerOf(shared))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := repro.Root.(*holder)
+	if clone.Shared == shared || clone.Shared != clone.byName["x"] {
+		t.Errorf("Expected the copies to share a fresh object")
+	}
+}
+
+func TestExtractReproSlices(t *testing.T) {
+	backing := []int{1, 2, 3, 4}
+	root := [][]int{backing[:2], backing}
+
+	repro, err := ExtractRepro(root, TypedPointerOf(backing))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := repro.Root.([][]int)
+	if len(clone[0]) != 2 || len(clone[1]) != 4 || &clone[0][0] != &clone[1][0] || &clone[1][0] == &backing[0] {
+		t.Errorf("Expected fresh slices sharing a backing array but got %v", clone)
+	}
+}
+
+func TestExtractReproNotDuplicate(t *testing.T) {
+	v := 1
+	if _, err := ExtractRepro([]*int{&v}, TypedPointerOf(&v)); err == nil {
+		t.Errorf("Expected an error for a pointer that isn't a duplicate")
+	}
+}
@@ -0,0 +1,68 @@
+package duplicates
+
+// RevisitPolicy determines whether a finder scans what an already registered
+// pointer references again when it finds the pointer again.
+type RevisitPolicy int
+
+const (
+	// Never scan below a pointer more than once.
+	RevisitNever RevisitPolicy = iota
+	// Scan below a pointer every time it is found (except when it closes a
+	// cycle), so that every path to every pointer is walked. Pointers found
+	// while revisiting are not sighted again, so they don't become
+	// duplicates, but they are counted (see OccurrenceCounts) and reported to
+	// OnPointer.
+	//
+	// Since every path is walked, scanning time grows with the number of
+	// paths rather than the number of objects, which can be exponential in
+	// graphs with a lot of sharing.
+	RevisitAlways
+)
+
+func (_this RevisitPolicy) String() string {
+	switch _this {
+	case RevisitNever:
+		return "RevisitNever"
+	case RevisitAlways:
+		return "RevisitAlways"
+	default:
+		return "RevisitPolicy(?)"
+	}
+}
+
+// OccurrenceCounts returns how many times each registered pointer was
+// reached, including while revisiting (see RevisitAlways). Without
+// revisiting, this is the same as ReferenceCounts.
+func (_this *DuplicateFinder) OccurrenceCounts() map[TypedPointer]int {
+	counts := make(map[TypedPointer]int, len(_this.records))
+	for _, record := range _this.records {
+		if !record.restricted {
+			counts[record.pointer] = int(record.sightings + record.revisits)
+		}
+	}
+	return counts
+}
+
+// Returns true if what the already registered pointer at index references
+// must be scanned again.
+func (_this *DuplicateFinder) shouldRevisit(index int) bool {
+	if _this.Revisit != RevisitAlways || index < 0 || _this.upgrading {
+		return false
+	}
+	record := &_this.records[index]
+	return !record.scanning && !record.skipped && !record.queued
+}
+
+// Marks the start of scanning what the pointer at index references again.
+func (_this *DuplicateFinder) beginRevisit(index int) {
+	referent := activeReferent{
+		index:       index,
+		wasScanning: _this.records[index].scanning,
+	}
+	_this.records[index].scanning = true
+	if !_this.revisiting {
+		_this.revisiting = true
+		referent.startedRevisit = true
+	}
+	_this.referents = append(_this.referents, referent)
+}
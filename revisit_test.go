@@ -0,0 +1,54 @@
+package duplicates
+
+import (
+	"testing"
+)
+
+type revisitNode struct {
+	Name     string
+	Children []*revisitNode
+}
+
+func TestRevisitAlways(t *testing.T) {
+	leaf := &revisitNode{Name: "leaf"}
+	shared := &revisitNode{Name: "shared", Children: []*revisitNode{leaf}}
+	root := &revisitNode{Name: "root", Children: []*revisitNode{shared, shared, shared}}
+	root.Children = append(root.Children, root)
+
+	finder := NewDuplicateFinder(WithRevisit(RevisitAlways))
+	finder.ScanForPointers(root)
+	counts := finder.OccurrenceCounts()
+	if counts[TypedPointerOf(shared)] != 3 || counts[TypedPointerOf(leaf)] != 3 {
+		t.Errorf("Expected shared and leaf to occur 3 times but got %v and %v",
+			counts[TypedPointerOf(shared)], counts[TypedPointerOf(leaf)])
+	}
+	if counts[TypedPointerOf(root)] != 2 {
+		t.Errorf("Expected the cycle not to be revisited but got %v occurrences of root", counts[TypedPointerOf(root)])
+	}
+	if finder.IsDuplicatePointer(leaf) {
+		t.Errorf("Expected revisiting not to make leaf a duplicate")
+	}
+	if !finder.IsDuplicatePointer(shared) {
+		t.Errorf("Expected shared to be a duplicate")
+	}
+
+	var edges []string
+	finder = NewDuplicateFinder(WithRevisit(RevisitAlways), WithPaths(),
+		WithOnPointer(func(ptr TypedPointer, path Path, duplicate bool) VisitAction {
+			if ptr == TypedPointerOf(leaf) {
+				edges = append(edges, path.String())
+			}
+			return VisitContinue
+		}))
+	finder.ScanForPointers(root)
+	if len(edges) != 3 || edges[2] != ".Children[2].Children[0]" {
+		t.Errorf("Expected every path to leaf to be reported but got %v", edges)
+	}
+
+	finder = NewDuplicateFinder()
+	finder.ScanForPointers(root)
+	counts = finder.OccurrenceCounts()
+	if counts[TypedPointerOf(leaf)] != 1 {
+		t.Errorf("Expected no revisiting by default")
+	}
+}
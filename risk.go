@@ -0,0 +1,55 @@
+package duplicates
+
+import (
+	"reflect"
+	"sort"
+)
+
+// RiskScore combines the properties of a shared object into a single score,
+// where higher means more likely to cause problems:
+//
+//   - Each reference beyond the first adds 1 to the base score.
+//   - Sharing that crosses root boundaries doubles the score.
+//   - Objects that can't be modified through the pointer (funcs, zero-sized
+//     values) score only a tenth as much.
+//   - Shallower objects score higher, since more of the graph depends on them:
+//     the score is divided by (1 + depth/10).
+func RiskScore(pointerType reflect.Type, fanIn int, depth int, crossesRoots bool) float64 {
+	if fanIn < 2 {
+		fanIn = 2
+	}
+	score := float64(fanIn - 1)
+	if crossesRoots {
+		score *= 2
+	}
+	if !isMutablePointerType(pointerType) {
+		score /= 10
+	}
+	return score / (1 + float64(depth)/10)
+}
+
+func (_this *pointerRecord) riskScore() float64 {
+	return RiskScore(_this.pointer.Type, int(_this.sightings), int(_this.depth), _this.crossRoot)
+}
+
+func isMutablePointerType(pointerType reflect.Type) bool {
+	switch pointerType.Kind() {
+	case reflect.Ptr:
+		return pointerType.Elem().Size() > 0
+	case reflect.Func, reflect.UnsafePointer:
+		return false
+	default:
+		return true
+	}
+}
+
+// DuplicatesByRisk returns the report's duplicates sorted by descending risk
+// score. Duplicates with the same score remain in discovery order.
+func (_this *DuplicateReport) DuplicatesByRisk() []TypedPointer {
+	sorted := make([]TypedPointer, len(_this.pointers))
+	copy(sorted, _this.pointers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return _this.infos[sorted[i]].Risk > _this.infos[sorted[j]].Risk
+	})
+	return sorted
+}
@@ -0,0 +1,39 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRiskScore(t *testing.T) {
+	intPtr := reflect.TypeOf((*int)(nil))
+	emptyPtr := reflect.TypeOf((*struct{})(nil))
+
+	if RiskScore(intPtr, 3, 0, false) <= RiskScore(intPtr, 2, 0, false) {
+		t.Errorf("Expected higher fan-in to score higher")
+	}
+	if RiskScore(intPtr, 2, 0, true) <= RiskScore(intPtr, 2, 0, false) {
+		t.Errorf("Expected cross-root sharing to score higher")
+	}
+	if RiskScore(intPtr, 2, 0, false) <= RiskScore(intPtr, 2, 5, false) {
+		t.Errorf("Expected shallower sharing to score higher")
+	}
+	if RiskScore(intPtr, 2, 0, false) <= RiskScore(emptyPtr, 2, 0, false) {
+		t.Errorf("Expected mutable objects to score higher")
+	}
+}
+
+func TestDuplicatesByRisk(t *testing.T) {
+	low := 1
+	high := 2
+	deep := &[]*int{&low, &low}
+	report := FindDuplicates([]interface{}{deep, &high, &high, &high})
+
+	sorted := report.DuplicatesByRisk()
+	if len(sorted) != 2 {
+		t.Fatalf("Expected 2 duplicates but got %v", sorted)
+	}
+	if sorted[0] != TypedPointerOf(&high) || sorted[1] != TypedPointerOf(&low) {
+		t.Errorf("Expected &high before &low but got %v", sorted)
+	}
+}
@@ -0,0 +1,37 @@
+package duplicates
+
+import (
+	"sort"
+)
+
+// Records that ptr was found from the current root, which isn't the root it
+// was first found from.
+func (_this *DuplicateFinder) addOtherRoot(ptr TypedPointer) {
+	roots := _this.otherRoots[ptr]
+	for _, root := range roots {
+		if root == _this.rootIndex {
+			return
+		}
+	}
+	if _this.otherRoots == nil {
+		_this.otherRoots = make(map[TypedPointer][]int)
+	}
+	_this.otherRoots[ptr] = append(roots, _this.rootIndex)
+}
+
+// RootsOf returns the indices of the roots (counting each call to
+// ScanForPointers) that ptr was found from, in ascending order. Objects are
+// only scanned from the root they were first found from, so the objects
+// inside a shared object are only attributed to that root; the shared
+// object itself is attributed to every root that reaches it.
+func (_this *DuplicateFinder) RootsOf(ptr TypedPointer) []int {
+	index, ok := _this.recordIndex[_this.identify(ptr)]
+	if !ok {
+		return nil
+	}
+	ptr = _this.records[index].pointer
+	roots := append([]int{int(_this.records[index].firstRoot)}, _this.otherRoots[ptr]...)
+	// Paused scans may resume earlier roots.
+	sort.Ints(roots)
+	return roots
+}
@@ -0,0 +1,49 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+)
+
+type rootsCacheEntry struct {
+	Key    string
+	State  *rootsState
+	Values []int
+}
+
+type rootsState struct {
+	Count int
+}
+
+func TestRootsOf(t *testing.T) {
+	shared := &rootsState{}
+	entries := []*rootsCacheEntry{
+		{Key: "a", State: shared},
+		{Key: "b", State: &rootsState{}},
+		{Key: "c", State: shared},
+		{Key: "d", State: shared},
+	}
+
+	finder := NewDuplicateFinder()
+	for _, entry := range entries {
+		finder.ScanForPointers(entry)
+	}
+
+	expected := map[TypedPointer][]int{
+		TypedPointerOf(shared):             {0, 2, 3},
+		TypedPointerOf(&shared.Count):      {0},
+		TypedPointerOf(entries[1].State):   {1},
+		TypedPointerOf(&entries[3].Key):    {3},
+		TypedPointerOf(&rootsCacheEntry{}): nil,
+	}
+	for ptr, roots := range expected {
+		if actual := finder.RootsOf(ptr); !reflect.DeepEqual(actual, roots) {
+			t.Errorf("Expected %v to be found from roots %v but got %v", ptr, roots, actual)
+		}
+	}
+
+	info, _ := finder.Report().Info(TypedPointerOf(shared))
+	if !reflect.DeepEqual(info.Roots, []int{0, 2, 3}) {
+		t.Errorf("Expected the report to list roots [0 2 3] but got %v", info.Roots)
+	}
+}
@@ -0,0 +1,333 @@
+package duplicates
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Continuation records where a paused scan stopped. It's for pausing within
+// the same process only: the record of everything scanned so far stays in the
+// finder and isn't part of the continuation, so a continuation can only be
+// resumed by the finder that produced it, against the same, unmodified root
+// object.
+type Continuation struct {
+	// The index of the root being scanned.
+	Root int
+	// The index of the child to continue from at each nesting level,
+	// outermost first. Struct fields are counted in scanning order, and map
+	// entries in sorted key order.
+	Positions []int
+}
+
+// Scan is a scan of a single root that can be paused and resumed, so that
+// extremely large analyses can be spread out over time. Scans created by the
+// same finder must not run concurrently.
+type Scan struct {
+	finder    *DuplicateFinder
+	root      reflect.Value
+	rootIndex int
+	positions []int
+	isDone    bool
+
+	// The number of new values to visit before pausing (0 for no limit), and
+	// how many have been visited since Run was called.
+	budget         int
+	visited        int
+	pauseRequested int32
+
+	mutex       sync.Mutex
+	isRunning   bool
+	pauseWaiter chan *Continuation
+}
+
+// NewScan prepares a pausable scan of object as a new root. Nothing is
+// scanned until Run is called.
+func (_this *DuplicateFinder) NewScan(object interface{}) *Scan {
+	rootIndex := _this.rootCount
+	_this.rootCount++
+	return &Scan{
+		finder:    _this,
+		root:      reflect.ValueOf(object),
+		rootIndex: rootIndex,
+	}
+}
+
+// Resume prepares to continue a paused scan of object from continuation.
+// object must be the same root that was being scanned when it paused.
+func (_this *DuplicateFinder) Resume(object interface{}, continuation *Continuation) *Scan {
+	if continuation.Root >= _this.rootCount {
+		panic(fmt.Errorf("duplicates: continuation refers to unknown root %v", continuation.Root))
+	}
+	return &Scan{
+		finder:    _this,
+		root:      reflect.ValueOf(object),
+		rootIndex: continuation.Root,
+		positions: append([]int(nil), continuation.Positions...),
+	}
+}
+
+// Run scans until the scan completes (returning nil), or until it is paused
+// (returning where it stopped). Calling Run again after a pause continues
+// from where it stopped.
+func (_this *Scan) Run() (continuation *Continuation) {
+	return _this.run(0)
+}
+
+// RunNodes scans until about nodeCount more values have been visited, and
+// then pauses. It returns nil if the scan completed.
+func (_this *Scan) RunNodes(nodeCount int) (continuation *Continuation) {
+	if nodeCount < 1 {
+		nodeCount = 1
+	}
+	return _this.run(nodeCount)
+}
+
+// Pause asks a scan running in another goroutine to stop, and waits for it
+// to do so, returning where it stopped (or nil if it completed). If the scan
+// isn't running, Pause returns where it last stopped.
+func (_this *Scan) Pause() (continuation *Continuation) {
+	_this.mutex.Lock()
+	if !_this.isRunning {
+		_this.mutex.Unlock()
+		return _this.continuation()
+	}
+	waiter := make(chan *Continuation, 1)
+	_this.pauseWaiter = waiter
+	atomic.StoreInt32(&_this.pauseRequested, 1)
+	_this.mutex.Unlock()
+	return <-waiter
+}
+
+// IsDone returns true if the scan has completed.
+func (_this *Scan) IsDone() bool {
+	_this.mutex.Lock()
+	defer _this.mutex.Unlock()
+	return _this.isDone
+}
+
+func (_this *Scan) continuation() *Continuation {
+	if _this.isDone {
+		return nil
+	}
+	return &Continuation{
+		Root:      _this.rootIndex,
+		Positions: append([]int(nil), _this.positions...),
+	}
+}
+
+func (_this *Scan) run(budget int) (continuation *Continuation) {
+	_this.mutex.Lock()
+	_this.isRunning = true
+	isDone := _this.isDone
+	_this.mutex.Unlock()
+
+	if !isDone {
+		_this.budget = budget
+		_this.visited = 0
+		finder := _this.finder
+		finder.scan = _this
+		finder.resumePositions = _this.positions
+		finder.resumeLevel = 0
+		finder.reentering = len(_this.positions) > 0
+		finder.paused = false
+		finder.pausePositions = finder.pausePositions[:0]
+
+		finder.scanRoot(_this.root, _this.rootIndex)
+
+		if finder.paused {
+			_this.positions = make([]int, len(finder.pausePositions))
+			for i, position := range finder.pausePositions {
+				_this.positions[len(_this.positions)-1-i] = position
+			}
+		} else {
+			_this.positions = nil
+			isDone = true
+		}
+		finder.scan = nil
+		finder.paused = false
+		finder.reentering = false
+		finder.resumePositions = nil
+	}
+
+	_this.mutex.Lock()
+	defer _this.mutex.Unlock()
+	_this.isDone = isDone
+	_this.isRunning = false
+	atomic.StoreInt32(&_this.pauseRequested, 0)
+	continuation = _this.continuation()
+	if _this.pauseWaiter != nil {
+		_this.pauseWaiter <- continuation
+		_this.pauseWaiter = nil
+	}
+	return
+}
+
+func (_this *Scan) shouldPause() bool {
+	// Always make some progress, so that every run gets further than the last.
+	if _this.visited == 0 {
+		return false
+	}
+	if _this.budget > 0 && _this.visited >= _this.budget {
+		return true
+	}
+	return atomic.LoadInt32(&_this.pauseRequested) != 0
+}
+
+// Returns the index that a loop over children should start from, which is
+// non-zero only while resuming a paused scan.
+func (_this *DuplicateFinder) loopStart() int {
+	if _this.resumeLevel >= len(_this.resumePositions) {
+		return 0
+	}
+	start := _this.resumePositions[_this.resumeLevel]
+	_this.resumeLevel++
+	_this.reentering = _this.resumeLevel < len(_this.resumePositions)
+	return start
+}
+
+// Checks whether a pausable scan should pause before scanning child i.
+func (_this *DuplicateFinder) pauseBefore(i int) bool {
+	if _this.scan == nil || _this.reentering || _this.upgrading || _this.revisiting || _this.handling > 0 {
+		return false
+	}
+	if !_this.scan.shouldPause() {
+		return false
+	}
+	_this.paused = true
+	_this.pausePositions = append(_this.pausePositions, i)
+	return true
+}
+
+// Checks whether the scan paused while scanning child i.
+func (_this *DuplicateFinder) pausedDuring(i int) bool {
+	if !_this.paused {
+		return false
+	}
+	_this.pausePositions = append(_this.pausePositions, i)
+	return true
+}
+
+// Ranks a pointer map key by when it was first found, if it has been found.
+type keyRank func(key reflect.Value) (rank int, ok bool)
+
+// Returns a ranking of the pointer keys of mapValue by the index of their
+// records. Only pointers that were found before the map itself are ranked, so
+// that the ranking (and thus the order of the map's entries) is the same
+// every time the map is visited, including when a paused scan re-enters it.
+func (_this *DuplicateFinder) keyRankOf(mapValue reflect.Value) keyRank {
+	limit, ok := _this.recordIndex[_this.typedPointerOf(mapValue)]
+	if !ok {
+		return nil
+	}
+	return func(key reflect.Value) (rank int, ok bool) {
+		rank, ok = _this.recordIndex[_this.typedPointerOf(key)]
+		return rank, ok && rank < limit
+	}
+}
+
+// A map entry, read through an iterator so that keys that can't be looked up
+// (such as NaN) keep their values.
+type mapEntry struct {
+	key   reflect.Value
+	value reflect.Value
+}
+
+// Returns the entries of a map in a deterministic order. Keys are ordered by
+// value, except that pointers (including those within struct and array keys)
+// are ordered by rank. Pointers without a rank come after those with one, in
+// address order, which isn't deterministic.
+func sortedMapEntries(value reflect.Value, rank keyRank) []mapEntry {
+	entries := make([]mapEntry, 0, value.Len())
+	var iter mapIterator
+	resetMapIterator(&iter, value)
+	for iter.Next() {
+		entries = append(entries, mapEntry{key: iter.Key(), value: iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return compareMapKeys(entries[i].key, entries[j].key, rank) < 0
+	})
+	return entries
+}
+
+func compareMapKeys(a, b reflect.Value, rank keyRank) int {
+	if a.Kind() == reflect.Interface {
+		a = a.Elem()
+	}
+	if b.Kind() == reflect.Interface {
+		b = b.Elem()
+	}
+	if a.Kind() != b.Kind() {
+		return int(a.Kind()) - int(b.Kind())
+	}
+	if !a.IsValid() {
+		return 0
+	}
+	if a.Type() != b.Type() {
+		aName, bName := a.Type().String(), b.Type().String()
+		return compareOrdered(aName < bName, aName > bName)
+	}
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compareOrdered(a.Int() < b.Int(), a.Int() > b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return compareOrdered(a.Uint() < b.Uint(), a.Uint() > b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return compareOrdered(a.Float() < b.Float(), a.Float() > b.Float())
+	case reflect.String:
+		return compareOrdered(a.String() < b.String(), a.String() > b.String())
+	case reflect.Bool:
+		return compareOrdered(!a.Bool() && b.Bool(), a.Bool() && !b.Bool())
+	case reflect.Ptr, reflect.Chan, reflect.UnsafePointer:
+		return comparePointerKeys(a, b, rank)
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if result := compareMapKeys(a.Field(i), b.Field(i), rank); result != 0 {
+				return result
+			}
+		}
+		return 0
+	case reflect.Array:
+		for i := 0; i < a.Len(); i++ {
+			if result := compareMapKeys(a.Index(i), b.Index(i), rank); result != 0 {
+				return result
+			}
+		}
+		return 0
+	}
+	aString := fmt.Sprint(a)
+	bString := fmt.Sprint(b)
+	return compareOrdered(aString < bString, aString > bString)
+}
+
+func comparePointerKeys(a, b reflect.Value, rank keyRank) int {
+	if a.Pointer() == b.Pointer() {
+		return 0
+	}
+	if rank != nil {
+		aRank, aRanked := rank(a)
+		bRank, bRanked := rank(b)
+		switch {
+		case aRanked && bRanked:
+			return compareOrdered(aRank < bRank, aRank > bRank)
+		case aRanked:
+			return -1
+		case bRanked:
+			return 1
+		}
+	}
+	return compareOrdered(a.Pointer() < b.Pointer(), a.Pointer() > b.Pointer())
+}
+
+func compareOrdered(isLess, isGreater bool) int {
+	switch {
+	case isLess:
+		return -1
+	case isGreater:
+		return 1
+	default:
+		return 0
+	}
+}
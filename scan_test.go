@@ -0,0 +1,148 @@
+package duplicates
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+type scanTestNode struct {
+	Name     *string
+	Children []*scanTestNode
+	Lookup   map[string]*scanTestNode
+	Parent   *scanTestNode
+}
+
+func newScanTestTree() *scanTestNode {
+	shared := "shared"
+	root := &scanTestNode{Name: &shared, Lookup: make(map[string]*scanTestNode)}
+	for i := 0; i < 5; i++ {
+		child := &scanTestNode{Name: &shared, Parent: root}
+		root.Children = append(root.Children, child)
+		root.Lookup[string(rune('a'+i))] = child
+	}
+	return root
+}
+
+func TestScanRunNodes(t *testing.T) {
+	tree := newScanTestTree()
+	expected := onlyDuplicates(FindDuplicatePointers(tree))
+
+	finder := NewDuplicateFinder()
+	scan := finder.NewScan(tree)
+	pauses := 0
+	for scan.RunNodes(3) != nil {
+		pauses++
+	}
+	if pauses == 0 {
+		t.Errorf("Expected the scan to pause at least once")
+	}
+	if !scan.IsDone() {
+		t.Errorf("Expected the scan to be done")
+	}
+	if !reflect.DeepEqual(finder.DuplicatePointers, expected) {
+		t.Errorf("Expected %v but got %v", expected, finder.DuplicatePointers)
+	}
+}
+
+func TestScanRunSingleNodes(t *testing.T) {
+	tree := newScanTestTree()
+	expectedFinder := NewDuplicateFinder()
+	expectedFinder.ScanForPointers(tree)
+	expected := expectedFinder.Stats().NodesVisited
+
+	for _, budget := range []int{1, 2} {
+		finder := NewDuplicateFinder()
+		scan := finder.NewScan(tree)
+		runs := 0
+		for scan.RunNodes(budget) != nil {
+			runs++
+			if runs > expected {
+				t.Fatalf("Budget %v: Expected the scan to complete within %v runs", budget, expected)
+			}
+		}
+		if !reflect.DeepEqual(finder.DuplicatePointers, expectedFinder.DuplicatePointers) {
+			t.Errorf("Budget %v: Expected %v but got %v", budget, expectedFinder.DuplicatePointers, finder.DuplicatePointers)
+		}
+		if actual := finder.Stats().NodesVisited; actual != expected {
+			t.Errorf("Budget %v: Expected %v nodes visited but got %v", budget, expected, actual)
+		}
+	}
+}
+
+type scanPointerKeys struct {
+	M map[*int]*int
+	A *int
+	B *int
+}
+
+func TestScanPointerKeys(t *testing.T) {
+	a, b := new(int), new(int)
+	root := &scanPointerKeys{M: map[*int]*int{a: b, b: a}, A: a, B: b}
+	expected := onlyDuplicates(FindDuplicatePointers(root))
+	if !expected[TypedPointerOf(a)] {
+		t.Fatalf("Expected a full scan to find a as a duplicate")
+	}
+
+	for _, budget := range []int{1, 2, 4} {
+		finder := NewDuplicateFinder()
+		scan := finder.NewScan(root)
+		for scan.RunNodes(budget) != nil {
+		}
+		if !reflect.DeepEqual(finder.DuplicatePointers, expected) {
+			t.Errorf("Budget %v: Expected %v but got %v", budget, expected, finder.DuplicatePointers)
+		}
+	}
+}
+
+type scanNaNKeys struct {
+	M map[float64]*int
+	V *int
+}
+
+func TestScanNaNKeys(t *testing.T) {
+	v := new(int)
+	root := &scanNaNKeys{M: map[float64]*int{math.NaN(): v}, V: v}
+
+	finder := NewDuplicateFinder(WithSortedMapKeys())
+	finder.ScanForPointers(root)
+	if !finder.IsDuplicatePointer(v) {
+		t.Errorf("Expected the value of a NaN key to be scanned in sorted order")
+	}
+
+	finder = NewDuplicateFinder()
+	scan := finder.NewScan(root)
+	for scan.RunNodes(1) != nil {
+	}
+	if !finder.IsDuplicatePointer(v) {
+		t.Errorf("Expected the value of a NaN key to be scanned by a pausable scan")
+	}
+}
+
+func TestScanResume(t *testing.T) {
+	tree := newScanTestTree()
+	expected := onlyDuplicates(FindDuplicatePointers(tree))
+
+	finder := NewDuplicateFinder()
+	continuation := finder.NewScan(tree).RunNodes(10)
+	for continuation != nil {
+		continuation = finder.Resume(tree, continuation).RunNodes(10)
+	}
+	if !reflect.DeepEqual(finder.DuplicatePointers, expected) {
+		t.Errorf("Expected %v but got %v", expected, finder.DuplicatePointers)
+	}
+}
+
+func TestScanPauseWhenNotRunning(t *testing.T) {
+	finder := NewDuplicateFinder()
+	scan := finder.NewScan(newScanTestTree())
+	if continuation := scan.Pause(); continuation == nil || len(continuation.Positions) != 0 {
+		t.Errorf("Expected an empty continuation but got %v", continuation)
+	}
+	if continuation := scan.Run(); continuation != nil {
+		t.Errorf("Expected the scan to complete but got %v", continuation)
+	}
+	if continuation := scan.Pause(); continuation != nil {
+		t.Errorf("Expected no continuation from a completed scan but got %v", continuation)
+	}
+}
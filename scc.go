@@ -0,0 +1,151 @@
+package duplicates
+
+import (
+	"sort"
+)
+
+// StronglyConnectedComponents scans value and partitions the pointers found
+// (including the addresses of struct fields) into strongly connected
+// components: maximal groups of pointers that can all reach each other. A
+// pointer that isn't part of any cycle forms a component of its own.
+//
+// Every component is listed before any component that refers to it, so that
+// the components can be processed (for example persisted) one unit at a
+// time, each after everything it depends on. The pointers within a component
+// are in discovery order.
+func StronglyConnectedComponents(value interface{}) (components [][]TypedPointer) {
+	finder, edges := scanReferenceEdges(value, false, false)
+	for _, members := range tarjan(adjacency(len(finder.records), edges, nil)) {
+		components = append(components, finder.pointersOf(members))
+	}
+	return
+}
+
+// Returns the pointers of the records at indices, in discovery order.
+func (_this *DuplicateFinder) pointersOf(indices []int) []TypedPointer {
+	sort.Ints(indices)
+	pointers := make([]TypedPointer, len(indices))
+	for i, index := range indices {
+		pointers[i] = _this.records[index].pointer
+	}
+	return pointers
+}
+
+// A reference from what the pointer at record from refers to, to the pointer
+// at record to.
+type referenceEdge struct {
+	from int
+	to   int
+	// The struct field holding the reference, if any.
+	field fieldRef
+	// The path node of the reference (if recording paths).
+	pathNode int32
+}
+
+// Scans value, returning every reference between the pointers found. Roots
+// are references from outside any pointer (from is -1), which are only
+// returned if includeRoots is set.
+func scanReferenceEdges(value interface{}, recordPaths, includeRoots bool) (finder *DuplicateFinder, edges []referenceEdge) {
+	finder = NewDuplicateFinder()
+	finder.RecordPaths = recordPaths
+	finder.sightingHook = func(index int) {
+		from := -1
+		if len(finder.referents) > 0 {
+			from = finder.referents[len(finder.referents)-1].index
+		} else if !includeRoots {
+			return
+		}
+		edges = append(edges, referenceEdge{
+			from:     from,
+			to:       index,
+			field:    finder.referrer,
+			pathNode: finder.pathNode,
+		})
+	}
+	finder.ScanForPointers(value)
+	return
+}
+
+// Builds the adjacency lists of nodeCount nodes from edges, leaving out any
+// edges that skip returns true for (if skip isn't nil).
+func adjacency(nodeCount int, edges []referenceEdge, skip func(edge referenceEdge) bool) [][]int {
+	adjacent := make([][]int, nodeCount)
+	for _, edge := range edges {
+		if skip == nil || !skip(edge) {
+			adjacent[edge.from] = append(adjacent[edge.from], edge.to)
+		}
+	}
+	return adjacent
+}
+
+// Finds the strongly connected components of a graph using Tarjan's
+// algorithm, driven from an explicit stack so that long chains don't exhaust
+// the goroutine stack. Components are returned in reverse topological order.
+func tarjan(edges [][]int) (components [][]int) {
+	const unvisited = -1
+	indices := make([]int, len(edges))
+	lowLinks := make([]int, len(edges))
+	onStack := make([]bool, len(edges))
+	for i := range indices {
+		indices[i] = unvisited
+	}
+	var stack []int
+	nextIndex := 0
+
+	type frame struct {
+		node int
+		next []int
+	}
+	var frames []frame
+	enter := func(node int) {
+		indices[node] = nextIndex
+		lowLinks[node] = nextIndex
+		nextIndex++
+		stack = append(stack, node)
+		onStack[node] = true
+		frames = append(frames, frame{node: node, next: edges[node]})
+	}
+
+	for root := range edges {
+		if indices[root] != unvisited {
+			continue
+		}
+		enter(root)
+		for len(frames) > 0 {
+			top := &frames[len(frames)-1]
+			node := top.node
+			if len(top.next) > 0 {
+				next := top.next[0]
+				top.next = top.next[1:]
+				if indices[next] == unvisited {
+					enter(next)
+				} else if onStack[next] && indices[next] < lowLinks[node] {
+					lowLinks[node] = indices[next]
+				}
+				continue
+			}
+
+			frames = frames[:len(frames)-1]
+			if len(frames) > 0 {
+				parent := frames[len(frames)-1].node
+				if lowLinks[node] < lowLinks[parent] {
+					lowLinks[parent] = lowLinks[node]
+				}
+			}
+			if lowLinks[node] == indices[node] {
+				var component []int
+				for {
+					last := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					onStack[last] = false
+					component = append(component, last)
+					if last == node {
+						break
+					}
+				}
+				components = append(components, component)
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,57 @@
+package duplicates
+
+import (
+	"testing"
+)
+
+type sccNode struct {
+	Name string
+	Refs []*sccNode
+}
+
+func TestStronglyConnectedComponents(t *testing.T) {
+	leaf := &sccNode{Name: "leaf"}
+	a := &sccNode{Name: "a"}
+	b := &sccNode{Name: "b"}
+	a.Refs = []*sccNode{b, leaf}
+	b.Refs = []*sccNode{a}
+
+	components := StronglyConnectedComponents(a)
+
+	componentOf := make(map[TypedPointer]int)
+	for i, component := range components {
+		for _, ptr := range component {
+			componentOf[ptr] = i
+		}
+	}
+	aComponent := componentOf[TypedPointerOf(a)]
+	if componentOf[TypedPointerOf(b)] != aComponent {
+		t.Errorf("Expected a and b to be in the same component")
+	}
+	if componentOf[TypedPointerOf(leaf)] == aComponent {
+		t.Errorf("Expected leaf to be in a component of its own")
+	}
+	if componentOf[TypedPointerOf(leaf)] > aComponent {
+		t.Errorf("Expected leaf's component to be listed before the component referring to it")
+	}
+
+	// a, &a.Refs, a.Refs, b, &b.Refs, and b.Refs
+	if members := components[aComponent]; len(members) != 6 || members[0] != TypedPointerOf(a) {
+		t.Errorf("Expected the cycle's component to hold the 6 pointers along it, starting with a, but got %v", members)
+	}
+}
+
+func TestTarjan(t *testing.T) {
+	edges := [][]int{
+		{1},
+		{2, 3},
+		{0},
+		{4},
+		{},
+	}
+	components := tarjan(edges)
+	if len(components) != 3 || len(components[0]) != 1 || components[0][0] != 4 ||
+		len(components[1]) != 1 || components[1][0] != 3 || len(components[2]) != 3 {
+		t.Errorf("Unexpected components %v", components)
+	}
+}
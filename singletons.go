@@ -0,0 +1,37 @@
+package duplicates
+
+import (
+	"reflect"
+)
+
+// AllowSharedPointers marks pointers (or maps, slices, etc) that are known
+// to be shared on purpose, such as global configs, shared loggers, or
+// time.UTC. They are still scanned, but are never reported as duplicates.
+func (_this *DuplicateFinder) AllowSharedPointers(pointers ...interface{}) {
+	if _this.allowedShared == nil {
+		_this.allowedShared = make(map[TypedPointer]bool)
+	}
+	for _, pointer := range pointers {
+		_this.allowedShared[TypedPointerOf(pointer).Raw()] = true
+	}
+}
+
+// AllowSharedTypes marks types whose instances are known to be shared on
+// purpose. Pointers of the given types, or pointing to values of them, are
+// still scanned, but are never reported as duplicates.
+func (_this *DuplicateFinder) AllowSharedTypes(types ...reflect.Type) {
+	if _this.allowedSharedTypes == nil {
+		_this.allowedSharedTypes = make(map[reflect.Type]bool)
+	}
+	for _, t := range types {
+		_this.allowedSharedTypes[t] = true
+	}
+}
+
+// Returns true if ptr was marked as shared on purpose.
+func (_this *DuplicateFinder) isAllowedShared(ptr TypedPointer) bool {
+	if _this.allowedShared[ptr.Raw()] || _this.allowedSharedTypes[ptr.Type] {
+		return true
+	}
+	return ptr.Type.Kind() == reflect.Ptr && _this.allowedSharedTypes[ptr.Type.Elem()]
+}
@@ -0,0 +1,41 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type singletonsService struct {
+	Config   *singletonsConfig
+	Location *time.Location
+	Data     *int
+}
+
+type singletonsConfig struct {
+	Name string
+}
+
+func TestAllowSharedPointers(t *testing.T) {
+	config := &singletonsConfig{Name: "global"}
+	data := 1
+	root := []*singletonsService{
+		{Config: config, Location: time.UTC, Data: &data},
+		{Config: config, Location: time.UTC, Data: &data},
+	}
+
+	finder := NewDuplicateFinder(WithAllowedSharedPointers(config), WithAllowedSharedTypes(reflect.TypeOf(time.Location{})))
+	finder.ScanForPointers(root)
+	if finder.IsDuplicatePointer(config) || finder.IsDuplicatePointer(time.UTC) {
+		t.Errorf("Expected allowed shared pointers not to be duplicates")
+	}
+	if !finder.IsDuplicatePointer(&data) {
+		t.Errorf("Expected other pointers to still be duplicates")
+	}
+	if finder.Report().Len() != 1 {
+		t.Errorf("Expected only one reported duplicate but got %v", finder.Report().Len())
+	}
+	if finder.ReferenceCounts()[TypedPointerOf(config)] != 2 {
+		t.Errorf("Expected allowed shared pointers to still be counted")
+	}
+}
@@ -0,0 +1,148 @@
+package duplicates
+
+import (
+	"reflect"
+	"sort"
+)
+
+// SliceOverlapKind describes how two slices of the same backing array
+// overlap.
+type SliceOverlapKind int
+
+const (
+	// All of Second's elements are also elements of First.
+	SliceContains SliceOverlapKind = iota
+	// Some, but not all, of Second's elements are also elements of First.
+	SlicePartial
+	// The slices have no elements in common, but Second's elements are
+	// within First's capacity, so appending to First overwrites them.
+	SliceWithinCapacity
+)
+
+func (_this SliceOverlapKind) String() string {
+	switch _this {
+	case SliceContains:
+		return "Contains"
+	case SlicePartial:
+		return "Partial"
+	case SliceWithinCapacity:
+		return "WithinCapacity"
+	default:
+		return "SliceOverlapKind(?)"
+	}
+}
+
+// SliceOverlap is a pair of registered slices whose data lies in the same
+// backing array. Since a slice is identified by the address of its first
+// element, such slices are distinct pointers unless they start at the same
+// element.
+type SliceOverlap struct {
+	// The slice whose data starts first (or the longer one if both start at
+	// the same address), and the other slice.
+	First  TypedPointer
+	Second TypedPointer
+	// The number of bytes from the start of First's data to the start of
+	// Second's.
+	Offset uintptr
+	Kind   SliceOverlapKind
+}
+
+type sliceExtent struct {
+	length   int
+	capacity int
+}
+
+// Notes the length and capacity of a sighting of a slice.
+func (_this *DuplicateFinder) recordSliceExtent(typedPtr TypedPointer, slice reflect.Value) {
+	if _this.sliceExtents == nil {
+		_this.sliceExtents = make(map[TypedPointer]sliceExtent)
+	}
+	extent := _this.sliceExtents[typedPtr]
+	if slice.Len() > extent.length {
+		extent.length = slice.Len()
+	}
+	if slice.Cap() > extent.capacity {
+		extent.capacity = slice.Cap()
+	}
+	_this.sliceExtents[typedPtr] = extent
+}
+
+// A slice's data as an address range.
+type sliceRange struct {
+	pointer TypedPointer
+	// Index into records.
+	record int
+	start  uintptr
+	// The end of the slice's elements, and the end of its capacity.
+	end         uintptr
+	capacityEnd uintptr
+}
+
+// SliceOverlaps returns every pair of registered slices that share a backing
+// array but start at different elements (or are of different types), ordered
+// by when their first slice was found. This only works if the finder had
+// RecordSliceExtents set while scanning. The longest length and capacity that
+// each slice was found with are used.
+func (_this *DuplicateFinder) SliceOverlaps() (overlaps []SliceOverlap) {
+	var ranges []sliceRange
+	for i, record := range _this.records {
+		extent, ok := _this.sliceExtents[record.pointer]
+		if !ok {
+			continue
+		}
+		elemSize := record.pointer.Type.Elem().Size()
+		if elemSize == 0 || extent.capacity == 0 {
+			continue
+		}
+		start := record.pointer.Address()
+		ranges = append(ranges, sliceRange{
+			pointer:     record.pointer,
+			record:      i,
+			start:       start,
+			end:         start + uintptr(extent.length)*elemSize,
+			capacityEnd: start + uintptr(extent.capacity)*elemSize,
+		})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].start != ranges[j].start {
+			return ranges[i].start < ranges[j].start
+		}
+		return ranges[i].end > ranges[j].end
+	})
+
+	// The records of each pair's slices, for ordering.
+	type foundOverlap struct {
+		overlap       SliceOverlap
+		first, second int
+	}
+	var found []foundOverlap
+	for i, first := range ranges {
+		for j := i + 1; j < len(ranges) && ranges[j].start < first.capacityEnd; j++ {
+			second := ranges[j]
+			overlap := SliceOverlap{
+				First:  first.pointer,
+				Second: second.pointer,
+				Offset: second.start - first.start,
+				Kind:   SliceWithinCapacity,
+			}
+			if second.start < first.end {
+				overlap.Kind = SlicePartial
+				if second.end <= first.end {
+					overlap.Kind = SliceContains
+				}
+			}
+			found = append(found, foundOverlap{overlap: overlap, first: first.record, second: second.record})
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].first != found[j].first {
+			return found[i].first < found[j].first
+		}
+		return found[i].second < found[j].second
+	})
+	for _, f := range found {
+		overlaps = append(overlaps, f.overlap)
+	}
+	return
+}
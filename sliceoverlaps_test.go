@@ -0,0 +1,54 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSliceOverlaps(t *testing.T) {
+	backing := make([]int, 10)
+	whole := backing[:8]
+	middle := backing[2:5]
+	tail := backing[6:10]
+	capped := backing[0:1:2]
+	unrelated := make([]int, 4)
+
+	finder := NewDuplicateFinder(WithSliceExtents())
+	finder.ScanForPointers([][]int{whole, middle, tail, capped, unrelated, backing[1:2:2]})
+
+	intSize := reflect.TypeOf(0).Size()
+	expected := []SliceOverlap{
+		{First: TypedPointerOf(whole), Second: TypedPointerOf(middle), Offset: 2 * intSize, Kind: SliceContains},
+		{First: TypedPointerOf(whole), Second: TypedPointerOf(tail), Offset: 6 * intSize, Kind: SlicePartial},
+		{First: TypedPointerOf(whole), Second: TypedPointerOf(backing[1:2]), Offset: intSize, Kind: SliceContains},
+		{First: TypedPointerOf(middle), Second: TypedPointerOf(tail), Offset: 4 * intSize, Kind: SliceWithinCapacity},
+	}
+	overlaps := finder.SliceOverlaps()
+	if len(overlaps) != len(expected) {
+		t.Fatalf("Expected %v but got %v", expected, overlaps)
+	}
+	for i, overlap := range overlaps {
+		if overlap != expected[i] {
+			t.Errorf("Overlap %v: Expected %v but got %v", i, expected[i], overlap)
+		}
+	}
+}
+
+func TestSliceOverlapsWithinCapacity(t *testing.T) {
+	backing := make([]byte, 4)
+	first := backing[:2]
+	second := backing[2:]
+
+	finder := NewDuplicateFinder(WithSliceExtents())
+	finder.ScanForPointers([]interface{}{second, first})
+	overlaps := finder.SliceOverlaps()
+	if len(overlaps) != 1 || overlaps[0].First != TypedPointerOf(first) || overlaps[0].Kind != SliceWithinCapacity {
+		t.Errorf("Expected %v to be within the capacity of %v but got %v", second, first, overlaps)
+	}
+
+	finder = NewDuplicateFinder()
+	finder.ScanForPointers([]interface{}{second, first})
+	if overlaps := finder.SliceOverlaps(); len(overlaps) != 0 {
+		t.Errorf("Expected no overlaps without RecordSliceExtents but got %v", overlaps)
+	}
+}
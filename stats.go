@@ -0,0 +1,73 @@
+package duplicates
+
+import (
+	"reflect"
+	"time"
+)
+
+// Stats describes the work done by all scans since a finder was initialized.
+type Stats struct {
+	// The number of values visited.
+	NodesVisited int
+	// The number of distinct pointers registered.
+	PointersRegistered int
+	// The number of distinct objects reached: the registered pointers,
+	// excluding the addresses of struct fields (which are parts of other
+	// objects).
+	Objects int
+	// The number of references to objects (pointer, map, slice, and
+	// interface values) encountered, including the reference to each root
+	// itself. This is the number of edges in the object graph.
+	References int
+	// The number of registered pointers that are duplicates.
+	Duplicates int
+	// The deepest level reached below a root (a root is at depth 0).
+	MaxDepth int
+	// The total time spent scanning.
+	Duration time.Duration
+	// The number of values visited of each kind.
+	KindCounts map[reflect.Kind]int
+}
+
+// Stats returns statistics about all scans since the finder was initialized.
+func (_this *DuplicateFinder) Stats() Stats {
+	stats := Stats{
+		NodesVisited:       _this.nodesVisited,
+		PointersRegistered: len(_this.records),
+		References:         _this.references,
+		MaxDepth:           _this.maxDepth,
+		Duration:           _this.scanDuration,
+		KindCounts:         make(map[reflect.Kind]int),
+	}
+	for _, record := range _this.records {
+		if _this.DuplicatePointers[record.pointer] {
+			stats.Duplicates++
+		}
+		if !record.fieldAddress {
+			stats.Objects++
+		}
+	}
+	for kind, count := range _this.kindCounts {
+		if count > 0 {
+			stats.KindCounts[reflect.Kind(kind)] = count
+		}
+	}
+	return stats
+}
+
+func (_this *DuplicateFinder) resetStats() {
+	_this.nodesVisited = 0
+	_this.maxDepth = 0
+	_this.references = 0
+	_this.kindCounts = [reflect.UnsafePointer + 1]int{}
+	_this.scanDuration = 0
+}
+
+// CountReachableObjects scans root and returns the number of distinct objects
+// reachable from it, and the number of references to them (see Stats).
+func CountReachableObjects(root interface{}) (objects int, references int) {
+	finder := NewDuplicateFinder()
+	finder.ScanForPointers(root)
+	stats := finder.Stats()
+	return stats.Objects, stats.References
+}
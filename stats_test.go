@@ -0,0 +1,63 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+)
+
+type statsNode struct {
+	Name  string
+	Next  *statsNode
+	Items []int
+}
+
+func TestStats(t *testing.T) {
+	last := &statsNode{Name: "last", Items: []int{1, 2}}
+	first := &statsNode{Name: "first", Next: last}
+	finder := NewDuplicateFinder()
+	finder.ScanForPointers([]*statsNode{first, last})
+
+	stats := finder.Stats()
+	if stats.Duplicates != 1 {
+		t.Errorf("Expected 1 duplicate but got %v", stats.Duplicates)
+	}
+	if stats.PointersRegistered != len(finder.records) {
+		t.Errorf("Expected %v pointers but got %v", len(finder.records), stats.PointersRegistered)
+	}
+	if stats.NodesVisited != finder.NodesVisited() {
+		t.Errorf("Expected %v nodes but got %v", finder.NodesVisited(), stats.NodesVisited)
+	}
+	// []*statsNode -> *statsNode -> statsNode -> .Next -> statsNode -> .Items
+	if stats.MaxDepth != 5 {
+		t.Errorf("Expected a max depth of 5 but got %v", stats.MaxDepth)
+	}
+	// Scalars are never visited, and nil pointers and slices are.
+	expectedKinds := map[reflect.Kind]int{
+		reflect.Slice:  3,
+		reflect.Ptr:    4,
+		reflect.Struct: 2,
+	}
+	if !reflect.DeepEqual(stats.KindCounts, expectedKinds) {
+		t.Errorf("Expected kind counts %v but got %v", expectedKinds, stats.KindCounts)
+	}
+
+	finder.Reset()
+	if stats := finder.Stats(); stats.NodesVisited != 0 || stats.MaxDepth != 0 || len(stats.KindCounts) != 0 || stats.Duration != 0 {
+		t.Errorf("Expected Reset to clear the stats but got %+v", stats)
+	}
+}
+
+func TestCountReachableObjects(t *testing.T) {
+	last := &statsNode{Name: "last", Items: []int{1, 2}}
+	first := &statsNode{Name: "first", Next: last}
+
+	// The slice, first, last, and last.Items
+	objects, references := CountReachableObjects([]*statsNode{first, last, last})
+	if objects != 4 {
+		t.Errorf("Expected 4 objects but got %v", objects)
+	}
+	// The slice, its 3 elements, first.Next, and last.Items
+	if references != 6 {
+		t.Errorf("Expected 6 references but got %v", references)
+	}
+}
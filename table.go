@@ -0,0 +1,114 @@
+package duplicates
+
+import (
+	"reflect"
+)
+
+// DuplicateTable is a frozen, read-only view of the duplicates found by a
+// DuplicateFinder, intended for the second pass of a two-pass encoder
+// (pass 1: scan; pass 2: encode).
+//
+// Every duplicate pointer is assigned a marker ID in the order in which it was
+// first discovered, starting from 0. Lookups never allocate, and the table may
+// be safely queried from multiple goroutines.
+type DuplicateTable struct {
+	pointers []TypedPointer
+	byType   map[reflect.Type]*TypeTable
+}
+
+// TypeTable holds the marker IDs for all duplicates of a single pointer type.
+// Encoders that already know the type they're working on can cache the
+// TypeTable and look up by address alone.
+type TypeTable struct {
+	markerIDs map[uintptr]int
+}
+
+// FindDuplicatesTable scans value for duplicate pointers and returns a frozen
+// table of the results. See FindDuplicatePointers.
+func FindDuplicatesTable(value interface{}) *DuplicateTable {
+	finder := NewDuplicateFinder()
+	finder.ScanForPointers(value)
+	return finder.Freeze()
+}
+
+// Freeze builds a DuplicateTable from the duplicates found so far. The table
+// does not change if the finder is used again afterwards.
+//
+// With IdentifyByContent, pointers whose content matched an earlier pointer
+// share its marker ID.
+func (_this *DuplicateFinder) Freeze() *DuplicateTable {
+	table := &DuplicateTable{
+		byType: make(map[reflect.Type]*TypeTable),
+	}
+	for _, record := range _this.reportedDuplicates() {
+		ptr := record.pointer
+		typeTable := table.byType[ptr.Type]
+		if typeTable == nil {
+			typeTable = &TypeTable{markerIDs: make(map[uintptr]int)}
+			table.byType[ptr.Type] = typeTable
+		}
+		typeTable.markerIDs[ptr.Address()] = len(table.pointers)
+		table.pointers = append(table.pointers, ptr)
+	}
+	for _, alias := range _this.contentAliases {
+		original := _this.records[alias.record].pointer
+		if markerID, isDuplicate := table.MarkerID(original); isDuplicate {
+			table.byType[alias.pointer.Type].markerIDs[alias.pointer.Address()] = markerID
+		}
+	}
+	return table
+}
+
+// Len returns the number of duplicate pointers in the table.
+func (_this *DuplicateTable) Len() int {
+	return len(_this.pointers)
+}
+
+// Pointer returns the duplicate pointer that was assigned markerID.
+// This method panics if markerID is out of range.
+func (_this *DuplicateTable) Pointer(markerID int) TypedPointer {
+	return _this.pointers[markerID]
+}
+
+// MarkerID returns the marker ID assigned to ptr, and whether ptr is a
+// duplicate at all.
+func (_this *DuplicateTable) MarkerID(ptr TypedPointer) (markerID int, isDuplicate bool) {
+	return _this.ForType(ptr.Type).MarkerID(ptr.Address())
+}
+
+// MarkerIDOf returns the marker ID of an arbitrary pointer-like object.
+// This method panics if pointer's Kind is not Chan, Func, Map, Ptr, Slice,
+// or UnsafePointer.
+func (_this *DuplicateTable) MarkerIDOf(pointer interface{}) (markerID int, isDuplicate bool) {
+	return _this.MarkerIDOfRV(reflect.ValueOf(pointer))
+}
+
+// MarkerIDOfRV returns the marker ID of the object that rv references.
+// This method panics if rv's Kind is not Chan, Func, Map, Ptr, Slice,
+// or UnsafePointer.
+func (_this *DuplicateTable) MarkerIDOfRV(rv reflect.Value) (markerID int, isDuplicate bool) {
+	return _this.ForType(rv.Type()).MarkerID(rv.Pointer())
+}
+
+// ForType returns the per-type lookup table for pointerType. If no duplicates
+// of that type exist, an empty (but usable) table is returned.
+func (_this *DuplicateTable) ForType(pointerType reflect.Type) *TypeTable {
+	if typeTable := _this.byType[pointerType]; typeTable != nil {
+		return typeTable
+	}
+	return &emptyTypeTable
+}
+
+var emptyTypeTable TypeTable
+
+// Len returns the number of duplicate pointers of this type.
+func (_this *TypeTable) Len() int {
+	return len(_this.markerIDs)
+}
+
+// MarkerID returns the marker ID assigned to the pointer at address, and
+// whether it is a duplicate at all.
+func (_this *TypeTable) MarkerID(address uintptr) (markerID int, isDuplicate bool) {
+	markerID, isDuplicate = _this.markerIDs[address]
+	return
+}
@@ -0,0 +1,65 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDuplicateTable(t *testing.T) {
+	v1 := 1
+	v2 := 2
+	v3 := 3
+	slice := []*int{&v1, &v2, &v1, &v3, &v3, &v2}
+
+	finder := NewDuplicateFinder()
+	finder.ScanForPointers(slice)
+	table := finder.Freeze()
+
+	if table.Len() != 3 {
+		t.Fatalf("Expected 3 duplicates but got %v", table.Len())
+	}
+	for expectedID, ptr := range []*int{&v1, &v2, &v3} {
+		markerID, isDuplicate := table.MarkerIDOf(ptr)
+		if !isDuplicate || markerID != expectedID {
+			t.Errorf("Expected marker ID %v but got %v (%v)", expectedID, markerID, isDuplicate)
+		}
+		if table.Pointer(markerID) != TypedPointerOf(ptr) {
+			t.Errorf("Pointer(%v) does not match %v", markerID, ptr)
+		}
+	}
+
+	v4 := 4
+	if _, isDuplicate := table.MarkerIDOf(&v4); isDuplicate {
+		t.Errorf("Expected &v4 to not be a duplicate")
+	}
+	if _, isDuplicate := table.MarkerIDOf(&slice); isDuplicate {
+		t.Errorf("Expected &slice to not be a duplicate")
+	}
+}
+
+func TestDuplicateTableForType(t *testing.T) {
+	v := "x"
+	m := map[int]*string{1: &v, 2: &v}
+	table := FindDuplicatesTable(m)
+
+	typeTable := table.ForType(reflect.TypeOf(&v))
+	if markerID, isDuplicate := typeTable.MarkerID(reflect.ValueOf(&v).Pointer()); !isDuplicate || markerID != 0 {
+		t.Errorf("Expected marker ID 0 but got %v (%v)", markerID, isDuplicate)
+	}
+	if table.ForType(reflect.TypeOf(m)).Len() != 0 {
+		t.Errorf("Expected no duplicates of type %v", reflect.TypeOf(m))
+	}
+}
+
+func TestDuplicateTableNoAllocs(t *testing.T) {
+	v := 1
+	other := 2
+	table := FindDuplicatesTable([]*int{&v, &v})
+	allocs := testing.AllocsPerRun(100, func() {
+		table.MarkerIDOf(&v)
+		table.MarkerIDOf(&other)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations but got %v", allocs)
+	}
+}
@@ -0,0 +1,93 @@
+package duplicates
+
+import (
+	"reflect"
+)
+
+// TraversalOrder determines the order in which a finder visits the objects
+// reachable from a root.
+type TraversalOrder int
+
+const (
+	// Scan what each pointer references as soon as the pointer is found.
+	DepthFirst TraversalOrder = iota
+	// Scan what each pointer references only after everything closer to the
+	// root has been scanned, so that the first sighting of every pointer
+	// (and thus its path and discovery order) is as shallow as possible.
+	// Structs and arrays held by value are still scanned as they are found.
+	//
+	// Cycles are only detected where an object refers back to itself, since
+	// an object's ancestors are no longer being scanned when it is. Pausable
+	// scans (see NewScan) always scan depth first.
+	BreadthFirst
+)
+
+func (_this TraversalOrder) String() string {
+	switch _this {
+	case DepthFirst:
+		return "DepthFirst"
+	case BreadthFirst:
+		return "BreadthFirst"
+	default:
+		return "TraversalOrder(?)"
+	}
+}
+
+// A pointer whose referent will be scanned later, in breadth-first order.
+type queuedReferent struct {
+	// Index into records.
+	index int
+	value reflect.Value
+	// The scan position of the pointer.
+	depth    int
+	pathNode int32
+	steps    []PathStep
+}
+
+// Returns true if the referent of a newly found pointer must be queued rather
+// than scanned right away.
+func (_this *DuplicateFinder) isQueueing() bool {
+	return _this.Order == BreadthFirst && _this.scan == nil && !_this.upgrading
+}
+
+func (_this *DuplicateFinder) queueReferent(index int, value reflect.Value) {
+	referent := queuedReferent{
+		index:    index,
+		value:    value,
+		depth:    _this.depth,
+		pathNode: _this.pathNode,
+	}
+	if _this.containing {
+		referent.steps = append([]PathStep(nil), _this.steps...)
+	}
+	_this.records[index].queued = true
+	_this.queue = append(_this.queue, referent)
+}
+
+// Scans the referents of all queued pointers, including any queued while
+// doing so.
+func (_this *DuplicateFinder) scanQueue() {
+	for i := 0; i < len(_this.queue); i++ {
+		referent := _this.queue[i]
+		record := &_this.records[referent.index]
+		record.queued = false
+		_this.depth = referent.depth
+		_this.pathNode = referent.pathNode
+		_this.exported = record.exportedPath
+		_this.referrer = fieldRef{}
+		if _this.containing {
+			_this.steps = append(_this.steps[:0], referent.steps...)
+		}
+		_this.beginReferent(referent.index, false)
+		_this.scanElements(referent.value)
+		_this.endReferent()
+	}
+	_this.clearQueue()
+}
+
+func (_this *DuplicateFinder) clearQueue() {
+	for _, referent := range _this.queue {
+		_this.records[referent.index].queued = false
+	}
+	_this.queue = _this.queue[:0]
+}
@@ -0,0 +1,60 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+)
+
+type traversalNode struct {
+	Name string
+	Next *traversalNode
+	Self *traversalNode
+}
+
+type traversalRoot struct {
+	Deep    *traversalNode
+	Shallow *traversalNode
+}
+
+func TestBreadthFirst(t *testing.T) {
+	target := &traversalNode{Name: "target"}
+	target.Self = target
+	root := &traversalRoot{
+		Deep:    &traversalNode{Next: &traversalNode{Next: target}},
+		Shallow: target,
+	}
+
+	depthFirst := NewDuplicateFinder(WithPaths())
+	depthFirst.ScanForPointers(root)
+	if path, _ := depthFirst.PathTo(TypedPointerOf(target)); path.String() != ".Deep.Next.Next" {
+		t.Errorf("Expected depth first path .Deep.Next.Next but got %v", path)
+	}
+
+	breadthFirst := NewDuplicateFinder(WithPaths(), WithOrder(BreadthFirst))
+	breadthFirst.ScanForPointers(root)
+	if path, _ := breadthFirst.PathTo(TypedPointerOf(target)); path.String() != ".Shallow" {
+		t.Errorf("Expected breadth first path .Shallow but got %v", path)
+	}
+	if !reflect.DeepEqual(breadthFirst.DuplicatePointers, depthFirst.DuplicatePointers) {
+		t.Errorf("Expected the same duplicates in both orders")
+	}
+	if !breadthFirst.IsCycle(TypedPointerOf(target)) {
+		t.Errorf("Expected a self reference to be detected as a cycle")
+	}
+}
+
+func TestBreadthFirstMaxNodes(t *testing.T) {
+	root := &traversalRoot{Deep: &traversalNode{Next: &traversalNode{}}, Shallow: &traversalNode{}}
+
+	finder := NewDuplicateFinder(WithOrder(BreadthFirst), WithMaxNodes(4))
+	finder.ScanForPointers(root)
+	if !finder.Truncated() || len(finder.queue) != 0 {
+		t.Errorf("Expected a stopped scan to clear its queue")
+	}
+	finder.MaxNodes = 0
+	finder.Reset()
+	finder.ScanForPointers([]*traversalRoot{root, root})
+	if !finder.IsDuplicatePointer(root) || finder.Stats().Objects != 5 {
+		t.Errorf("Expected the finder to remain usable")
+	}
+}
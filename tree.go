@@ -0,0 +1,63 @@
+package duplicates
+
+// TreeNode is a node in a DuplicateReport's tree view, representing a path
+// prefix. Pointer dereferences and interfaces don't get nodes of their own,
+// since they are implicit in rendered paths.
+type TreeNode struct {
+	// The rendered step from the parent node, for example ".Servers" or
+	// "[2]". This is empty for the root node.
+	Label string
+	// The rendered path from the root to this node.
+	Path string
+	// The number of labeled steps from the root.
+	Depth int
+	// The duplicates first found exactly at this node's path.
+	Duplicates []TypedPointer
+	// The number of duplicates found at or below this node.
+	Count    int
+	Children []*TreeNode
+}
+
+// Tree organizes the report's duplicates hierarchically by the path at which
+// they were first found, so that a UI can progressively expand from top-level
+// fields down to the individual shared objects. Children are in the order in
+// which their first duplicates were discovered.
+//
+// This only works if the finder had RecordPaths set while scanning; otherwise
+// the tree contains only an empty root node.
+func (_this *DuplicateReport) Tree() *TreeNode {
+	root := &TreeNode{}
+	for _, ptr := range _this.pointers {
+		path, ok := _this.paths[ptr]
+		if !ok {
+			continue
+		}
+		node := root
+		node.Count++
+		for _, step := range path.Steps() {
+			label := step.String()
+			if label == "" {
+				continue
+			}
+			node = node.child(label)
+			node.Count++
+		}
+		node.Duplicates = append(node.Duplicates, ptr)
+	}
+	return root
+}
+
+func (_this *TreeNode) child(label string) *TreeNode {
+	for _, child := range _this.Children {
+		if child.Label == label {
+			return child
+		}
+	}
+	child := &TreeNode{
+		Label: label,
+		Path:  _this.Path + label,
+		Depth: _this.Depth + 1,
+	}
+	_this.Children = append(_this.Children, child)
+	return child
+}
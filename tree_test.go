@@ -0,0 +1,60 @@
+package duplicates
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type treeServer struct {
+	TLS     *string
+	Backend *string
+}
+
+type treeConfig struct {
+	Servers []treeServer
+	Default *string
+}
+
+func describeTree(node *TreeNode, builder *strings.Builder) {
+	builder.WriteString(fmt.Sprintf("%v%q %v %v\n", strings.Repeat("  ", node.Depth), node.Path, node.Count, len(node.Duplicates)))
+	for _, child := range node.Children {
+		describeTree(child, builder)
+	}
+}
+
+func TestReportTree(t *testing.T) {
+	tls := "tls"
+	backend := "backend"
+	config := &treeConfig{
+		Servers: []treeServer{
+			{TLS: &tls, Backend: &backend},
+			{TLS: &tls},
+		},
+		Default: &backend,
+	}
+
+	finder := NewDuplicateFinder()
+	finder.RecordPaths = true
+	finder.ScanForPointers(config)
+	builder := &strings.Builder{}
+	describeTree(finder.Report().Tree(), builder)
+
+	expected := `"" 2 0
+  ".Servers" 2 0
+    ".Servers[0]" 2 0
+      ".Servers[0].TLS" 1 1
+      ".Servers[0].Backend" 1 1
+`
+	if builder.String() != expected {
+		t.Errorf("Expected\n%v\nbut got\n%v", expected, builder.String())
+	}
+}
+
+func TestReportTreeWithoutPaths(t *testing.T) {
+	v := 1
+	tree := FindDuplicates([]*int{&v, &v}).Tree()
+	if tree.Count != 0 || len(tree.Children) != 0 {
+		t.Errorf("Expected an empty tree without recorded paths")
+	}
+}
@@ -0,0 +1,141 @@
+package duplicates
+
+import (
+	"reflect"
+	"strings"
+)
+
+// TypePathStepKind identifies how a TypePathStep moves from one type to the
+// next.
+type TypePathStepKind int
+
+const (
+	// A struct field.
+	StepField TypePathStepKind = iota
+	// The value a pointer points to.
+	StepPointerElem
+	// Any element of a slice or array.
+	StepElem
+	// Any key of a map.
+	StepMapKey
+	// Any value of a map.
+	StepMapValue
+	// The dynamic value held in an interface. This only appears in Paths,
+	// since the dynamic type is unknown statically.
+	StepTypeAssertion
+)
+
+// TypePathStep is a single step in a TypePath.
+type TypePathStep struct {
+	Kind TypePathStepKind
+	// The field name and index (for StepField only).
+	Field      string
+	FieldIndex int
+}
+
+// TypePath is a static path through a type's structure, leading to a location
+// that can hold a referential value (pointer, map, slice, chan, func, unsafe
+// pointer, or interface).
+type TypePath struct {
+	Steps []TypePathStep
+	// The type of the value at the end of the path.
+	Type reflect.Type
+	// True if Type (or what it points to) already appears further up the
+	// path, meaning that the type is recursive and the path was not followed
+	// any further.
+	Recursive bool
+}
+
+// String renders the path in a Go-like syntax, where "[]" means any element
+// of a slice, array or map, "{}" means any map key, and pointer
+// dereferences are implicit. For example: ".Servers[].TLSConfig".
+func (_this TypePath) String() string {
+	builder := strings.Builder{}
+	for _, step := range _this.Steps {
+		switch step.Kind {
+		case StepField:
+			builder.WriteString(".")
+			builder.WriteString(step.Field)
+		case StepElem, StepMapValue:
+			builder.WriteString("[]")
+		case StepMapKey:
+			builder.WriteString("{}")
+		}
+	}
+	return builder.String()
+}
+
+// PointerPaths statically enumerates every path through t that leads to a
+// location that can hold a referential value, and thus could potentially
+// participate in sharing. Paths are listed depth-first, in field order.
+// Interfaces are listed but not descended into, since their dynamic types
+// are unknown.
+func PointerPaths(t reflect.Type) []TypePath {
+	walker := typePathWalker{}
+	walker.walk(t)
+	return walker.paths
+}
+
+type typePathWalker struct {
+	steps []TypePathStep
+	types []reflect.Type
+	paths []TypePath
+}
+
+func (_this *typePathWalker) walk(t reflect.Type) {
+	isRecursive := false
+	for _, ancestor := range _this.types {
+		if ancestor == t || (t.Kind() == reflect.Ptr && ancestor == t.Elem()) {
+			isRecursive = true
+			break
+		}
+	}
+
+	if isReferentialKind(t.Kind()) && len(_this.steps) > 0 {
+		steps := make([]TypePathStep, len(_this.steps))
+		copy(steps, _this.steps)
+		_this.paths = append(_this.paths, TypePath{
+			Steps:     steps,
+			Type:      t,
+			Recursive: isRecursive,
+		})
+	}
+	if isRecursive {
+		return
+	}
+
+	_this.types = append(_this.types, t)
+	switch t.Kind() {
+	case reflect.Ptr:
+		_this.step(TypePathStep{Kind: StepPointerElem}, t.Elem())
+	case reflect.Slice, reflect.Array:
+		_this.step(TypePathStep{Kind: StepElem}, t.Elem())
+	case reflect.Map:
+		_this.step(TypePathStep{Kind: StepMapKey}, t.Key())
+		_this.step(TypePathStep{Kind: StepMapValue}, t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			_this.step(TypePathStep{Kind: StepField, Field: field.Name, FieldIndex: i}, field.Type)
+		}
+	}
+	_this.types = _this.types[:len(_this.types)-1]
+}
+
+func (_this *typePathWalker) step(step TypePathStep, t reflect.Type) {
+	_this.steps = append(_this.steps, step)
+	_this.walk(t)
+	_this.steps = _this.steps[:len(_this.steps)-1]
+}
+
+const referentialKinds uint = (uint(1) << reflect.Interface) |
+	(uint(1) << reflect.Ptr) |
+	(uint(1) << reflect.Slice) |
+	(uint(1) << reflect.Map) |
+	(uint(1) << reflect.Chan) |
+	(uint(1) << reflect.Func) |
+	(uint(1) << reflect.UnsafePointer)
+
+func isReferentialKind(kind reflect.Kind) bool {
+	return referentialKinds&(uint(1)<<kind) != 0
+}
@@ -0,0 +1,48 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+)
+
+type typePathServer struct {
+	Name      string
+	TLSConfig *typePathTLS
+	Next      *typePathServer
+}
+
+type typePathTLS struct {
+	Certs map[string][]byte
+}
+
+type typePathConfig struct {
+	Port    int
+	Servers []typePathServer
+	Extra   interface{}
+}
+
+func TestPointerPaths(t *testing.T) {
+	paths := PointerPaths(reflect.TypeOf(typePathConfig{}))
+	expected := []string{
+		".Servers",
+		".Servers[].TLSConfig",
+		".Servers[].TLSConfig.Certs",
+		".Servers[].TLSConfig.Certs[]",
+		".Servers[].Next",
+		".Extra",
+	}
+	if len(paths) != len(expected) {
+		t.Fatalf("Expected %v but got %v", expected, paths)
+	}
+	for i, path := range paths {
+		if path.String() != expected[i] {
+			t.Errorf("Expected %v but got %v", expected[i], path)
+		}
+	}
+	if !paths[4].Recursive {
+		t.Errorf("Expected %v to be recursive", paths[4])
+	}
+	if paths[1].Type != reflect.TypeOf(&typePathTLS{}) {
+		t.Errorf("Expected type %v but got %v", reflect.TypeOf(&typePathTLS{}), paths[1].Type)
+	}
+}
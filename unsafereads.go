@@ -0,0 +1,34 @@
+package duplicates
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// Returns a view of value (which was reached through unexported fields) that
+// reflection allows full use of, or value itself if there is none. The view
+// refers to the same memory wherever value is addressable or is itself a
+// reference, so the identities of everything it leads to are unchanged.
+func readableView(value reflect.Value) reflect.Value {
+	if value.CanAddr() {
+		return reflect.NewAt(value.Type(), unsafe.Pointer(value.UnsafeAddr())).Elem()
+	}
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Chan, reflect.UnsafePointer:
+		// All of these are a single pointer in memory.
+		pointer := unsafe.Pointer(value.Pointer())
+		return reflect.NewAt(value.Type(), unsafe.Pointer(&pointer)).Elem()
+	}
+	return value
+}
+
+// Returns an addressable copy of value, or value itself if reflection doesn't
+// allow copying it.
+func addressableCopy(value reflect.Value) reflect.Value {
+	if !value.CanInterface() {
+		return value
+	}
+	copied := reflect.New(value.Type()).Elem()
+	copied.Set(value)
+	return copied
+}
@@ -0,0 +1,72 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+type unsafeReadsSecret struct {
+	value *int
+}
+
+type unsafeReadsHolder struct {
+	secret unsafeReadsSecret
+	byName map[string]*int
+}
+
+func TestUnsafeReads(t *testing.T) {
+	v := 1
+	root := unsafeReadsHolder{
+		secret: unsafeReadsSecret{value: &v},
+		byName: map[string]*int{"v": &v},
+	}
+
+	var handled interface{}
+	finder := NewDuplicateFinder(WithRetainValues(), WithUnsafeReads())
+	finder.RegisterTypeHandler(reflect.TypeOf(unsafeReadsSecret{}), func(finder *DuplicateFinder, value reflect.Value) {
+		handled = value.Interface()
+		finder.ScanChild(0, value.Field(0))
+	})
+	finder.ScanForPointers(root)
+	if handled != root.secret {
+		t.Errorf("Expected the handler to be able to use the value")
+	}
+	if !finder.IsDuplicatePointer(&v) {
+		t.Errorf("Expected &v to be a duplicate")
+	}
+	for _, ptr := range []TypedPointer{TypedPointerOf(&v), TypedPointerOf(root.byName)} {
+		if value, ok := finder.ResolvePointer(ptr); !ok || !value.CanInterface() {
+			t.Errorf("Expected %v to resolve to a usable value", ptr)
+		}
+	}
+}
+
+type unsafeReadsEnumerableRoot struct {
+	pool enumerablePool
+}
+
+func TestUnsafeReadsCopiedRoot(t *testing.T) {
+	a, dead := 1, 2
+	root := unsafeReadsEnumerableRoot{pool: enumerablePool{
+		slots: []unsafe.Pointer{unsafe.Pointer(&a), unsafe.Pointer(&dead)},
+		live:  []int{0},
+	}}
+
+	finder := NewDuplicateFinder(WithUnsafePointers())
+	finder.ScanForPointers([]interface{}{root, unsafe.Pointer(&dead)})
+	if !finder.IsDuplicatePointer(unsafe.Pointer(&dead)) {
+		t.Errorf("Expected the fields of a non-addressable value to be scanned")
+	}
+
+	finder = NewDuplicateFinder(WithUnsafePointers(), WithUnsafeReads())
+	finder.ScanForPointers(root)
+	finder.ScanForPointers(&a)
+	finder.ScanForPointers(unsafe.Pointer(&dead))
+	if !finder.IsDuplicatePointer(&a) || finder.IsDuplicatePointer(unsafe.Pointer(&dead)) {
+		t.Errorf("Expected the copied root to be enumerated")
+	}
+	if finder.CopiedRoots() != 1 {
+		t.Errorf("Expected 1 copied root but got %v", finder.CopiedRoots())
+	}
+}
@@ -0,0 +1,193 @@
+package duplicates
+
+import (
+	"reflect"
+	"sort"
+)
+
+// PackageDuplicates lists the duplicates whose types are declared in one
+// package.
+type PackageDuplicates struct {
+	// The import path of the package, or "" for predeclared types.
+	Package  string
+	Pointers []TypedPointer
+}
+
+// DuplicatesByPackage groups the report's duplicates by the package that
+// declares the shared object's type, with the packages responsible for the
+// most sharing listed first. For unnamed types (such as *[]pkg.Thing), the
+// named type they're composed of is used.
+func (_this *DuplicateReport) DuplicatesByPackage() []PackageDuplicates {
+	indices := make(map[string]int)
+	var groups []PackageDuplicates
+	for _, ptr := range _this.pointers {
+		pkg := declaringPackage(ptr.Type)
+		index, ok := indices[pkg]
+		if !ok {
+			index = len(groups)
+			indices[pkg] = index
+			groups = append(groups, PackageDuplicates{Package: pkg})
+		}
+		groups[index].Pointers = append(groups[index].Pointers, ptr)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if len(groups[i].Pointers) != len(groups[j].Pointers) {
+			return len(groups[i].Pointers) > len(groups[j].Pointers)
+		}
+		return groups[i].Package < groups[j].Package
+	})
+	return groups
+}
+
+// DuplicatesByType groups the report's duplicates by pointer type. Each
+// type's duplicates are in discovery order.
+func (_this *DuplicateReport) DuplicatesByType() map[reflect.Type][]TypedPointer {
+	groups := make(map[reflect.Type][]TypedPointer)
+	for _, ptr := range _this.pointers {
+		groups[ptr.Type] = append(groups[ptr.Type], ptr)
+	}
+	return groups
+}
+
+// Returns the package declaring the named type that t is built from.
+func declaringPackage(t reflect.Type) string {
+	for t.Name() == "" {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+			t = t.Elem()
+		default:
+			return ""
+		}
+	}
+	return t.PkgPath()
+}
+
+// FieldStat counts how often a struct field referred to duplicated objects.
+type FieldStat struct {
+	Struct reflect.Type
+	Field  string
+	// The number of references from this field (across all instances of the
+	// struct) to objects that turned out to be duplicates.
+	Count int
+}
+
+func (_this *DuplicateFinder) fieldStats() (stats []FieldStat) {
+	indices := make(map[fieldRef]int)
+	for _, sighting := range _this.fieldSightings {
+		if !_this.DuplicatePointers[_this.records[sighting.record].pointer] {
+			continue
+		}
+		index, ok := indices[sighting.field]
+		if !ok {
+			index = len(stats)
+			indices[sighting.field] = index
+			stats = append(stats, FieldStat{
+				Struct: sighting.field.container,
+				Field:  _this.plans.planFor(sighting.field.container).fieldNames[sighting.field.index],
+			})
+		}
+		stats[index].Count++
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].Count > stats[j].Count
+	})
+	return
+}
+
+// FieldStats returns, for every struct field that referred to a duplicated
+// object, how many such references it made, highest first. This highlights
+// the specific fields in a schema that introduce sharing. This only works if
+// the finder had RecordFieldStats set while scanning.
+func (_this *DuplicateReport) FieldStats() []FieldStat {
+	return _this.fieldStats
+}
+
+// RootFieldDuplicate is a duplicate found within a root field's subtree.
+type RootFieldDuplicate struct {
+	Pointer TypedPointer
+	// True if the duplicate was also found within other root fields'
+	// subtrees.
+	CrossField bool
+}
+
+// RootFieldDuplicates lists the duplicates found within one top-level field
+// of the root.
+type RootFieldDuplicates struct {
+	Field      string
+	Duplicates []RootFieldDuplicate
+}
+
+// DuplicatesByRootField scans root (a struct, or a pointer to one) and buckets
+// its duplicates by which of the root's fields they were found under, so that
+// the owners of each subsystem can see the sharing within their part of a
+// large object. Duplicates found under more than one field are listed under
+// each of them, flagged as CrossField. Fields are listed in declaration
+// order, and fields without duplicates are omitted.
+func DuplicatesByRootField(root interface{}) []RootFieldDuplicates {
+	finder := NewDuplicateFinder()
+	finder.RecordPaths = true
+
+	var rootType reflect.Type
+	fieldsByRecord := make(map[int][]int)
+	finder.sightingHook = func(index int) {
+		container, field := finder.rootField(finder.pathNode)
+		if container == nil {
+			return
+		}
+		rootType = container
+		for _, existing := range fieldsByRecord[index] {
+			if existing == field {
+				return
+			}
+		}
+		fieldsByRecord[index] = append(fieldsByRecord[index], field)
+	}
+	finder.ScanForPointers(root)
+	if rootType == nil {
+		return nil
+	}
+
+	byField := make([][]RootFieldDuplicate, rootType.NumField())
+	for index, record := range finder.records {
+		if !finder.DuplicatePointers[record.pointer] {
+			continue
+		}
+		fields := fieldsByRecord[index]
+		for _, field := range fields {
+			byField[field] = append(byField[field], RootFieldDuplicate{
+				Pointer:    record.pointer,
+				CrossField: len(fields) > 1,
+			})
+		}
+	}
+
+	var results []RootFieldDuplicates
+	plan := finder.plans.planFor(rootType)
+	for field, duplicates := range byField {
+		if len(duplicates) > 0 {
+			results = append(results, RootFieldDuplicates{
+				Field:      plan.fieldNames[field],
+				Duplicates: duplicates,
+			})
+		}
+	}
+	return results
+}
+
+// Returns the root struct field that the path at node goes through, or a nil
+// container if the root isn't a struct (reached only through pointers and
+// interfaces) or the path is to the root itself.
+func (_this *DuplicateFinder) rootField(node int32) (container reflect.Type, field int) {
+	for ; node != noPathNode; node = _this.pathNodes[node].parent {
+		step := _this.pathNodes[node].step
+		switch step.Kind {
+		case StepField:
+			container = step.Container
+			field = step.Index
+		case StepPointerElem, StepTypeAssertion:
+		default:
+			container = nil
+		}
+	}
+	return
+}
@@ -0,0 +1,121 @@
+package duplicates
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestDuplicatesByPackage(t *testing.T) {
+	u1 := &url.URL{}
+	u2 := &url.URL{}
+	s := &SomeStruct{}
+	i := 1
+	report := FindDuplicates([]interface{}{u1, u1, u2, u2, s, s, &i, &i})
+
+	groups := report.DuplicatesByPackage()
+	expected := []struct {
+		pkg   string
+		count int
+	}{
+		{"net/url", 2},
+		{"", 1},
+		{"github.com/kstenerud/go-duplicates", 1},
+	}
+	if len(groups) != len(expected) {
+		t.Fatalf("Expected %v groups but got %v", len(expected), groups)
+	}
+	for index, group := range groups {
+		if group.Package != expected[index].pkg || len(group.Pointers) != expected[index].count {
+			t.Errorf("Expected %v %v but got %v %v", expected[index].pkg, expected[index].count, group.Package, len(group.Pointers))
+		}
+	}
+}
+
+func TestDuplicatesByType(t *testing.T) {
+	u1 := &url.URL{}
+	u2 := &url.URL{}
+	i := 1
+	report := FindDuplicates([]interface{}{u1, &i, u1, u2, u2, &i})
+
+	groups := report.DuplicatesByType()
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 types but got %v", groups)
+	}
+	urls := groups[reflect.TypeOf(u1)]
+	if len(urls) != 2 || urls[0] != TypedPointerOf(u1) || urls[1] != TypedPointerOf(u2) {
+		t.Errorf("Expected the URLs in discovery order but got %v", urls)
+	}
+	if ints := groups[reflect.TypeOf(&i)]; len(ints) != 1 || ints[0] != TypedPointerOf(&i) {
+		t.Errorf("Expected &i but got %v", ints)
+	}
+}
+
+type fieldStatsNode struct {
+	Owner  *SomeStruct
+	Backup *SomeStruct
+	Unique *SomeStruct
+}
+
+func TestFieldStats(t *testing.T) {
+	shared := &SomeStruct{}
+	nodes := []fieldStatsNode{
+		{Owner: shared, Unique: &SomeStruct{}},
+		{Owner: shared, Backup: shared},
+	}
+
+	finder := NewDuplicateFinder()
+	finder.RecordFieldStats = true
+	finder.ScanForPointers(nodes)
+	stats := finder.Report().FieldStats()
+
+	if len(stats) != 2 {
+		t.Fatalf("Expected 2 field stats but got %v", stats)
+	}
+	if stats[0].Field != "Owner" || stats[0].Count != 2 || stats[0].Struct != reflect.TypeOf(fieldStatsNode{}) {
+		t.Errorf("Unexpected first stat %v", stats[0])
+	}
+	if stats[1].Field != "Backup" || stats[1].Count != 1 {
+		t.Errorf("Unexpected second stat %v", stats[1])
+	}
+
+	if stats := FindDuplicates(nodes).FieldStats(); len(stats) != 0 {
+		t.Errorf("Expected no field stats when not recorded but got %v", stats)
+	}
+}
+
+type rootFieldUsers struct {
+	Admin *string
+	Staff []*string
+}
+
+type rootFieldRoot struct {
+	Users   rootFieldUsers
+	Billing map[string]*string
+	Audit   *string
+}
+
+func TestDuplicatesByRootField(t *testing.T) {
+	admin := "admin"
+	local := "local"
+	root := &rootFieldRoot{
+		Users:   rootFieldUsers{Admin: &admin, Staff: []*string{&admin, &local, &local}},
+		Billing: map[string]*string{"owner": &admin},
+	}
+
+	results := DuplicatesByRootField(root)
+	if len(results) != 2 || results[0].Field != "Users" || results[1].Field != "Billing" {
+		t.Fatalf("Expected duplicates under Users and Billing but got %v", results)
+	}
+	expectedUsers := []RootFieldDuplicate{
+		{Pointer: TypedPointerOf(&admin), CrossField: true},
+		{Pointer: TypedPointerOf(&local), CrossField: false},
+	}
+	if !reflect.DeepEqual(results[0].Duplicates, expectedUsers) {
+		t.Errorf("Expected %v but got %v", expectedUsers, results[0].Duplicates)
+	}
+	expectedBilling := []RootFieldDuplicate{{Pointer: TypedPointerOf(&admin), CrossField: true}}
+	if !reflect.DeepEqual(results[1].Duplicates, expectedBilling) {
+		t.Errorf("Expected %v but got %v", expectedBilling, results[1].Duplicates)
+	}
+}
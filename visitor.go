@@ -0,0 +1,33 @@
+package duplicates
+
+// VisitAction tells the scan how to proceed after an OnPointer callback.
+type VisitAction int
+
+const (
+	// Carry on scanning as usual.
+	VisitContinue VisitAction = iota
+	// Don't scan what the pointer references. This only has an effect on
+	// the first sighting of a pointer, since later sightings are never
+	// scanned into anyway.
+	VisitSkip
+	// Stop the scan, keeping the results found so far and marking them as
+	// truncated (see Truncated).
+	VisitStop
+)
+
+// Calls OnPointer for a sighting of the pointer at index, returning true if
+// what it references should not be scanned.
+func (_this *DuplicateFinder) visitOnPointer(typedPtr TypedPointer, index int, isNew bool) (skip bool) {
+	record := &_this.records[index]
+	switch _this.OnPointer(typedPtr, _this.pathAt(_this.pathNode), _this.DuplicatePointers[record.pointer]) {
+	case VisitSkip:
+		if isNew {
+			record.skipped = true
+			return true
+		}
+	case VisitStop:
+		_this.truncated = true
+		_this.stopScan()
+	}
+	return false
+}
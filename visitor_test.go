@@ -0,0 +1,72 @@
+package duplicates
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type visitorNode struct {
+	Name  string
+	Left  *visitorNode
+	Right *visitorNode
+}
+
+func TestOnPointer(t *testing.T) {
+	shared := &visitorNode{Name: "shared"}
+	root := &visitorNode{Name: "root", Left: shared, Right: shared}
+
+	var visits []string
+	finder := NewDuplicateFinder(WithPaths(), WithOnPointer(func(ptr TypedPointer, path Path, duplicate bool) VisitAction {
+		if ptr.Type == TypedPointerOf(root).Type {
+			visits = append(visits, fmt.Sprintf("%v:%v", path, duplicate))
+		}
+		return VisitContinue
+	}))
+	finder.ScanForPointers(root)
+	actual := strings.Join(visits, " ")
+	expected := ":false .Left:false .Right:true"
+	if actual != expected {
+		t.Errorf("Expected %v but got %v", expected, actual)
+	}
+}
+
+func TestOnPointerSkip(t *testing.T) {
+	hidden := &visitorNode{Name: "hidden"}
+	root := &visitorNode{Left: &visitorNode{Left: hidden, Right: hidden}}
+
+	finder := NewDuplicateFinder(WithOnPointer(func(ptr TypedPointer, path Path, duplicate bool) VisitAction {
+		if ptr == TypedPointerOf(root.Left) {
+			return VisitSkip
+		}
+		return VisitContinue
+	}))
+	finder.ScanForPointers(root)
+	if isRegistered(finder, TypedPointerOf(hidden)) {
+		t.Errorf("Expected the skipped subtree not to be scanned")
+	}
+	if finder.Truncated() {
+		t.Errorf("Expected skipping not to truncate the results")
+	}
+}
+
+func TestOnPointerStop(t *testing.T) {
+	values := []int{1, 2, 3}
+	root := []*int{&values[0], &values[1], &values[2], &values[0]}
+
+	visits := 0
+	finder := NewDuplicateFinder(WithOnPointer(func(ptr TypedPointer, path Path, duplicate bool) VisitAction {
+		visits++
+		if ptr == TypedPointerOf(&values[1]) {
+			return VisitStop
+		}
+		return VisitContinue
+	}))
+	finder.ScanForPointers(root)
+	if visits != 3 || !finder.Truncated() {
+		t.Errorf("Expected the scan to stop after 3 visits but got %v (truncated: %v)", visits, finder.Truncated())
+	}
+	if isRegistered(finder, TypedPointerOf(&values[2])) {
+		t.Errorf("Expected nothing after the stop to be scanned")
+	}
+}
@@ -0,0 +1,296 @@
+package duplicates
+
+import (
+	"reflect"
+)
+
+// EventKind identifies a walk event.
+type EventKind int
+
+const (
+	// A struct, followed by an EventField (and the field's events) for each
+	// field, and then EventLeaveStruct.
+	EventEnterStruct EventKind = iota
+	EventField
+	EventLeaveStruct
+	// A slice or array, followed by an EventElement (and the element's
+	// events) for each element, and then EventLeaveSlice.
+	EventEnterSlice
+	EventElement
+	EventLeaveSlice
+	// A map, followed by an EventKey (and the key's events) and an EventValue
+	// (and the value's events) for each entry in key order, and then
+	// EventLeaveMap.
+	EventEnterMap
+	EventKey
+	EventValue
+	EventLeaveMap
+	// Any other non-nil value (numbers, strings, channels, funcs, etc).
+	EventScalar
+	// A nil pointer, map, slice, or interface.
+	EventNil
+	// The first occurrence of a shared object. It is followed by the object's
+	// own events.
+	EventSharedObject
+	// A later occurrence of a shared object, which is not walked again.
+	EventBackReference
+)
+
+func (_this EventKind) String() string {
+	switch _this {
+	case EventEnterStruct:
+		return "EnterStruct"
+	case EventField:
+		return "Field"
+	case EventLeaveStruct:
+		return "LeaveStruct"
+	case EventEnterSlice:
+		return "EnterSlice"
+	case EventElement:
+		return "Element"
+	case EventLeaveSlice:
+		return "LeaveSlice"
+	case EventEnterMap:
+		return "EnterMap"
+	case EventKey:
+		return "Key"
+	case EventValue:
+		return "Value"
+	case EventLeaveMap:
+		return "LeaveMap"
+	case EventScalar:
+		return "Scalar"
+	case EventNil:
+		return "Nil"
+	case EventSharedObject:
+		return "SharedObject"
+	case EventBackReference:
+		return "BackReference"
+	default:
+		return "EventKind(?)"
+	}
+}
+
+// Event is a single step of a walk.
+type Event struct {
+	Kind EventKind
+	// The value the event is about: the struct, slice, map, key, scalar, or
+	// shared pointer. For EventField and EventElement, the field or element.
+	Value reflect.Value
+	// The field index (EventField) or element index (EventElement).
+	Index int
+	// The field name (EventField).
+	Name string
+	// The marker ID of the shared object (EventSharedObject and
+	// EventBackReference). IDs are assigned in discovery order, as in
+	// DuplicateTable.
+	ID int
+}
+
+// EventHandler receives walk events. Returning an error stops the walk, and
+// the error is returned from Walk.
+type EventHandler func(event Event) error
+
+// Walk walks value as a stream of events, following pointers and
+// interfaces transparently. Every shared object is walked only once, and
+// every later occurrence of it (including any that would close a cycle) is
+// reported as a back reference, which makes the walk safe for any object
+// graph.
+//
+// Map entries are walked in key order. Pointer keys (including those within
+// struct and array keys) that are shared come first, in the order they were
+// found, followed by the rest in address order, which isn't deterministic.
+func Walk(value interface{}, handler EventHandler) error {
+	return WalkRV(reflect.ValueOf(value), handler)
+}
+
+// WalkRV walks value as a stream of events. See Walk.
+func WalkRV(value reflect.Value, handler EventHandler) error {
+	finder := NewDuplicateFinder()
+	// The walk enters map keys, so a cycle through a key must be found.
+	finder.ScanMapKeys = true
+	finder.scanRoot(value, 0)
+	table := finder.Freeze()
+	walker := &eventWalker{
+		table:   table,
+		emitted: make([]bool, table.Len()),
+		handler: handler,
+	}
+	return walker.walk(value)
+}
+
+// Like scans, walks are driven by an explicit stack of pending work rather
+// than by recursion, so that deep graphs don't overflow the goroutine's stack.
+// Each container pushes its remaining events and children in reverse order.
+
+type walkOp uint8
+
+const (
+	// Walk the event's value.
+	walkValue walkOp = iota
+	// Walk the event's value, which is a struct field that may itself be
+	// shared through pointers to it.
+	walkField
+	// Send the event to the handler.
+	walkEmit
+)
+
+type walkItem struct {
+	op    walkOp
+	event Event
+}
+
+type eventWalker struct {
+	table   *DuplicateTable
+	emitted []bool
+	handler EventHandler
+	pending []walkItem
+}
+
+// Ranks a pointer map key by its marker ID, if it's shared.
+func (_this *eventWalker) rankKey(key reflect.Value) (rank int, ok bool) {
+	return _this.table.MarkerIDOfRV(key)
+}
+
+func (_this *eventWalker) walk(value reflect.Value) error {
+	_this.push(walkValue, Event{Value: value})
+	for len(_this.pending) > 0 {
+		last := len(_this.pending) - 1
+		item := _this.pending[last]
+		_this.pending[last] = walkItem{}
+		_this.pending = _this.pending[:last]
+
+		var err error
+		switch item.op {
+		case walkValue:
+			err = _this.walkValue(item.event.Value)
+		case walkField:
+			err = _this.walkField(item.event.Value)
+		case walkEmit:
+			err = _this.handler(item.event)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (_this *eventWalker) push(op walkOp, event Event) {
+	_this.pending = append(_this.pending, walkItem{op: op, event: event})
+}
+
+func (_this *eventWalker) walkValue(value reflect.Value) error {
+	switch value.Kind() {
+	case reflect.Invalid:
+		return _this.handler(Event{Kind: EventNil, Value: value})
+	case reflect.Interface:
+		if value.IsNil() {
+			return _this.handler(Event{Kind: EventNil, Value: value})
+		}
+		_this.push(walkValue, Event{Value: value.Elem()})
+		return nil
+	case reflect.Ptr:
+		if value.IsNil() {
+			return _this.handler(Event{Kind: EventNil, Value: value})
+		}
+		if shouldWalk, err := _this.walkShared(value); !shouldWalk || err != nil {
+			return err
+		}
+		_this.push(walkValue, Event{Value: value.Elem()})
+		return nil
+	case reflect.Map:
+		if value.IsNil() {
+			return _this.handler(Event{Kind: EventNil, Value: value})
+		}
+		if shouldWalk, err := _this.walkShared(value); !shouldWalk || err != nil {
+			return err
+		}
+		return _this.walkMap(value)
+	case reflect.Slice:
+		if value.IsNil() {
+			return _this.handler(Event{Kind: EventNil, Value: value})
+		}
+		if shouldWalk, err := _this.walkShared(value); !shouldWalk || err != nil {
+			return err
+		}
+		return _this.walkSlice(value)
+	case reflect.Array:
+		return _this.walkSlice(value)
+	case reflect.Struct:
+		return _this.walkStruct(value)
+	default:
+		return _this.handler(Event{Kind: EventScalar, Value: value})
+	}
+}
+
+func (_this *eventWalker) walkStruct(value reflect.Value) error {
+	if err := _this.handler(Event{Kind: EventEnterStruct, Value: value}); err != nil {
+		return err
+	}
+	_this.push(walkEmit, Event{Kind: EventLeaveStruct, Value: value})
+	plan := defaultPlanCache.planFor(value.Type())
+	for i := value.NumField() - 1; i >= 0; i-- {
+		field := value.Field(i)
+		_this.push(walkField, Event{Value: field})
+		_this.push(walkEmit, Event{Kind: EventField, Value: field, Index: i, Name: plan.fieldNames[i]})
+	}
+	return nil
+}
+
+func (_this *eventWalker) walkField(field reflect.Value) error {
+	if field.CanAddr() {
+		// The field itself may be shared through pointers to it.
+		if shouldWalk, err := _this.walkShared(field.Addr()); !shouldWalk || err != nil {
+			return err
+		}
+	}
+	return _this.walkValue(field)
+}
+
+func (_this *eventWalker) walkSlice(value reflect.Value) error {
+	if err := _this.handler(Event{Kind: EventEnterSlice, Value: value}); err != nil {
+		return err
+	}
+	_this.push(walkEmit, Event{Kind: EventLeaveSlice, Value: value})
+	for i := value.Len() - 1; i >= 0; i-- {
+		elem := value.Index(i)
+		_this.push(walkValue, Event{Value: elem})
+		_this.push(walkEmit, Event{Kind: EventElement, Value: elem, Index: i})
+	}
+	return nil
+}
+
+func (_this *eventWalker) walkMap(value reflect.Value) error {
+	if err := _this.handler(Event{Kind: EventEnterMap, Value: value}); err != nil {
+		return err
+	}
+	_this.push(walkEmit, Event{Kind: EventLeaveMap, Value: value})
+	entries := sortedMapEntries(value, _this.rankKey)
+	for i := len(entries) - 1; i >= 0; i-- {
+		key := entries[i].key
+		elem := entries[i].value
+		_this.push(walkValue, Event{Value: elem})
+		_this.push(walkEmit, Event{Kind: EventValue, Value: elem})
+		_this.push(walkValue, Event{Value: key})
+		_this.push(walkEmit, Event{Kind: EventKey, Value: key})
+	}
+	return nil
+}
+
+// Reports a shared object, returning whether its contents should be walked
+// (they shouldn't if this is a back reference).
+func (_this *eventWalker) walkShared(pointer reflect.Value) (shouldWalk bool, err error) {
+	if !isReferenceable(pointer) {
+		return true, nil
+	}
+	markerID, isDuplicate := _this.table.MarkerIDOfRV(pointer)
+	if !isDuplicate {
+		return true, nil
+	}
+	if _this.emitted[markerID] {
+		return false, _this.handler(Event{Kind: EventBackReference, Value: pointer, ID: markerID})
+	}
+	_this.emitted[markerID] = true
+	return true, _this.handler(Event{Kind: EventSharedObject, Value: pointer, ID: markerID})
+}
@@ -0,0 +1,119 @@
+package duplicates
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+type walkerNode struct {
+	Name string
+	Next *walkerNode
+	Tags map[string]int
+}
+
+func describeWalk(value interface{}) (string, error) {
+	events := []string{}
+	err := Walk(value, func(event Event) error {
+		switch event.Kind {
+		case EventField:
+			events = append(events, "Field:"+event.Name)
+		case EventScalar, EventKey:
+			events = append(events, fmt.Sprintf("%v:%v", event.Kind, event.Value))
+		case EventSharedObject, EventBackReference:
+			events = append(events, fmt.Sprintf("%v:%v", event.Kind, event.ID))
+		default:
+			events = append(events, event.Kind.String())
+		}
+		return nil
+	})
+	return strings.Join(events, " "), err
+}
+
+func TestWalk(t *testing.T) {
+	node := &walkerNode{Name: "a", Tags: map[string]int{"y": 2, "x": 1}}
+	node.Next = node
+
+	actual, err := describeWalk([]*walkerNode{node, nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "EnterSlice Element SharedObject:0 EnterStruct " +
+		"Field:Name Scalar:a Field:Next BackReference:0 " +
+		"Field:Tags EnterMap Key:x Scalar:x Value Scalar:1 Key:y Scalar:y Value Scalar:2 LeaveMap " +
+		"LeaveStruct Element Nil LeaveSlice"
+	if actual != expected {
+		t.Errorf("Expected\n%v\nbut got\n%v", expected, actual)
+	}
+}
+
+func TestWalkSharedField(t *testing.T) {
+	type holder struct {
+		Value int
+		Ref   *int
+	}
+	h := &holder{Value: 5}
+	h.Ref = &h.Value
+
+	actual, err := describeWalk(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "EnterStruct Field:Value SharedObject:0 Scalar:5 Field:Ref BackReference:0 LeaveStruct"
+	if actual != expected {
+		t.Errorf("Expected\n%v\nbut got\n%v", expected, actual)
+	}
+}
+
+func TestWalkStop(t *testing.T) {
+	stop := errors.New("stop")
+	count := 0
+	err := Walk([]int{1, 2, 3}, func(event Event) error {
+		count++
+		if event.Kind == EventScalar {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || count != 3 {
+		t.Errorf("Expected the walk to stop after 3 events but got %v events (%v)", count, err)
+	}
+}
+
+type walkerKeyNode struct {
+	M map[*walkerKeyNode]bool
+}
+
+func TestWalkMapKeyCycle(t *testing.T) {
+	node := &walkerKeyNode{}
+	node.M = map[*walkerKeyNode]bool{node: true}
+
+	actual, err := describeWalk(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "SharedObject:0 EnterStruct Field:M EnterMap Key:" + fmt.Sprint(node) +
+		" BackReference:0 Value Scalar:true LeaveMap LeaveStruct"
+	if actual != expected {
+		t.Errorf("Expected\n%v\nbut got\n%v", expected, actual)
+	}
+}
+
+func TestWalkDeepGraph(t *testing.T) {
+	// Far less stack than a recursive walk of this graph would need.
+	defer debug.SetMaxStack(debug.SetMaxStack(1 << 20))
+
+	head, _ := newDeepGraph()
+	shared := 0
+	err := Walk(head, func(event Event) error {
+		if event.Kind == EventSharedObject || event.Kind == EventBackReference {
+			shared++
+		}
+		return nil
+	})
+	if err != nil || shared != 2 {
+		t.Errorf("Expected the head to be shared once and referenced back once but got %v (%v)", shared, err)
+	}
+}
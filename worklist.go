@@ -0,0 +1,415 @@
+package duplicates
+
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// Scans are driven by an explicit stack of frames rather than by recursion,
+// so that the depth of the scanned graph is bounded only by memory rather
+// than by the goroutine's stack. Each frame holds the pending work of one
+// level of nesting, in the same order that a recursive walk would do it.
+
+type frameOp uint8
+
+const (
+	// Scan value.
+	opValue frameOp = iota
+	// Marks the end of a value scanned with SkipPanickingValues (see
+	// containment).
+	opContained
+	// Return from the dynamic value of an interface to the interface.
+	opPopStep
+	// Scan the element of a pointer, the elements of a slice or array, or the
+	// values of a map.
+	opElements
+	// Scan the keys (and possibly the values) of a map, as two children per
+	// entry.
+	opMapEntries
+	// Scan the fields of a struct.
+	opFields
+)
+
+type scanFrame struct {
+	op frameOp
+	// The value to scan, or the container whose children are being scanned.
+	value reflect.Value
+	// The child being scanned (or to scan next), and how many there are.
+	index int
+	count int
+	// Whether child index is currently being scanned, and the path node to
+	// return to once it has been (or the node to return to for opPopStep).
+	inChild bool
+	parent  int32
+	// Whether the container is a referent that ends along with the frame.
+	endsReferent bool
+	// The referrer to restore after each child (opElements, opMapEntries) or
+	// at the end (opFields).
+	referrer fieldRef
+
+	// opFields only.
+	plan           *typePlan
+	addressable    bool
+	wasExported    bool
+	referrerParent unsafe.Pointer
+
+	// opElements and opMapEntries on maps only.
+	cursor     int
+	sorted     bool
+	scanValues bool
+}
+
+// The iteration state of a map being scanned.
+type mapCursor struct {
+	// The entries in sorted order (if sorted).
+	entries []mapEntry
+	iter    mapIterator
+}
+
+// Frame stacks are recycled between scans, much like goroutine stacks are,
+// so that scanning with a fresh finder doesn't have to grow one every time.
+var frameStacks = sync.Pool{New: func() interface{} { return new([]scanFrame) }}
+
+// Takes a frame stack from frameStacks for the scan of a root.
+func (_this *DuplicateFinder) acquireFrames() {
+	if _this.pooledFrames == nil {
+		_this.pooledFrames = frameStacks.Get().(*[]scanFrame)
+		_this.frames = (*_this.pooledFrames)[:0]
+	}
+}
+
+// Returns the frame stack to frameStacks once the scan of a root is over.
+func (_this *DuplicateFinder) releaseFrames() {
+	if _this.pooledFrames == nil || len(_this.frames) > 0 {
+		return
+	}
+	*_this.pooledFrames = _this.frames
+	frameStacks.Put(_this.pooledFrames)
+	_this.pooledFrames = nil
+	_this.frames = nil
+}
+
+// Scans value and everything it leads to.
+func (_this *DuplicateFinder) scanValue(value reflect.Value) {
+	base := len(_this.frames)
+	defer _this.discardFrames(base)
+	_this.pushValue(value)
+	_this.runFrames(base)
+}
+
+// Scans the elements of a pointer, map, slice, or array.
+func (_this *DuplicateFinder) scanElements(value reflect.Value) {
+	base := len(_this.frames)
+	defer _this.discardFrames(base)
+	_this.pushElements(value, false)
+	_this.runFrames(base)
+}
+
+// Processes frames until only base of them remain.
+func (_this *DuplicateFinder) runFrames(base int) {
+	if _this.SkipPanickingValues && _this.containing {
+		for len(_this.frames) > base {
+			_this.stepContained(base)
+		}
+		return
+	}
+	for len(_this.frames) > base {
+		_this.stepFrame()
+	}
+}
+
+// Does the next piece of work of the innermost frame.
+func (_this *DuplicateFinder) stepFrame() {
+	frame := &_this.frames[len(_this.frames)-1]
+	switch frame.op {
+	case opValue:
+		value := frame.value
+		_this.popFrame()
+		_this.scanKind(value)
+	case opContained:
+		_this.popFrame()
+	case opPopStep:
+		parent := frame.parent
+		_this.popFrame()
+		_this.popStep(parent)
+	case opElements, opMapEntries:
+		_this.stepElements(frame)
+	case opFields:
+		_this.stepFields(frame)
+	}
+}
+
+func (_this *DuplicateFinder) pushFrame(frame scanFrame) {
+	_this.frames = append(_this.frames, frame)
+}
+
+// Removes the innermost frame, releasing whatever it holds.
+func (_this *DuplicateFinder) popFrame() {
+	last := len(_this.frames) - 1
+	frame := &_this.frames[last]
+	switch {
+	case frame.op == opContained:
+		_this.containments = _this.containments[:len(_this.containments)-1]
+	case frame.value.Kind() == reflect.Map && (frame.op == opElements || frame.op == opMapEntries):
+		_this.releaseCursor()
+	}
+	*frame = scanFrame{}
+	_this.frames = _this.frames[:last]
+}
+
+// Abandons all frames beyond the first count, after a panic skipped them.
+func (_this *DuplicateFinder) discardFrames(count int) {
+	for len(_this.frames) > count {
+		_this.popFrame()
+	}
+}
+
+func (_this *DuplicateFinder) pushValue(value reflect.Value) {
+	if _this.SkipPanickingValues && _this.containing {
+		_this.pushContainment()
+	}
+	_this.pushFrame(scanFrame{op: opValue, value: value})
+}
+
+// Starts scanning the elements of a pointer, map, slice, or array. If
+// endsReferent is true, the current referent ends once they're scanned.
+func (_this *DuplicateFinder) pushElements(value reflect.Value, endsReferent bool) {
+	if _this.atDepthLimit() {
+		if endsReferent {
+			_this.endReferent()
+		}
+		return
+	}
+	frame := scanFrame{
+		op:           opElements,
+		value:        value,
+		endsReferent: endsReferent,
+		referrer:     _this.referrer,
+	}
+	switch value.Kind() {
+	case reflect.Ptr:
+		frame.count = 1
+		frame.index = _this.loopStart()
+	case reflect.Map:
+		plan := _this.plans.planFor(value.Type())
+		frame.count = _this.limitElements(value, value.Len())
+		frame.sorted = _this.scan != nil || _this.SortMapKeys
+		if _this.ScanMapKeys && plan.keyScannable {
+			frame.op = opMapEntries
+			frame.count *= 2
+			frame.scanValues = _this.isElemScannable(plan)
+		}
+		frame.cursor = _this.acquireCursor()
+		cursor := _this.mapCursors[frame.cursor]
+		if frame.sorted {
+			cursor.entries = sortedMapEntries(value, _this.keyRankOf(value))
+			frame.index = _this.loopStart()
+		} else {
+			resetMapIterator(&cursor.iter, value)
+		}
+	case reflect.Slice, reflect.Array:
+		frame.count = _this.limitElements(value, value.Len())
+		frame.index = _this.loopStart()
+	}
+	_this.pushFrame(frame)
+}
+
+func (_this *DuplicateFinder) stepElements(frame *scanFrame) {
+	if frame.inChild {
+		frame.inChild = false
+		_this.referrer = frame.referrer
+		_this.leaveChild(frame.parent)
+		if _this.pausedDuring(frame.index) {
+			_this.endElements()
+			return
+		}
+		frame.index++
+	}
+	for ; frame.index < frame.count; frame.index++ {
+		if _this.pauseBefore(frame.index) {
+			break
+		}
+		child, step, ok := _this.childAt(frame)
+		if !ok {
+			continue
+		}
+		frame.inChild = true
+		frame.parent = _this.enterChild(step)
+		_this.referrer = fieldRef{}
+		_this.pushValue(child)
+		return
+	}
+	_this.endElements()
+}
+
+func (_this *DuplicateFinder) endElements() {
+	endsReferent := _this.frames[len(_this.frames)-1].endsReferent
+	_this.popFrame()
+	if endsReferent {
+		_this.endReferent()
+	}
+}
+
+// Returns the child at the frame's index, or ok = false if there is nothing
+// to scan there.
+func (_this *DuplicateFinder) childAt(frame *scanFrame) (child reflect.Value, step PathStep, ok bool) {
+	value := frame.value
+	i := frame.index
+	switch value.Kind() {
+	case reflect.Ptr:
+		return value.Elem(), PathStep{Kind: StepPointerElem}, true
+	case reflect.Slice, reflect.Array:
+		return value.Index(i), PathStep{Kind: StepElem, Index: i}, true
+	}
+
+	cursor := _this.mapCursors[frame.cursor]
+	if frame.op == opMapEntries {
+		isKey := i%2 == 0
+		if !isKey && !frame.scanValues {
+			return
+		}
+		if frame.sorted {
+			entry := cursor.entries[i/2]
+			if isKey {
+				return entry.key, PathStep{Kind: StepMapKey, Key: entry.key}, true
+			}
+			return entry.value, PathStep{Kind: StepMapValue, Key: entry.key}, true
+		}
+		if isKey && !cursor.iter.Next() {
+			frame.count = i
+			return
+		}
+		key := cursor.iter.Key()
+		if isKey {
+			return key, PathStep{Kind: StepMapKey, Key: key}, true
+		}
+		return cursor.iter.Value(), PathStep{Kind: StepMapValue, Key: key}, true
+	}
+
+	if frame.sorted {
+		entry := cursor.entries[i]
+		return entry.value, PathStep{Kind: StepMapValue, Key: entry.key}, true
+	}
+	if !cursor.iter.Next() {
+		frame.count = i
+		return
+	}
+	step = PathStep{Kind: StepMapValue}
+	if _this.RecordPaths || _this.containing {
+		// Copying out the key allocates, so only do it when needed.
+		step.Key = cursor.iter.Key()
+	}
+	return cursor.iter.Value(), step, true
+}
+
+// Starts scanning the fields of a struct.
+func (_this *DuplicateFinder) pushFields(value reflect.Value) {
+	if _this.atDepthLimit() {
+		return
+	}
+	plan := _this.plans.planFor(value.Type())
+	if (plan.enumerable || plan.ptrEnumerable) && _this.scanEnumerated(value, plan) {
+		return
+	}
+	frame := scanFrame{
+		op:             opFields,
+		value:          value,
+		plan:           plan,
+		wasExported:    _this.exported,
+		referrer:       _this.referrer,
+		referrerParent: _this.referrerParent,
+	}
+	_this.referrerParent = nil
+	if value.CanAddr() {
+		_this.referrerParent = unsafe.Pointer(value.UnsafeAddr())
+		frame.addressable = true
+		frame.count = value.NumField()
+	} else {
+		frame.count = len(_this.unaddressableFields(plan))
+	}
+	frame.index = _this.loopStart()
+	_this.pushFrame(frame)
+}
+
+// Returns the fields of a struct that isn't addressable that must be scanned,
+// since there is no point in visiting the others.
+func (_this *DuplicateFinder) unaddressableFields(plan *typePlan) []int {
+	if _this.RegisterChannels || _this.RegisterFuncs || _this.RegisterUnsafePointers {
+		return plan.referenceFields
+	}
+	return plan.scannableFields
+}
+
+func (_this *DuplicateFinder) stepFields(frame *scanFrame) {
+	if frame.inChild {
+		frame.inChild = false
+		if frame.addressable {
+			_this.endReferent()
+		}
+		_this.leaveChild(frame.parent)
+		if _this.pausedDuring(frame.index) {
+			_this.endFields()
+			return
+		}
+		frame.index++
+	}
+	value := frame.value
+	plan := frame.plan
+	excluded := _this.excludedFieldsOf(value.Type(), plan)
+	for ; frame.index < frame.count; frame.index++ {
+		if _this.pauseBefore(frame.index) {
+			break
+		}
+		i := frame.index
+		if !frame.addressable {
+			i = _this.unaddressableFields(plan)[frame.index]
+		}
+		if _this.isFieldExcluded(value.Type(), plan, i, excluded) {
+			continue
+		}
+		_this.exported = frame.wasExported && plan.fieldExported[i]
+		parent := _this.enterChild(PathStep{Kind: StepField, Index: i, Container: value.Type()})
+		field := value.Field(i)
+		referrer := fieldRef{container: value.Type(), index: i}
+		if frame.addressable {
+			if !_this.beginAddressableField(field, referrer) {
+				_this.leaveChild(parent)
+				if _this.pausedDuring(frame.index) {
+					break
+				}
+				continue
+			}
+		} else {
+			_this.referrer = referrer
+		}
+		frame.inChild = true
+		frame.parent = parent
+		_this.pushValue(field)
+		return
+	}
+	_this.endFields()
+}
+
+func (_this *DuplicateFinder) endFields() {
+	frame := &_this.frames[len(_this.frames)-1]
+	_this.exported = frame.wasExported
+	_this.referrer = frame.referrer
+	_this.referrerParent = frame.referrerParent
+	_this.popFrame()
+}
+
+func (_this *DuplicateFinder) acquireCursor() int {
+	if _this.cursorsInUse == len(_this.mapCursors) {
+		_this.mapCursors = append(_this.mapCursors, &mapCursor{})
+	}
+	_this.cursorsInUse++
+	return _this.cursorsInUse - 1
+}
+
+func (_this *DuplicateFinder) releaseCursor() {
+	_this.cursorsInUse--
+	cursor := _this.mapCursors[_this.cursorsInUse]
+	cursor.entries = nil
+	cursor.iter = mapIterator{}
+}
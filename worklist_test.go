@@ -0,0 +1,66 @@
+package duplicates
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+type worklistNode struct {
+	Next     *worklistNode
+	ByName   map[string]interface{}
+	Children []*worklistNode
+}
+
+// Returns a cycle of 30000 nodes linked through pointers, maps, and slices.
+func newDeepGraph() (head, tail *worklistNode) {
+	tail = &worklistNode{}
+	head = tail
+	for i := 0; i < 30000; i++ {
+		switch i % 3 {
+		case 0:
+			head = &worklistNode{Next: head}
+		case 1:
+			head = &worklistNode{ByName: map[string]interface{}{"next": head}}
+		case 2:
+			head = &worklistNode{Children: []*worklistNode{head}}
+		}
+	}
+	tail.Next = head
+	return
+}
+
+func TestDeepGraph(t *testing.T) {
+	// Far less stack than a recursive scan of this graph would need.
+	defer debug.SetMaxStack(debug.SetMaxStack(1 << 20))
+
+	head, tail := newDeepGraph()
+	finder := NewDuplicateFinder()
+	finder.ScanForPointers(head)
+	if !finder.IsDuplicatePointer(head) {
+		t.Errorf("Expected the head to be a duplicate")
+	}
+	if finder.IsDuplicatePointer(tail) {
+		t.Errorf("Expected the tail not to be a duplicate")
+	}
+	if depth := finder.Stats().MaxDepth; depth < 30000 {
+		t.Errorf("Expected a depth of at least 30000 but got %v", depth)
+	}
+
+	var cycle []TypedPointer
+	for _, component := range StronglyConnectedComponents(head) {
+		if len(component) > len(cycle) {
+			cycle = component
+		}
+	}
+	if len(cycle) < 30000 {
+		t.Errorf("Expected a component of at least 30000 pointers but got %v", len(cycle))
+	}
+
+	paths := PathsTo(head, TypedPointerOf(tail), 0)
+	if len(paths) != 1 {
+		t.Fatalf("Expected 1 path but got %v", len(paths))
+	}
+	if length := paths[0].Len(); length < 30000 {
+		t.Errorf("Expected a path of at least 30000 steps but got %v", length)
+	}
+}
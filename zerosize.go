@@ -0,0 +1,62 @@
+package duplicates
+
+import (
+	"reflect"
+)
+
+// ZeroSizePolicy determines how a finder treats pointers to zero-sized
+// objects. Since zero-sized objects occupy no memory, the runtime is free to
+// give them all the same address (and often does), so such pointers are
+// usually spurious duplicates.
+type ZeroSizePolicy int
+
+const (
+	// Register pointers to zero-sized objects, but leave their duplicates
+	// out of DuplicatePointers, reports, and tables, listing them in
+	// DuplicateReport.ZeroSizedDuplicates instead. This is the default.
+	ZeroSizeGroup ZeroSizePolicy = iota
+	// Report zero-sized duplicates like any other duplicate.
+	ZeroSizeReport
+	// Don't register pointers to zero-sized objects at all.
+	ZeroSizeIgnore
+)
+
+func (_this ZeroSizePolicy) String() string {
+	switch _this {
+	case ZeroSizeReport:
+		return "ZeroSizeReport"
+	case ZeroSizeIgnore:
+		return "ZeroSizeIgnore"
+	case ZeroSizeGroup:
+		return "ZeroSizeGroup"
+	default:
+		return "ZeroSizePolicy(?)"
+	}
+}
+
+// Returns true if pointerType is a pointer or slice whose referenced
+// object(s) have zero size.
+func hasZeroSizedReferent(pointerType reflect.Type) bool {
+	switch pointerType.Kind() {
+	case reflect.Ptr, reflect.Slice:
+		return pointerType.Elem().Size() == 0
+	default:
+		return false
+	}
+}
+
+func (_this *DuplicateFinder) zeroSizedDuplicates() (duplicates []TypedPointer) {
+	for _, record := range _this.records {
+		if record.zeroSized && record.sightings > 1 {
+			duplicates = append(duplicates, record.pointer)
+		}
+	}
+	return
+}
+
+// ZeroSizedDuplicates returns the pointers to zero-sized objects that were
+// found more than once, in discovery order. This only works if the finder
+// had ZeroSizedPointers set to ZeroSizeGroup while scanning.
+func (_this *DuplicateReport) ZeroSizedDuplicates() []TypedPointer {
+	return _this.zeroSized
+}
@@ -0,0 +1,66 @@
+package duplicates
+
+import (
+	"reflect"
+	"testing"
+)
+
+type zeroSizeHolder struct {
+	A     *struct{}
+	B     *struct{}
+	Empty []struct{}
+	Value *int
+}
+
+func newZeroSizeHolder() (*zeroSizeHolder, *int) {
+	v := 1
+	a := &struct{}{}
+	return &zeroSizeHolder{A: a, B: a, Empty: make([]struct{}, 3), Value: &v}, &v
+}
+
+func TestZeroSizeReport(t *testing.T) {
+	holder, _ := newZeroSizeHolder()
+	finder := NewDuplicateFinder()
+	finder.ScanForPointers(holder)
+	if finder.IsDuplicatePointer(holder.A) {
+		t.Errorf("Expected zero-sized duplicates not to be reported by default")
+	}
+
+	finder = NewDuplicateFinder(WithZeroSizedPointers(ZeroSizeReport))
+	finder.ScanForPointers(holder)
+	if !finder.IsDuplicatePointer(holder.A) {
+		t.Errorf("Expected zero-sized duplicates to be reported with ZeroSizeReport")
+	}
+}
+
+func TestZeroSizeIgnore(t *testing.T) {
+	holder, _ := newZeroSizeHolder()
+	finder := NewDuplicateFinder()
+	finder.ZeroSizedPointers = ZeroSizeIgnore
+	finder.ScanForPointers(holder)
+	if _, ok := finder.recordIndex[TypedPointerOf(holder.A)]; ok {
+		t.Errorf("Expected zero-sized pointers to not be registered")
+	}
+	if _, ok := finder.recordIndex[TypedPointerOf(holder.Empty)]; ok {
+		t.Errorf("Expected slices of zero-sized elements to not be registered")
+	}
+}
+
+func TestZeroSizeGroup(t *testing.T) {
+	holder, v := newZeroSizeHolder()
+	finder := NewDuplicateFinder()
+	finder.ZeroSizedPointers = ZeroSizeGroup
+	finder.ScanForPointers([]interface{}{holder, v})
+
+	if finder.IsDuplicatePointer(holder.A) {
+		t.Errorf("Expected zero-sized duplicates to be left out of DuplicatePointers")
+	}
+	report := finder.Report()
+	if report.Len() != 1 || !report.IsDuplicate(TypedPointerOf(v)) {
+		t.Errorf("Expected only &v in the report")
+	}
+	expected := []TypedPointer{TypedPointerOf(holder.A)}
+	if !reflect.DeepEqual(report.ZeroSizedDuplicates(), expected) {
+		t.Errorf("Expected %v but got %v", expected, report.ZeroSizedDuplicates())
+	}
+}